@@ -0,0 +1,87 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+// BackfillWorker implemented by workers created by NewWorker
+type BackfillWorker interface {
+	NextAt(t time.Time) (int64, error)
+}
+
+// maxBackfillMillis NextAt 在内存中记录的不同毫秒数上限
+const maxBackfillMillis = 1 << 16
+
+// NextAt return id timestamped at t, for migration jobs minting ids of historical records
+// that sort correctly among real ones. t must be before the worker was created, so that
+// backfilled ids never collide with ids this worker issues with Next. Uniqueness per
+// millisecond is only tracked in this worker's memory: ids issued for the same millisecond
+// by an earlier process, or by another worker, with the same worker id are issued again, so
+// give backfills a dedicated worker id that is never used for live ids, and run a given time
+// range through a single worker once.
+// Up to 65536 distinct milliseconds are tracked; beyond that, the half farthest from t is
+// forgotten and later calls in the forgotten range fail, so process time ranges in order.
+func (w *worker) NextAt(t time.Time) (int64, error) {
+	if w.unsignedOnly() {
+		return 0, fmt.Errorf("layout is unsigned, use NextUint64")
+	}
+	timestamp := t.UnixMilli()
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.closed {
+		return 0, ErrClosed
+	}
+	if timestamp >= w.created {
+		return 0, fmt.Errorf("backfill time %v is not before the worker was created", t)
+	}
+	if w.backfill == nil {
+		w.backfill = make(map[int64]int64)
+		w.backfillAbove = math.MaxInt64
+	}
+	if timestamp <= w.backfillBelow || timestamp >= w.backfillAbove {
+		return 0, fmt.Errorf("backfill time %v is in a range no longer tracked, process time ranges in order", t)
+	}
+	sequence, ok := w.backfill[timestamp]
+	if !ok && len(w.backfill) >= maxBackfillMillis {
+		w.evictBackfill(timestamp)
+	}
+	if sequence > w.layout.sequenceMask() {
+		return 0, fmt.Errorf("sequence exhausted for backfill time %v", t)
+	}
+	id, err := signed(w.compose(timestamp, sequence))
+	if err != nil {
+		return 0, err
+	}
+	w.backfill[timestamp] = sequence + 1
+	w.issued.Add(1)
+	return id, nil
+}
+
+// evictBackfill 保留最接近 timestamp 的一半毫秒，更早和更晚的范围此后拒绝，调用方需持有 w.mutex
+func (w *worker) evictBackfill(timestamp int64) {
+	keys := make([]int64, 0, len(w.backfill))
+	for k := range w.backfill {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	keep := len(keys) / 2
+	pos, _ := slices.BinarySearch(keys, timestamp)
+	start := min(max(pos-keep/2, 0), len(keys)-keep)
+	if start > 0 {
+		w.backfillBelow = keys[start-1]
+	}
+	if end := start + keep; end < len(keys) {
+		w.backfillAbove = keys[end]
+	}
+	for _, k := range keys[:start] {
+		delete(w.backfill, k)
+	}
+	for _, k := range keys[start+keep:] {
+		delete(w.backfill, k)
+	}
+}
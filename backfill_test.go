@@ -0,0 +1,77 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_NextAt(t *testing.T) {
+	w, err := newWorker(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Now().Add(-24 * time.Hour)
+	seen := make(map[int64]struct{})
+	var last int64
+	for i := 0; i <= sequenceMask; i++ {
+		id, err := w.NextAt(at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := seen[id]; ok {
+			t.Fatalf("duplicate id %d", id)
+		}
+		seen[id] = struct{}{}
+		if id <= last {
+			t.Fatalf("id %d not greater than previous %d", id, last)
+		}
+		last = id
+	}
+	if _, err := w.NextAt(at); err == nil {
+		t.Error("expected sequence exhausted error")
+	}
+
+	earlier, err := w.NextAt(at.Add(-time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	live, err := w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(earlier < last && last < live) {
+		t.Errorf("backfilled ids should sort before live ids: %d, %d, %d", earlier, last, live)
+	}
+
+	if _, err := w.NextAt(time.Now().Add(time.Second)); err == nil {
+		t.Error("expected error for time after worker creation")
+	}
+	if _, err := w.NextAt(time.UnixMilli(twepoch - 1)); err == nil {
+		t.Error("expected error for time before epoch")
+	}
+}
+
+func Test_NextAtEviction(t *testing.T) {
+	w, err := newWorker(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().Add(-24 * time.Hour)
+	for i := 0; i <= maxBackfillMillis; i++ {
+		if _, err := w.NextAt(start.Add(time.Duration(i) * time.Millisecond)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(w.backfill) > maxBackfillMillis {
+		t.Fatalf("%d milliseconds tracked, want at most %d", len(w.backfill), maxBackfillMillis)
+	}
+	// 按时间顺序继续不受影响，已遗忘的早期毫秒被拒绝而不是重复签发
+	if _, err := w.NextAt(start.Add((maxBackfillMillis + 1) * time.Millisecond)); err != nil {
+		t.Errorf("next millisecond in order = %v", err)
+	}
+	if _, err := w.NextAt(start); err == nil {
+		t.Error("forgotten millisecond accepted")
+	}
+}
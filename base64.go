@@ -0,0 +1,40 @@
+package snowflake
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+)
+
+// sortableAlphabet base64url 字符集按 ASCII 升序重新排列，编码结果的字典序与原始字节序一致
+const sortableAlphabet = "-0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz"
+
+// SortableEncoding base64url variant whose lexicographic order matches byte order, without padding
+var SortableEncoding = base64.NewEncoding(sortableAlphabet).WithPadding(base64.NoPadding).Strict()
+
+// sortableLen 64 位 id 编码后的固定长度
+const sortableLen = 11
+
+// EncodeSortable return fixed width (11 chars) base64url form of id,
+// for non-negative ids string order equals numeric order
+func EncodeSortable(id int64) string {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(id))
+	return SortableEncoding.EncodeToString(b[:])
+}
+
+// DecodeSortable parse id from EncodeSortable output
+func DecodeSortable(s string) (int64, error) {
+	if len(s) != sortableLen {
+		return 0, fmt.Errorf("invalid sortable id %q: length must be %d", s, sortableLen)
+	}
+	var b [8]byte
+	n, err := SortableEncoding.Decode(b[:], []byte(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid sortable id %q: %v", s, err)
+	}
+	if n != 8 {
+		return 0, fmt.Errorf("invalid sortable id %q", s)
+	}
+	return int64(binary.BigEndian.Uint64(b[:])), nil
+}
@@ -0,0 +1,49 @@
+package snowflake
+
+import (
+	"math"
+	"testing"
+	"testing/quick"
+)
+
+func Test_EncodeSortable(t *testing.T) {
+	roundTrip := func(id int64) bool {
+		if id < 0 {
+			id = -id
+		}
+		s := EncodeSortable(id)
+		got, err := DecodeSortable(s)
+		return err == nil && got == id && len(s) == sortableLen
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+
+	ordered := func(a, b int64) bool {
+		if a < 0 {
+			a = -a
+		}
+		if b < 0 {
+			b = -b
+		}
+		return (a < b) == (EncodeSortable(a) < EncodeSortable(b))
+	}
+	if err := quick.Check(ordered, nil); err != nil {
+		t.Error(err)
+	}
+
+	for _, id := range []int64{0, 1, math.MaxInt64} {
+		got, err := DecodeSortable(EncodeSortable(id))
+		if err != nil || got != id {
+			t.Errorf("round trip %d: got %d, %v", id, got, err)
+		}
+	}
+}
+
+func Test_DecodeSortable_invalid(t *testing.T) {
+	for _, s := range []string{"", "abc", "-----------=", "----------+", "----------1"} {
+		if _, err := DecodeSortable(s); err == nil {
+			t.Errorf("DecodeSortable(%q) expected error", s)
+		}
+	}
+}
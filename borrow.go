@@ -0,0 +1,72 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// WithBorrowAhead let a saturated worker borrow up to max milliseconds ahead of the clock:
+// once a millisecond's sequence is used up it moves on to the next millisecond right away,
+// whether or not the clock got there, and only waits once its timestamp is max ahead. Bursts
+// above the per millisecond capacity are smoothed into the following milliseconds instead of
+// stalling every caller, the debt is repaid as soon as the burst ends and the clock catches up.
+// While in debt ids are timestamped up to max in the future, and a restarted process may
+// reissue them if it starts before the debt was repaid: keep max small or use WithStateStore.
+// Stats().BorrowStats reports the debt. max is truncated to milliseconds.
+func WithBorrowAhead(max time.Duration) Option {
+	return func(w *worker) {
+		w.borrow = max.Milliseconds()
+	}
+}
+
+// BorrowStats milliseconds borrowed ahead of the clock, see WithBorrowAhead
+type BorrowStats struct {
+	Borrowed    uint64        // milliseconds moved to before the clock reached them
+	BorrowDepth time.Duration // how far the latest id's timestamp is ahead of the clock
+}
+
+type borrowCounter struct {
+	borrowed  atomic.Uint64
+	borrowing bool // 最近的时间戳是借来的，调用方需持有 w.mutex
+}
+
+// BorrowStats return borrow statistics of the worker
+func (w *worker) BorrowStats() BorrowStats {
+	s := BorrowStats{Borrowed: w.borrowCounter.borrowed.Load()}
+	if w.borrow > 0 {
+		if depth := w.counters.last.Load() - w.now(); depth > 0 {
+			s.BorrowDepth = time.Duration(depth) * time.Millisecond
+		}
+	}
+	return s
+}
+
+// repaid 借用期间时钟尚未追上时沿用借来的时间戳，调用方需持有 w.mutex
+func (w *worker) repaid(timestamp int64) int64 {
+	if timestamp >= w.lastTimestamp {
+		w.borrowCounter.borrowing = false
+		return timestamp
+	}
+	return w.lastTimestamp
+}
+
+// canBorrow 下一毫秒是否在借用上限内，调用方需持有 w.mutex
+func (w *worker) canBorrow() bool {
+	return w.borrow > 0 && w.lastTimestamp+1-w.timestamp() <= w.borrow
+}
+
+// borrowNext 序列用尽后借用下一毫秒，超出上限时等待时钟追上，调用方需持有 w.mutex
+func (w *worker) borrowNext(ctx context.Context) int64 {
+	next := w.lastTimestamp + 1
+	if !w.canBorrow() {
+		w.tilNextMillis(ctx, next-w.borrow-1, "borrow")
+	}
+	if next > w.timestamp() {
+		w.borrowCounter.borrowing = true
+		w.borrowCounter.borrowed.Add(1)
+	}
+	return next
+}
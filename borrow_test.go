@@ -0,0 +1,72 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_BorrowAhead(t *testing.T) {
+	var ms atomic.Int64
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	ms.Store(start)
+	clock := func() time.Time { return time.UnixMilli(ms.Load()) }
+	l := Layout{Epoch: DefaultLayout.Epoch, TimestampBits: 41, SequenceBits: 2}
+	w, err := newWorker(0, 0, WithLayout(l), WithClock(clock), WithBorrowAhead(3*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 每毫秒 4 个 id，借用 3 毫秒后共 16 个 id 无需等待
+	ids, err := w.NextN(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range ids {
+		p := l.Decompose(ID(id))
+		if want := start + int64(i/4); p.Time.UnixMilli() != want || p.Sequence != int64(i%4) {
+			t.Fatalf("id %d = %+v, want millisecond %d", i, p, want)
+		}
+	}
+	st := w.Stats()
+	if st.Borrowed != 3 || st.BorrowDepth != 3*time.Millisecond || st.Rollovers != 3 {
+		t.Errorf("stats = %+v", st.BorrowStats)
+	}
+	if _, ok := w.TryNext(); ok {
+		t.Error("TryNext borrowed beyond the limit")
+	}
+
+	// 时钟前进 1 毫秒后可以再借 1 毫秒
+	ms.Add(1)
+	more, err := w.NextN(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := l.Decompose(ID(more[0])); p.Time.UnixMilli() != start+4 || more[0] <= ids[15] {
+		t.Errorf("after catch up = %+v", p)
+	}
+
+	// 超出上限时等待时钟
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		ms.Add(1)
+	}()
+	id, err := w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := l.Decompose(ID(id)); p.Time.UnixMilli() != start+5 {
+		t.Errorf("after waiting = %+v", p)
+	}
+
+	// 时钟越过借用的时间戳后还清
+	ms.Store(start + 10)
+	if id, err := w.Next(); err != nil || l.Decompose(ID(id)).Time.UnixMilli() != start+10 {
+		t.Errorf("after repaying = %d, %v", id, err)
+	}
+	if st := w.Stats(); st.BorrowDepth != 0 || st.Borrowed != 5 {
+		t.Errorf("repaid stats = %+v", st.BorrowStats)
+	}
+}
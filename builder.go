@@ -0,0 +1,161 @@
+package snowflake
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// LayoutBuilder fluent definition of a custom id scheme, including timestamp units other
+// than the millisecond, compiled by Build into a Codec:
+//
+//	c, err := snowflake.NewLayoutBuilder().Timestamp(39, 10*time.Millisecond).Node(16).Sequence(8).Epoch(t).Build()
+//
+// Fields are laid out from high to low bits as timestamp, datacenter, worker (or node),
+// sequence, whatever order the methods are called in. Errors are reported by Build.
+type LayoutBuilder struct {
+	layout Layout
+	unit   time.Duration
+	err    error
+}
+
+// NewLayoutBuilder return a builder with no fields and a millisecond timestamp unit
+func NewLayoutBuilder() *LayoutBuilder {
+	return &LayoutBuilder{unit: time.Millisecond}
+}
+
+// Timestamp use bits timestamp bits counting unit ticks since the epoch
+func (b *LayoutBuilder) Timestamp(bits uint8, unit time.Duration) *LayoutBuilder {
+	if unit <= 0 && b.err == nil {
+		b.err = fmt.Errorf("timestamp unit must be positive, got %s", unit)
+	}
+	b.layout.TimestampBits, b.unit = bits, unit
+	return b
+}
+
+// Datacenter use bits datacenter bits
+func (b *LayoutBuilder) Datacenter(bits uint8) *LayoutBuilder {
+	b.layout.DatacenterBits = bits
+	return b
+}
+
+// Worker use bits worker bits
+func (b *LayoutBuilder) Worker(bits uint8) *LayoutBuilder {
+	b.layout.WorkerBits = bits
+	return b
+}
+
+// Node use bits machine bits without a datacenter split, decoded as the worker id (see WithMachineID)
+func (b *LayoutBuilder) Node(bits uint8) *LayoutBuilder {
+	b.layout.DatacenterBits, b.layout.WorkerBits = 0, bits
+	return b
+}
+
+// Sequence use bits sequence bits
+func (b *LayoutBuilder) Sequence(bits uint8) *LayoutBuilder {
+	b.layout.SequenceBits = bits
+	return b
+}
+
+// Epoch count the timestamp from t
+func (b *LayoutBuilder) Epoch(t time.Time) *LayoutBuilder {
+	b.layout.Epoch = t
+	return b
+}
+
+// Unsigned allow the top bit, see Layout.Unsigned
+func (b *LayoutBuilder) Unsigned() *LayoutBuilder {
+	b.layout.Unsigned = true
+	return b
+}
+
+// Build validate the scheme and return its codec
+func (b *LayoutBuilder) Build() (*Codec, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if err := b.layout.Validate(); err != nil {
+		return nil, err
+	}
+	if b.unit%time.Millisecond != 0 && b.layout.maxElapsed() > int64(math.MaxInt64/b.unit) {
+		return nil, fmt.Errorf("%d timestamp bits of %s span more than 292 years", b.layout.TimestampBits, b.unit)
+	}
+	c := &Codec{layout: b.layout, unit: b.unit}
+	if b.unit%time.Millisecond == 0 {
+		c.unitMillis = b.unit.Milliseconds()
+	}
+	return c, nil
+}
+
+// Codec encoder and decoder of a scheme built with LayoutBuilder, safe for concurrent use
+type Codec struct {
+	layout     Layout // 各字段位宽和 epoch，时间戳以 unit 计
+	unit       time.Duration
+	unitMillis int64 // unit 为整毫秒时的毫秒数，按毫秒计算避免纳秒溢出；否则为 0
+}
+
+// Unit return the timestamp unit
+func (c *Codec) Unit() time.Duration {
+	return c.unit
+}
+
+// Layout return the scheme as a Layout for WithLayout and the Layout based helpers, only
+// for millisecond units as workers issue one timestamp per millisecond
+func (c *Codec) Layout() (Layout, error) {
+	if c.unit != time.Millisecond {
+		return Layout{}, fmt.Errorf("timestamp unit %s is not a millisecond", c.unit)
+	}
+	return c.layout, nil
+}
+
+// ticks t 距 epoch 的 unit 数，早于 epoch 时为负
+func (c *Codec) ticks(t time.Time) int64 {
+	if c.unitMillis > 0 {
+		d := t.UnixMilli() - c.layout.Epoch.UnixMilli()
+		if d < 0 {
+			return -1
+		}
+		return d / c.unitMillis
+	}
+	d := t.Sub(c.layout.Epoch)
+	if d < 0 {
+		return -1
+	}
+	return int64(d / c.unit)
+}
+
+// Encode return the id of the fields, t truncated to the timestamp unit
+func (c *Codec) Encode(t time.Time, datacenterID, workerID, sequence int64) (uint64, error) {
+	l := c.layout
+	ticks := c.ticks(t)
+	switch {
+	case ticks < 0:
+		return 0, fmt.Errorf("time %s is before the epoch", t.UTC().Format(time.RFC3339Nano))
+	case ticks > l.maxElapsed():
+		return 0, fmt.Errorf("time %s exceeds %d timestamp bits", t.UTC().Format(time.RFC3339Nano), l.TimestampBits)
+	case datacenterID < 0 || datacenterID > l.maxDatacenterID():
+		return 0, fmt.Errorf("datacenter id %d outside [0, %d]", datacenterID, l.maxDatacenterID())
+	case workerID < 0 || workerID > l.maxWorkerID():
+		return 0, fmt.Errorf("worker id %d outside [0, %d]", workerID, l.maxWorkerID())
+	case sequence < 0 || sequence > l.sequenceMask():
+		return 0, fmt.Errorf("sequence %d outside [0, %d]", sequence, l.sequenceMask())
+	}
+	return l.compose(ticks, datacenterID, workerID, sequence), nil
+}
+
+// Decode split id into its fields, Time being the start of its timestamp unit
+func (c *Codec) Decode(id uint64) Parts {
+	ticks, datacenterID, workerID, sequence := c.layout.decompose(id)
+	p := Parts{DatacenterID: datacenterID, WorkerID: workerID, Sequence: sequence}
+	if c.unitMillis > 0 {
+		p.Time = time.UnixMilli(c.layout.Epoch.UnixMilli() + ticks*c.unitMillis)
+	} else {
+		p.Time = c.layout.Epoch.Add(time.Duration(ticks) * c.unit)
+	}
+	return p
+}
+
+// IsValid report whether id has no bits set above the scheme (and is positive unless unsigned)
+func (c *Codec) IsValid(id uint64) bool {
+	return c.layout.isValid(id)
+}
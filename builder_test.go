@@ -0,0 +1,69 @@
+package snowflake
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_LayoutBuilder(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c, err := NewLayoutBuilder().Timestamp(39, 10*time.Millisecond).Node(16).Sequence(8).Epoch(epoch).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := epoch.Add(time.Hour + 25*time.Millisecond)
+	id, err := c.Encode(at, 0, 0xbeef, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64(360002)<<24 | 0xbeef<<8 | 7; id != want {
+		t.Errorf("Encode = %#x, want %#x", id, want)
+	}
+	p := c.Decode(id)
+	if !p.Time.Equal(epoch.Add(time.Hour+20*time.Millisecond)) || p.WorkerID != 0xbeef || p.Sequence != 7 || p.DatacenterID != 0 {
+		t.Errorf("Decode = %+v", p)
+	}
+	if !c.IsValid(id) || c.IsValid(1<<63) {
+		t.Error("IsValid")
+	}
+	if _, err := c.Layout(); err == nil {
+		t.Error("Layout of a 10ms scheme")
+	}
+	for _, bad := range []struct {
+		t                    time.Time
+		dc, worker, sequence int64
+	}{{epoch.Add(-time.Millisecond), 0, 0, 0}, {at, 1, 0, 0}, {at, 0, 1 << 16, 0}, {at, 0, 0, 256}, {epoch.AddDate(200, 0, 0), 0, 0, 0}} {
+		if _, err := c.Encode(bad.t, bad.dc, bad.worker, bad.sequence); err == nil {
+			t.Errorf("Encode(%+v) accepted", bad)
+		}
+	}
+
+	// 微秒时间戳
+	us, err := NewLayoutBuilder().Timestamp(50, time.Microsecond).Worker(5).Sequence(8).Epoch(epoch).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := us.Encode(epoch.Add(1500*time.Nanosecond), 0, 3, 1); err != nil || !us.Decode(id).Time.Equal(epoch.Add(time.Microsecond)) || us.Decode(id).WorkerID != 3 {
+		t.Errorf("microsecond round trip = %#x, %v", id, err)
+	}
+
+	// 毫秒方案可以交给 worker
+	ms, err := NewLayoutBuilder().Timestamp(41, time.Millisecond).Datacenter(5).Worker(5).Sequence(12).Epoch(DefaultLayout.Epoch).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l, err := ms.Layout(); err != nil || l.Fingerprint() != DefaultLayout.Fingerprint() {
+		t.Errorf("Layout = %+v, %v", l, err)
+	}
+
+	for name, b := range map[string]*LayoutBuilder{
+		"no epoch":     NewLayoutBuilder().Timestamp(41, time.Millisecond),
+		"too wide":     NewLayoutBuilder().Timestamp(50, time.Millisecond).Node(10).Sequence(12).Epoch(epoch),
+		"zero unit":    NewLayoutBuilder().Timestamp(41, 0).Epoch(epoch),
+		"span too big": NewLayoutBuilder().Timestamp(55, time.Microsecond).Epoch(epoch),
+	} {
+		if _, err := b.Build(); err == nil {
+			t.Errorf("%s: Build accepted", name)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrChecksum check digit does not match, the id was mistyped
+var ErrChecksum = errors.New("snowflake: check digit mismatch")
+
+// dammTable Damm 算法的全反对称拟群，能检出所有单个数字错误和相邻数字对调
+var dammTable = [10][10]byte{
+	{0, 3, 1, 7, 5, 9, 8, 6, 4, 2},
+	{7, 0, 9, 2, 1, 5, 4, 8, 6, 3},
+	{4, 2, 0, 6, 8, 7, 1, 3, 5, 9},
+	{1, 7, 5, 0, 9, 8, 3, 4, 2, 6},
+	{6, 1, 2, 3, 0, 4, 5, 9, 7, 8},
+	{3, 6, 7, 4, 2, 0, 9, 5, 8, 1},
+	{5, 8, 6, 9, 7, 2, 0, 1, 3, 4},
+	{8, 9, 4, 5, 3, 6, 2, 0, 1, 7},
+	{9, 4, 3, 8, 6, 1, 7, 2, 0, 5},
+	{2, 5, 8, 1, 4, 3, 6, 7, 9, 0},
+}
+
+// damm 计算十进制数字串的校验位，含校验位的合法串计算结果为 0
+func damm(digits string) byte {
+	var interim byte
+	for i := 0; i < len(digits); i++ {
+		interim = dammTable[interim][digits[i]-'0']
+	}
+	return interim
+}
+
+// FormatChecked return decimal form of id followed by a Damm check digit,
+// for ids read over the phone or typed by hand
+func FormatChecked(id ID) string {
+	// 按无符号格式化，负数也不会产生 '-' 字符
+	s := strconv.FormatUint(uint64(id), 10)
+	return s + string('0'+damm(s))
+}
+
+// ParseChecked parse FormatChecked output, returning ErrChecksum for any single mistyped
+// digit or swap of adjacent digits
+func ParseChecked(s string) (ID, error) {
+	if len(s) < 2 {
+		return 0, errors.New("snowflake: checked id too short")
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return 0, errors.New("snowflake: checked id must be decimal digits")
+		}
+	}
+	if damm(s) != 0 {
+		return 0, ErrChecksum
+	}
+	u, err := strconv.ParseUint(s[:len(s)-1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid checked id %q: %v", s, err)
+	}
+	return ID(u), nil
+}
@@ -0,0 +1,55 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import "testing"
+
+func Test_CheckDigit(t *testing.T) {
+	// Damm 算法标准示例: 572 的校验位为 4
+	if got := FormatChecked(572); got != "5724" {
+		t.Errorf("FormatChecked(572) = %q, want 5724", got)
+	}
+	id, err := Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := FormatChecked(ID(id))
+	got, err := ParseChecked(s)
+	if err != nil || got != ID(id) {
+		t.Fatalf("ParseChecked(%q) = %d, %v", s, got, err)
+	}
+
+	b := []byte(s)
+	for i := range b {
+		orig := b[i]
+		for d := byte('0'); d <= '9'; d++ {
+			if d == orig {
+				continue
+			}
+			b[i] = d
+			if _, err := ParseChecked(string(b)); err != ErrChecksum {
+				t.Errorf("single digit error %q not detected: %v", b, err)
+			}
+		}
+		b[i] = orig
+	}
+	for i := 0; i+1 < len(b); i++ {
+		if b[i] == b[i+1] {
+			continue
+		}
+		b[i], b[i+1] = b[i+1], b[i]
+		if _, err := ParseChecked(string(b)); err != ErrChecksum {
+			t.Errorf("transposition %q not detected: %v", b, err)
+		}
+		b[i], b[i+1] = b[i+1], b[i]
+	}
+
+	if got, err := ParseChecked(FormatChecked(-1)); err != nil || got != -1 {
+		t.Errorf("negative id round trip = %d, %v", got, err)
+	}
+	for _, s := range []string{"", "7", "12a4", "-123"} {
+		if _, err := ParseChecked(s); err == nil {
+			t.Errorf("ParseChecked(%q) expected error", s)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultMaxBatch default cap of ids fetched per round trip by WithBatching
+const DefaultMaxBatch = 1000
+
+// WithBatching merge concurrent ID and Next calls: while a request is in flight, new
+// callers queue up and the next round trip fetches ids for all of them with GET /ids, up
+// to max per request (0 means DefaultMaxBatch, keep it within the server's cap). One
+// round trip is in flight at a time; a failed batch fails all of its callers, and a round
+// trip is canceled once every one of its callers has given up.
+func WithBatching(max int) Option {
+	return func(c *Client) {
+		if max <= 0 {
+			max = DefaultMaxBatch
+		}
+		c.batch = &batcher{c: c, max: max}
+	}
+}
+
+// batcher 单飞请求：同一时间只有一个批量请求，其间到达的调用排队合入下一批
+type batcher struct {
+	c   *Client
+	max int
+
+	mu      sync.Mutex
+	waiting []waiter
+	running bool
+}
+
+// waiter 排队的调用方，ctx 结束后不再等待结果
+type waiter struct {
+	ctx context.Context
+	ch  chan batchResult
+}
+
+type batchResult struct {
+	id  int64
+	err error
+}
+
+func (b *batcher) next(ctx context.Context) (int64, error) {
+	ch := make(chan batchResult, 1)
+	b.mu.Lock()
+	b.waiting = append(b.waiting, waiter{ctx: ctx, ch: ch})
+	if !b.running {
+		b.running = true
+		go b.loop()
+	}
+	b.mu.Unlock()
+	select {
+	case r := <-ch:
+		return r.id, r.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func (b *batcher) loop() {
+	for {
+		b.mu.Lock()
+		n := min(len(b.waiting), b.max)
+		if n == 0 {
+			b.running = false
+			b.mu.Unlock()
+			return
+		}
+		batch := b.waiting[:n:n]
+		b.waiting = b.waiting[n:]
+		b.mu.Unlock()
+
+		ids, err := b.fetch(batch)
+		for i, w := range batch {
+			if err != nil {
+				w.ch <- batchResult{err: err}
+			} else {
+				w.ch <- batchResult{id: ids[i]}
+			}
+		}
+	}
+}
+
+// fetch 为 batch 取号，所有调用方都放弃等待后取消请求，不让挂起的请求拖住后续批次
+func (b *batcher) fetch(batch []waiter) ([]int64, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var left atomic.Int64
+	left.Store(int64(len(batch)))
+	for _, w := range batch {
+		stop := context.AfterFunc(w.ctx, func() {
+			if left.Add(-1) == 0 {
+				cancel()
+			}
+		})
+		defer stop()
+	}
+	ids, err := b.c.IDs(ctx, len(batch))
+	if err == nil && len(ids) != len(batch) {
+		err = fmt.Errorf("snowflake service: got %d ids, want %d", len(ids), len(batch))
+	}
+	return ids, err
+}
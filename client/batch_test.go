@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/perlyna/snowflake"
+	"github.com/perlyna/snowflake/server"
+)
+
+func Test_Batching(t *testing.T) {
+	g, err := snowflake.NewGenerator(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var requests atomic.Int64
+	h := server.New(g)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(5 * time.Millisecond)
+		h.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, WithBatching(64))
+	const calls = 500
+	var mu sync.Mutex
+	seen := map[int64]bool{}
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := c.Next()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if seen[id] {
+				t.Errorf("duplicate id %d", id)
+			}
+			seen[id] = true
+		}()
+	}
+	wg.Wait()
+	if len(seen) != calls {
+		t.Fatalf("%d ids for %d calls", len(seen), calls)
+	}
+	if n := requests.Load(); n >= calls/4 || n < calls/64 {
+		t.Errorf("%d round trips for %d calls with batches of 64", n, calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.ID(ctx); err != context.Canceled {
+		t.Errorf("ID with canceled context = %v", err)
+	}
+}
+
+func Test_BatchingAbandoned(t *testing.T) {
+	release := make(chan struct{})
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 第一个请求挂起，直到客户端取消
+		if requests.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-release:
+			}
+			return
+		}
+		w.Write([]byte(`{"ids":["7"]}`))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c := New(srv.URL, WithBatching(64))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.ID(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("ID on a hung server = %v", err)
+	}
+	// 调用方全部放弃后挂起的批次被取消，后续调用不受影响
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if id, err := c.ID(ctx); err != nil || id != 7 {
+		t.Errorf("ID after abandoned batch = %d, %v", id, err)
+	}
+}
@@ -0,0 +1,246 @@
+// Package client Go bindings of the HTTP id service in package server, following its
+// OpenAPI definition (server.OpenAPI)
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIKeyHeader header carrying the API key, same as server.APIKeyHeader
+const APIKeyHeader = "X-Api-Key"
+
+// ErrorTrailer trailer reporting a stream that ended early, same as server.ErrorTrailer
+const ErrorTrailer = "X-Snowflake-Error"
+
+// Client client of the id service, safe for concurrent use
+type Client struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+	batch   *batcher
+	retries int
+}
+
+// Option client option
+type Option func(*Client)
+
+// WithAPIKey send key in APIKeyHeader to select the caller's quota
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithHTTPClient use hc instead of http.DefaultClient
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+// DefaultRetries default number of retries after a 429, see WithRetries
+const DefaultRetries = 2
+
+// WithRetries retry GET requests answered with 429 up to n times, each after waiting the
+// response's Retry-After; 0 disables retries. A wait that would outlast the context's
+// deadline is not started and the 429 is returned as is.
+func WithRetries(n int) Option {
+	return func(c *Client) {
+		c.retries = max(n, 0)
+	}
+}
+
+// New return client of the service at baseURL, e.g. http://ids.internal:8080
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{baseURL: strings.TrimSuffix(baseURL, "/"), http: http.DefaultClient, retries: DefaultRetries}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Error non-2xx response of the service
+type Error struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration // from Retry-After on 429, 0 otherwise
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("snowflake service: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// ID GET /id, one id; with WithBatching concurrent calls share GET /ids round trips
+func (c *Client) ID(ctx context.Context) (int64, error) {
+	if c.batch != nil {
+		return c.batch.next(ctx)
+	}
+	body, err := c.get(ctx, "/id")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(body)), 10, 64)
+}
+
+// Next ID without a context, so the client can stand in for a snowflake.Worker
+func (c *Client) Next() (int64, error) {
+	return c.ID(context.Background())
+}
+
+// IDs GET /ids?n=N, n ids in issue order
+func (c *Client) IDs(ctx context.Context, n int) ([]int64, error) {
+	body, err := c.get(ctx, "/ids?n="+strconv.Itoa(n))
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		IDs []string `json:"ids"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	ids := make([]int64, len(resp.IDs))
+	for i, s := range resp.IDs {
+		if ids[i], err = strconv.ParseInt(s, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// Stream POST /ids?n=N, call fn with each of n ids as the server issues them, without
+// buffering the batch; stops at the first error of fn
+func (c *Client) Stream(ctx context.Context, n int, fn func(id int64) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/ids?n="+strconv.Itoa(n), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/x-ndjson")
+	if c.apiKey != "" {
+		req.Header.Set(APIKeyHeader, c.apiKey)
+	}
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return responseError(res)
+	}
+	sc := bufio.NewScanner(res.Body)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) > 0 && line[0] == '{' {
+			var e struct {
+				Error string `json:"error"`
+			}
+			json.Unmarshal(line, &e)
+			return &Error{StatusCode: res.StatusCode, Message: e.Error}
+		}
+		var s string
+		if err := json.Unmarshal(line, &s); err != nil {
+			return err
+		}
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		if err := fn(id); err != nil {
+			return err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if msg := res.Trailer.Get(ErrorTrailer); msg != "" {
+		return &Error{StatusCode: res.StatusCode, Message: msg}
+	}
+	return nil
+}
+
+// Time GET /time, the server's clock
+func (c *Client) Time(ctx context.Context) (time.Time, error) {
+	body, err := c.get(ctx, "/time")
+	if err != nil {
+		return time.Time{}, err
+	}
+	nanos, err := strconv.ParseInt(strings.TrimSpace(string(body)), 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, nanos), nil
+}
+
+// Ready GET /readyz, nil when the server is ready, an *Error with the reason otherwise
+func (c *Client) Ready(ctx context.Context) error {
+	_, err := c.get(ctx, "/readyz")
+	return err
+}
+
+func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if c.apiKey != "" {
+			req.Header.Set(APIKeyHeader, c.apiKey)
+		}
+		body, err := c.do(req)
+		if attempt >= c.retries || !backoff(ctx, err) {
+			return body, err
+		}
+	}
+}
+
+// backoff 对 429 等待 Retry-After 后返回 true；其他错误、等待超出 ctx 的截止时间或等待中
+// ctx 结束时返回 false
+func backoff(ctx context.Context, err error) bool {
+	var e *Error
+	if !errors.As(err, &e) || e.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < e.RetryAfter {
+		return false
+	}
+	t := time.NewTimer(e.RetryAfter)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// do 执行请求，非 2xx 响应转换为 *Error
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return nil, responseError(res)
+	}
+	return io.ReadAll(res.Body)
+}
+
+// responseError 将非 2xx 响应转换为 *Error
+func responseError(res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1<<10))
+	e := &Error{StatusCode: res.StatusCode, Message: strings.TrimSpace(string(body))}
+	if s, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil {
+		e.RetryAfter = time.Duration(s) * time.Second
+	}
+	return e
+}
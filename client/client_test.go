@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/perlyna/snowflake"
+	"github.com/perlyna/snowflake/server"
+)
+
+func newTestService(t *testing.T, opts ...server.Option) *httptest.Server {
+	g, err := snowflake.NewGenerator(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(server.New(g, opts...))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func Test_Client(t *testing.T) {
+	srv := newTestService(t, server.WithMaxBatch(10), server.WithQuota(server.Quota{PerMinute: 5}, map[string]server.Quota{"big": {PerMinute: 1000}}))
+	ctx := context.Background()
+
+	var w snowflake.Worker = New(srv.URL, WithAPIKey("big"))
+	id, err := w.Next()
+	if err != nil || !snowflake.IsValid(id) {
+		t.Fatalf("Next = %d, %v", id, err)
+	}
+	ids, err := New(srv.URL+"/", WithAPIKey("big")).IDs(ctx, 10)
+	if err != nil || len(ids) != 10 || ids[0] <= id {
+		t.Fatalf("IDs = %v, %v", ids, err)
+	}
+
+	var e *Error
+	if _, err := New(srv.URL).IDs(ctx, 11); !errors.As(err, &e) || e.StatusCode != http.StatusBadRequest {
+		t.Errorf("batch over cap = %v", err)
+	}
+	c := New(srv.URL, WithAPIKey("small"), WithHTTPClient(&http.Client{Timeout: time.Second}), WithRetries(0))
+	if _, err := c.IDs(ctx, 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ID(ctx); !errors.As(err, &e) || e.StatusCode != http.StatusTooManyRequests || e.RetryAfter <= 0 {
+		t.Errorf("over quota = %v", err)
+	}
+	if now, err := c.Time(ctx); err != nil || time.Since(now).Abs() > time.Minute {
+		t.Errorf("Time = %v, %v", now, err)
+	}
+	if err := c.Ready(ctx); err != nil {
+		t.Errorf("Ready = %v", err)
+	}
+}
+
+func Test_ClientRetry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("42\n"))
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	id, err := New(srv.URL).ID(context.Background())
+	if err != nil || id != 42 {
+		t.Fatalf("ID after one 429 = %d, %v", id, err)
+	}
+	if calls.Load() != 2 || time.Since(start) < time.Second {
+		t.Errorf("%d calls in %v, want a retry after Retry-After", calls.Load(), time.Since(start))
+	}
+
+	// 等待会超出截止时间时直接返回 429
+	calls.Store(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var e *Error
+	if _, err := New(srv.URL).ID(ctx); !errors.As(err, &e) || e.StatusCode != http.StatusTooManyRequests || calls.Load() != 1 {
+		t.Errorf("ID with short deadline = %v after %d calls", err, calls.Load())
+	}
+}
+
+// 客户端覆盖 OpenAPI 定义中的全部操作
+func Test_ClientMatchesOpenAPI(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(server.OpenAPI, &spec); err != nil {
+		t.Fatal(err)
+	}
+	bound := map[string]string{"getID": "Client.ID", "getIDs": "Client.IDs", "streamIDs": "Client.Stream", "getTime": "Client.Time", "getReady": "Client.Ready", "getOpenAPI": "server.OpenAPI"}
+	srv := newTestService(t)
+	for path, ops := range spec.Paths {
+		for method, op := range ops {
+			if _, ok := bound[op.OperationID]; !ok {
+				t.Errorf("%s %s (%s) has no client binding", method, path, op.OperationID)
+			}
+			req, _ := http.NewRequest(strings.ToUpper(method), srv.URL+path, nil)
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusMethodNotAllowed {
+				t.Errorf("%s %s = %d, server does not implement it", method, path, res.StatusCode)
+			}
+		}
+	}
+}
+
+func Test_ClientStream(t *testing.T) {
+	srv := newTestService(t, server.WithMaxStream(5000))
+	var got []int64
+	err := New(srv.URL).Stream(context.Background(), 3000, func(id int64) error {
+		got = append(got, id)
+		return nil
+	})
+	if err != nil || len(got) != 3000 {
+		t.Fatalf("Stream = %d ids, %v", len(got), err)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] <= got[i-1] {
+			t.Fatalf("id %d not increasing", i)
+		}
+	}
+	stop := errors.New("stop")
+	if err := New(srv.URL).Stream(context.Background(), 3000, func(int64) error { return stop }); err != stop {
+		t.Errorf("Stream with failing callback = %v", err)
+	}
+	var e *Error
+	if err := New(srv.URL).Stream(context.Background(), 5001, func(int64) error { return nil }); !errors.As(err, &e) || e.StatusCode != http.StatusBadRequest {
+		t.Errorf("stream over cap = %v", err)
+	}
+}
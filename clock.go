@@ -0,0 +1,106 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// currentMillis 当前毫秒时间戳，currentNanos 按平台实现
+func currentMillis() int64 {
+	return currentNanos() / 1e6
+}
+
+// coarse 进程内共享的缓存时钟，使用它的 worker 存在期间由后台 ticker 每毫秒刷新
+var coarse struct {
+	ms atomic.Int64
+
+	mu   sync.Mutex
+	refs int           // 使用缓存时钟且未关闭的 worker 数
+	stop chan struct{} // 关闭时停止 ticker
+}
+
+// retainCoarse 增加缓存时钟的引用，第一个引用启动 ticker
+func retainCoarse() {
+	coarse.mu.Lock()
+	defer coarse.mu.Unlock()
+	if coarse.refs++; coarse.refs > 1 {
+		return
+	}
+	coarse.ms.Store(currentMillis())
+	stop := make(chan struct{})
+	coarse.stop = stop
+	go func() {
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				coarse.ms.Store(currentMillis())
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// releaseCoarse 减少缓存时钟的引用，最后一个引用释放时停止 ticker
+func releaseCoarse() {
+	coarse.mu.Lock()
+	defer coarse.mu.Unlock()
+	if coarse.refs--; coarse.refs == 0 {
+		close(coarse.stop)
+		coarse.stop = nil
+	}
+}
+
+// coarseMillis 返回缓存的毫秒时间戳，热路径上省去 time.Now 调用，调用方需持有引用；
+// 缓存值可能落后真实时间，调用方需在回拨判断和序列号耗尽时回退到 currentMillis
+func coarseMillis() int64 {
+	return coarse.ms.Load()
+}
+
+// retainClock 使用缓存时钟的 worker 取得其引用，newWorker 调用
+func (w *worker) retainClock() {
+	if w.cachedClock && !w.clockRetained {
+		w.clockRetained = true
+		retainCoarse()
+	}
+}
+
+// releaseClock 释放缓存时钟的引用，可重复调用
+func (w *worker) releaseClock() {
+	if w.clockRetained {
+		w.clockRetained = false
+		releaseCoarse()
+	}
+}
+
+// Option worker option
+type Option func(*worker)
+
+// WithCachedClock read time from a process wide clock refreshed every millisecond by a
+// background ticker instead of calling time.Now on every Next,
+// id timestamps may lag the wall clock by a tick but stay monotonic.
+// The ticker runs while any worker using it is open; Close the worker to stop it.
+// Combined with WithClock the option given last wins
+func WithCachedClock() Option {
+	return func(w *worker) {
+		w.now = coarseMillis
+		w.cachedClock = true
+		w.customClock = false
+	}
+}
+
+// WithClock read time from now instead of the system clock, e.g. a host provided clock
+// on WASM or embedded targets; when the sequence is used up Next polls now until it advances.
+// Combined with WithCachedClock the option given last wins
+func WithClock(now func() time.Time) Option {
+	return func(w *worker) {
+		w.now = func() int64 { return now().UnixMilli() }
+		w.customClock = true
+		w.cachedClock = false
+	}
+}
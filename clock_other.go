@@ -0,0 +1,10 @@
+//go:build !windows && !snowflake_decode
+
+package snowflake
+
+import "time"
+
+// currentNanos 当前纳秒时间戳
+func currentNanos() int64 {
+	return time.Now().UnixNano()
+}
@@ -0,0 +1,170 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_NextCachedClock(t *testing.T) {
+	worker, err := NewGenerator(1, 1, WithCachedClock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer worker.Close()
+	var last int64
+	for i := 0; i < 100000; i++ {
+		id, err := worker.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= last {
+			t.Fatalf("id %d not greater than previous %d", id, last)
+		}
+		last = id
+	}
+}
+
+func Test_CachedClockStops(t *testing.T) {
+	running := func() bool {
+		coarse.mu.Lock()
+		defer coarse.mu.Unlock()
+		return coarse.stop != nil
+	}
+	if running() {
+		t.Skip("cached clock held by a worker outside this test")
+	}
+	a, err := NewGenerator(1, 1, WithCachedClock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewGenerator(2, 1, WithCachedClock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !running() {
+		t.Fatal("cached clock not started")
+	}
+	a.Close()
+	a.Close()
+	if !running() {
+		t.Fatal("cached clock stopped while a worker still uses it")
+	}
+	if _, err := b.Next(); err != nil {
+		t.Fatal(err)
+	}
+	b.Close()
+	if running() {
+		t.Error("cached clock still running after the last worker closed")
+	}
+	// WithClock 覆盖 WithCachedClock 时不启动共享时钟
+	c, err := NewGenerator(3, 1, WithCachedClock(), WithClock(time.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if running() {
+		t.Error("cached clock started for a worker using WithClock")
+	}
+}
+
+func Benchmark_currentMillis(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		currentMillis()
+	}
+}
+
+func Benchmark_coarseMillis(b *testing.B) {
+	retainCoarse()
+	defer releaseCoarse()
+	for i := 0; i < b.N; i++ {
+		coarseMillis()
+	}
+}
+
+func Benchmark_NextCachedClock(b *testing.B) {
+	worker := NewWorker(0, 0, WithCachedClock())
+	for i := 0; i < b.N; i++ {
+		worker.Next()
+	}
+}
+
+func Test_WithClock(t *testing.T) {
+	var mu sync.Mutex
+	now := DefaultLayout.Epoch.Add(time.Hour)
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	w, err := newWorker(1, 1, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DefaultLayout.Decompose(ID(id)).Time; !got.Equal(now) {
+		t.Errorf("id time = %v, want %v", got, now)
+	}
+
+	// 序列耗尽后应等待注入的时钟前进，而不是系统时钟
+	for i := 0; i < sequenceMask; i++ {
+		if _, err := w.Next(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		now = now.Add(time.Millisecond)
+		mu.Unlock()
+	}()
+	id, err = w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DefaultLayout.Decompose(ID(id)).Time; !got.Equal(now) {
+		t.Errorf("id time after rollover = %v, want %v", got, now)
+	}
+
+	mu.Lock()
+	now = now.Add(-time.Millisecond)
+	mu.Unlock()
+	if _, err := w.Next(); err == nil {
+		t.Error("custom clock moving backwards should be refused")
+	}
+}
+
+func Test_ClockOptionsLastWins(t *testing.T) {
+	now := DefaultLayout.Epoch.Add(time.Hour)
+	fixed := WithClock(func() time.Time { return now })
+
+	w, err := newWorker(1, 1, fixed, WithCachedClock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.customClock || !w.cachedClock {
+		t.Errorf("WithClock then WithCachedClock: custom %v, cached %v", w.customClock, w.cachedClock)
+	}
+	w.Close()
+
+	w, err = newWorker(1, 1, WithCachedClock(), fixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if !w.customClock || w.cachedClock {
+		t.Errorf("WithCachedClock then WithClock: custom %v, cached %v", w.customClock, w.cachedClock)
+	}
+	id, err := w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DefaultLayout.Decompose(ID(id)).Time; !got.Equal(now) {
+		t.Errorf("id time = %v, want %v", got, now)
+	}
+}
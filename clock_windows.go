@@ -0,0 +1,41 @@
+//go:build windows && !snowflake_decode
+
+package snowflake
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Windows 系统时间默认按 ~15.6ms 的时钟中断更新，直接使用会让同一毫秒内的序列集中爆发；
+// 这里用 QueryPerformanceCounter 在两次系统时间读数之间插值
+var (
+	kernel32                      = syscall.NewLazyDLL("kernel32.dll")
+	procQueryPerformanceCounter   = kernel32.NewProc("QueryPerformanceCounter")
+	procQueryPerformanceFrequency = kernel32.NewProc("QueryPerformanceFrequency")
+)
+
+var systemClock = newSystemClock()
+
+func newSystemClock() *interpolatedClock {
+	var freq int64
+	if r, _, _ := procQueryPerformanceFrequency.Call(uintptr(unsafe.Pointer(&freq))); r == 0 || freq <= 0 {
+		return nil
+	}
+	return newInterpolatedClock(queryPerformanceCounter, freq, func() int64 { return time.Now().UnixNano() })
+}
+
+func queryPerformanceCounter() int64 {
+	var c int64
+	procQueryPerformanceCounter.Call(uintptr(unsafe.Pointer(&c)))
+	return c
+}
+
+// currentNanos 当前纳秒时间戳，QPC 不可用时退回系统时间
+func currentNanos() int64 {
+	if systemClock == nil {
+		return time.Now().UnixNano()
+	}
+	return systemClock.nanos()
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+// auditExamples 每类问题最多列出的 id 数
+const auditExamples = 5
+
+type auditOptions struct {
+	layout        snowflake.Layout
+	now           time.Time
+	maxWorker     int64 // 负数表示不检查
+	maxDatacenter int64
+	bucket        time.Duration
+}
+
+// auditFinding 一类问题的计数和示例
+type auditFinding struct {
+	count    int
+	examples []string
+}
+
+func (f *auditFinding) add(s string) {
+	f.count++
+	if len(f.examples) < auditExamples {
+		f.examples = append(f.examples, s)
+	}
+}
+
+type auditNode struct {
+	datacenter, worker int64
+	count              int
+	first, last        time.Time
+}
+
+type auditReport struct {
+	total                            int
+	first, last                      time.Time
+	malformed, invalid, dups, future auditFinding
+	outOfRange                       auditFinding
+	nodes                            map[[2]int64]*auditNode
+	buckets                          map[int64]int
+	bucket                           time.Duration
+}
+
+// audit 读取每行一个 id 的输入并汇总
+func audit(r io.Reader, opts auditOptions) (*auditReport, error) {
+	rep := &auditReport{nodes: map[[2]int64]*auditNode{}, buckets: map[int64]int{}, bucket: opts.bucket}
+	seen := map[snowflake.ID]struct{}{}
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		s := strings.TrimSpace(sc.Text())
+		if s == "" {
+			continue
+		}
+		id, err := snowflake.Parse(s)
+		if err != nil {
+			rep.malformed.add(fmt.Sprintf("line %d: %q", line, s))
+			continue
+		}
+		rep.total++
+		if _, ok := seen[id]; ok {
+			rep.dups.add(s)
+		}
+		seen[id] = struct{}{}
+		if !opts.layout.IsValid(int64(id)) {
+			rep.invalid.add(s)
+			continue
+		}
+		p := opts.layout.Decompose(id)
+		if p.Time.After(opts.now) {
+			rep.future.add(fmt.Sprintf("%s (%s)", s, p.Time.UTC().Format(time.RFC3339Nano)))
+		}
+		if opts.maxWorker >= 0 && p.WorkerID > opts.maxWorker || opts.maxDatacenter >= 0 && p.DatacenterID > opts.maxDatacenter {
+			rep.outOfRange.add(fmt.Sprintf("%s (datacenter %d worker %d)", s, p.DatacenterID, p.WorkerID))
+		}
+		if rep.first.IsZero() || p.Time.Before(rep.first) {
+			rep.first = p.Time
+		}
+		if p.Time.After(rep.last) {
+			rep.last = p.Time
+		}
+		key := [2]int64{p.DatacenterID, p.WorkerID}
+		n := rep.nodes[key]
+		if n == nil {
+			n = &auditNode{datacenter: p.DatacenterID, worker: p.WorkerID, first: p.Time}
+			rep.nodes[key] = n
+		}
+		n.count++
+		if p.Time.Before(n.first) {
+			n.first = p.Time
+		}
+		if p.Time.After(n.last) {
+			n.last = p.Time
+		}
+		rep.buckets[p.Time.UnixMilli()/opts.bucket.Milliseconds()]++
+	}
+	return rep, sc.Err()
+}
+
+// ok 没有发现任何问题
+func (r *auditReport) ok() bool {
+	return r.malformed.count+r.invalid.count+r.dups.count+r.future.count+r.outOfRange.count == 0
+}
+
+func (r *auditReport) write(w io.Writer) {
+	const tf = "2006-01-02T15:04:05.000Z07:00"
+	fmt.Fprintf(w, "ids: %d\n", r.total)
+	if !r.first.IsZero() {
+		fmt.Fprintf(w, "time range: %s .. %s\n", r.first.UTC().Format(tf), r.last.UTC().Format(tf))
+	}
+	for _, f := range []struct {
+		name string
+		f    *auditFinding
+	}{
+		{"malformed lines", &r.malformed},
+		{"duplicates", &r.dups},
+		{"invalid for layout", &r.invalid},
+		{"future timestamps", &r.future},
+		{"out of range worker/datacenter", &r.outOfRange},
+	} {
+		fmt.Fprintf(w, "%s: %d\n", f.name, f.f.count)
+		for _, e := range f.f.examples {
+			fmt.Fprintf(w, "  %s\n", e)
+		}
+	}
+
+	nodes := make([]*auditNode, 0, len(r.nodes))
+	for _, n := range r.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].datacenter != nodes[j].datacenter {
+			return nodes[i].datacenter < nodes[j].datacenter
+		}
+		return nodes[i].worker < nodes[j].worker
+	})
+	fmt.Fprintf(w, "\n%-10s %-6s %10s  %-24s  %-24s\n", "datacenter", "worker", "ids", "first", "last")
+	for _, n := range nodes {
+		fmt.Fprintf(w, "%-10d %-6d %10d  %-24s  %-24s\n", n.datacenter, n.worker, n.count, n.first.UTC().Format(tf), n.last.UTC().Format(tf))
+	}
+
+	keys := make([]int64, 0, len(r.buckets))
+	for k := range r.buckets {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	fmt.Fprintf(w, "\n%-24s %10s\n", "time (per "+r.bucket.String()+")", "ids")
+	for _, k := range keys {
+		fmt.Fprintf(w, "%-24s %10d\n", time.UnixMilli(k*r.bucket.Milliseconds()).UTC().Format(tf), r.buckets[k])
+	}
+}
+
+func auditCmd(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	maxWorker := fs.Int64("max-worker", -1, "flag ids with a larger worker id, negative to disable")
+	maxDatacenter := fs.Int64("max-datacenter", -1, "flag ids with a larger datacenter id, negative to disable")
+	bucket := fs.Duration("bucket", time.Hour, "time histogram bucket width")
+	skew := fs.Duration("future", time.Minute, "tolerated clock skew before timestamps count as future")
+	lf := addLayoutFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return flag.ErrHelp
+	}
+	l, err := lf.layout()
+	if err != nil {
+		return err
+	}
+	if *bucket < time.Millisecond {
+		return fmt.Errorf("bucket must be at least 1ms")
+	}
+	opts := auditOptions{layout: l, now: time.Now().Add(*skew), maxWorker: *maxWorker, maxDatacenter: *maxDatacenter, bucket: *bucket}
+
+	var inputs []io.Reader
+	for _, name := range fs.Args() {
+		if name == "-" {
+			inputs = append(inputs, stdin)
+			continue
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		inputs = append(inputs, f)
+	}
+	if len(inputs) == 0 {
+		inputs = append(inputs, stdin)
+	}
+	rep, err := audit(io.MultiReader(inputs...), opts)
+	if err != nil {
+		return err
+	}
+	rep.write(stdout)
+	if !rep.ok() {
+		return errAuditFindings
+	}
+	return nil
+}
+
+// errAuditFindings 报告已输出，以非零状态退出便于脚本判断
+var errAuditFindings = fmt.Errorf("found problems")
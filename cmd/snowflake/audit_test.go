@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+func Test_audit(t *testing.T) {
+	g1, _ := snowflake.NewGenerator(1, 0)
+	g2, _ := snowflake.NewGenerator(2, 3)
+	ids1, _ := g1.NextN(10)
+	ids2, _ := g2.NextN(5)
+	var in strings.Builder
+	for _, id := range append(ids1, ids2...) {
+		fmt.Fprintln(&in, id)
+	}
+	fmt.Fprintln(&in, ids1[0])  // 重复
+	fmt.Fprintln(&in, "banana") // 无法解析
+	fmt.Fprintln(&in, -5)       // 布局外
+	future := snowflake.DefaultLayout.FirstID(time.Now().Add(time.Hour))
+	fmt.Fprintln(&in, int64(future))
+
+	rep, err := audit(strings.NewReader(in.String()), auditOptions{
+		layout: snowflake.DefaultLayout, now: time.Now(), maxWorker: 1, maxDatacenter: -1, bucket: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.total != 18 || rep.dups.count != 1 || rep.malformed.count != 1 || rep.invalid.count != 1 || rep.future.count != 1 {
+		t.Errorf("report = %+v", rep)
+	}
+	// worker 2 的 5 个 id 超出 -max-worker 1
+	if rep.outOfRange.count != 5 {
+		t.Errorf("out of range = %d", rep.outOfRange.count)
+	}
+	if n := rep.nodes[[2]int64{3, 2}]; n == nil || n.count != 5 {
+		t.Errorf("node 3/2 = %+v", n)
+	}
+	if rep.ok() {
+		t.Error("report with findings should not be ok")
+	}
+}
+
+func Test_auditCmd(t *testing.T) {
+	g, _ := snowflake.NewGenerator(4, 1)
+	ids, _ := g.NextN(100)
+	var in strings.Builder
+	for _, id := range ids {
+		fmt.Fprintln(&in, id)
+	}
+	path := filepath.Join(t.TempDir(), "ids.txt")
+	if err := os.WriteFile(path, []byte(in.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"audit", path}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "ids: 100\n") || !strings.Contains(stdout.String(), "duplicates: 0\n") {
+		t.Errorf("output:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	dup := strings.NewReader(in.String() + fmt.Sprintln(ids[3]))
+	if code := run([]string{"audit", "-"}, dup, &stdout, &stderr); code != 1 {
+		t.Errorf("duplicates: exit %d", code)
+	}
+	if !strings.Contains(stdout.String(), fmt.Sprintf("duplicates: 1\n  %d\n", ids[3])) {
+		t.Errorf("output:\n%s", stdout.String())
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+// rowWriter 按格式逐行输出 id，decompose 时附带解码后的列
+type rowWriter interface {
+	write(id snowflake.ID, p snowflake.Parts) error
+	close() error
+}
+
+// columns 输出列名，与 Parts 字段对应
+var columns = []string{"id", "time", "datacenter", "worker", "sequence"}
+
+const timeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+func newRowWriter(format string, w io.Writer, decompose bool) (rowWriter, error) {
+	switch format {
+	case "csv":
+		return newCSVWriter(w, decompose)
+	case "jsonl":
+		return &jsonlWriter{enc: json.NewEncoder(w), decompose: decompose}, nil
+	case "parquet":
+		return newParquetWriter(w, decompose), nil
+	}
+	return nil, fmt.Errorf("unknown format %q, want text, csv, jsonl or parquet", format)
+}
+
+type csvWriter struct {
+	w         *csv.Writer
+	decompose bool
+	record    []string
+}
+
+func newCSVWriter(w io.Writer, decompose bool) (*csvWriter, error) {
+	c := &csvWriter{w: csv.NewWriter(w), decompose: decompose}
+	header := columns[:1]
+	if decompose {
+		header = columns
+	}
+	c.record = make([]string, len(header))
+	return c, c.w.Write(header)
+}
+
+func (c *csvWriter) write(id snowflake.ID, p snowflake.Parts) error {
+	c.record[0] = id.String()
+	if c.decompose {
+		c.record[1] = p.Time.UTC().Format(timeFormat)
+		c.record[2] = strconv.FormatInt(p.DatacenterID, 10)
+		c.record[3] = strconv.FormatInt(p.WorkerID, 10)
+		c.record[4] = strconv.FormatInt(p.Sequence, 10)
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonlWriter struct {
+	enc       *json.Encoder
+	decompose bool
+}
+
+// jsonlRow id 以字符串输出，避免 JavaScript 等客户端丢失精度
+type jsonlRow struct {
+	ID         string    `json:"id"`
+	Time       time.Time `json:"time,omitzero"`
+	Datacenter *int64    `json:"datacenter,omitempty"`
+	Worker     *int64    `json:"worker,omitempty"`
+	Sequence   *int64    `json:"sequence,omitempty"`
+}
+
+func (j *jsonlWriter) write(id snowflake.ID, p snowflake.Parts) error {
+	row := jsonlRow{ID: id.String()}
+	if j.decompose {
+		row.Time = p.Time.UTC()
+		row.Datacenter, row.Worker, row.Sequence = &p.DatacenterID, &p.WorkerID, &p.Sequence
+	}
+	return j.enc.Encode(row)
+}
+
+func (j *jsonlWriter) close() error {
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+func Test_genCSV(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"gen", "-n", "100", "-worker", "2", "--format", "csv", "--decompose"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	records, err := csv.NewReader(&stdout).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 101 || strings.Join(records[0], ",") != "id,time,datacenter,worker,sequence" {
+		t.Fatalf("records = %v", records[:2])
+	}
+	id, err := snowflake.Parse(records[1][0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := snowflake.DefaultLayout.Decompose(id)
+	if records[1][1] != p.Time.UTC().Format(timeFormat) || records[1][3] != "2" {
+		t.Errorf("row = %v, parts = %+v", records[1], p)
+	}
+}
+
+func Test_genJSONL(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"gen", "-n", "3", "-format", "jsonl"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], `{"id":"`) || strings.Contains(lines[0], "time") {
+		t.Fatalf("lines = %q", lines)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"gen", "-n", "1", "-format", "jsonl", "-decompose", "-datacenter", "4"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	var row struct {
+		ID         string    `json:"id"`
+		Time       time.Time `json:"time"`
+		Datacenter int64     `json:"datacenter"`
+		Sequence   *int64    `json:"sequence"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &row); err != nil {
+		t.Fatal(err)
+	}
+	if row.Datacenter != 4 || row.Sequence == nil || time.Since(row.Time) > time.Minute {
+		t.Errorf("row = %+v", row)
+	}
+}
+
+func Test_genFormatErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	for _, args := range [][]string{
+		{"gen", "-format", "xml"},
+		{"gen", "-decompose"},
+		{"gen", "-n", "-1", "-format", "csv"},
+	} {
+		if code := run(args, nil, &stdout, &stderr); code != 1 {
+			t.Errorf("%v: exit %d", args, code)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+// genField 生成代码中的一个字段
+type genField struct {
+	Name  string // 常量名中的字段名，如 Datacenter
+	Label string // 注释中的字段名，如 datacenter
+	Param string // 参数名，如 datacenterID
+	Shift uint8
+	Bits  uint8
+}
+
+// genVector 生成测试的一组期望值，由库的编码器计算，与生成的移位代码相互独立
+type genVector struct {
+	ID     uint64
+	Millis int64
+	Fields []int64 // 与 genLayout.Fields 对应
+}
+
+type genLayout struct {
+	Package, Name, Command string
+	Layout                 snowflake.Layout
+	EpochMillis            int64
+	Fields                 []genField // 时间戳以外位数非零的字段，从高到低
+	Timestamp              genField
+	Bits                   int
+	Vectors                []genVector
+}
+
+func newGenLayout(pkg, name, command string, l snowflake.Layout) (*genLayout, error) {
+	g := &genLayout{Package: pkg, Name: name, Command: command, Layout: l, EpochMillis: l.Epoch.UnixMilli()}
+	names := map[string][2]string{
+		"version":    {"Version", ""},
+		"time":       {"Timestamp", "millis"},
+		"datacenter": {"Datacenter", "datacenterID"},
+		"worker":     {"Worker", "workerID"},
+		"sequence":   {"Sequence", "sequence"},
+	}
+	for _, f := range udfFields(l) {
+		g.Bits += int(f.bits)
+		gf := genField{Name: names[f.name][0], Label: f.name, Param: names[f.name][1], Shift: f.shift, Bits: f.bits}
+		switch {
+		case f.name == "time":
+			g.Timestamp = gf
+		case f.name != "version" && f.bits > 0:
+			g.Fields = append(g.Fields, gf)
+		}
+	}
+	b := snowflake.NewLayoutBuilder().Timestamp(l.TimestampBits, time.Millisecond).Datacenter(l.DatacenterBits).
+		Worker(l.WorkerBits).Sequence(l.SequenceBits).Epoch(l.Epoch)
+	if l.Unsigned {
+		b.Unsigned()
+	}
+	codec, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	edges := func(bits uint8) []int64 {
+		if bits == 0 {
+			return []int64{0}
+		}
+		return []int64{0, 1, int64(uint64(1)<<bits - 1)}
+	}
+	version := uint64(l.Version) << (g.Timestamp.Shift + g.Timestamp.Bits)
+	for _, elapsed := range edges(l.TimestampBits) {
+		for _, dc := range edges(l.DatacenterBits) {
+			for _, worker := range edges(l.WorkerBits) {
+				for _, seq := range edges(l.SequenceBits) {
+					id, err := codec.Encode(time.UnixMilli(g.EpochMillis+elapsed), dc, worker, seq)
+					if err != nil {
+						return nil, err
+					}
+					if version+id == 0 {
+						// epoch 当毫秒各字段均为 0 的 id 不会签发
+						continue
+					}
+					v := genVector{ID: version + id, Millis: g.EpochMillis + elapsed}
+					for _, f := range g.Fields {
+						v.Fields = append(v.Fields, map[string]int64{"Datacenter": dc, "Worker": worker, "Sequence": seq}[f.Name])
+					}
+					g.Vectors = append(g.Vectors, v)
+				}
+			}
+		}
+	}
+	return g, nil
+}
+
+var genFuncs = template.FuncMap{
+	"mask": func(bits uint8) string { return fmt.Sprintf("%#x", uint64(1)<<bits-1) },
+}
+
+var genSource = template.Must(template.New("source").Funcs(genFuncs).Parse(`// Code generated by "{{.Command}}"; DO NOT EDIT.
+
+package {{.Package}}
+
+import "time"
+
+// {{.Name}} layout: {{.Layout.TimestampBits}} timestamp bits from {{.Layout.Epoch.UTC.Format "2006-01-02T15:04:05.000Z07:00"}}
+{{- range .Fields}}, {{.Bits}} {{.Label}} bits{{end}}
+{{- if .Layout.VersionBits}}, version {{.Layout.Version}} in {{.Layout.VersionBits}} bits{{end}}
+const (
+	{{.Name}}EpochMillis = {{.EpochMillis}}
+	{{.Name}}TimestampBits = {{.Timestamp.Bits}}
+	{{.Name}}TimestampShift = {{.Timestamp.Shift}}
+	{{.Name}}TimestampMask = {{mask .Timestamp.Bits}}
+{{- range .Fields}}
+	{{$.Name}}{{.Name}}Bits = {{.Bits}}
+	{{$.Name}}{{.Name}}Shift = {{.Shift}}
+	{{$.Name}}{{.Name}}Mask = {{mask .Bits}}
+{{- end}}
+{{- if .Layout.VersionBits}}
+	{{.Name}}Version = {{.Layout.Version}}
+	{{.Name}}VersionShift = {{.Version.Shift}}
+{{- end}}
+	{{.Name}}Bits = {{.Bits}}
+)
+
+// {{.Name}}Compose return the id of the fields, millis being unix milliseconds; fields are not range checked
+func {{.Name}}Compose(millis{{range .Fields}}, {{.Param}}{{end}} int64) uint64 {
+	return {{if .Layout.VersionBits}}{{.Name}}Version<<{{.Name}}VersionShift | {{end}}uint64(millis-{{.Name}}EpochMillis)<<{{.Name}}TimestampShift
+{{- range .Fields}} |
+		uint64({{.Param}}){{if .Shift}}<<{{$.Name}}{{.Name}}Shift{{end}}{{end}}
+}
+
+// {{.Name}}Decompose split id into its fields
+func {{.Name}}Decompose(id uint64) (t time.Time{{range .Fields}}, {{.Param}}{{end}} int64) {
+	t = time.UnixMilli(int64(id>>{{.Name}}TimestampShift&{{.Name}}TimestampMask) + {{.Name}}EpochMillis)
+{{- range .Fields}}
+	{{.Param}} = int64(id{{if .Shift}}>>{{$.Name}}{{.Name}}Shift{{end}} & {{$.Name}}{{.Name}}Mask)
+{{- end}}
+	return
+}
+
+// {{.Name}}Valid report whether id could have been issued with the layout
+func {{.Name}}Valid(id uint64) bool {
+	return id != 0{{if lt .Bits 64}} && id>>{{.Name}}Bits == 0{{end}}
+{{- if .Layout.VersionBits}} && id>>{{.Name}}VersionShift == {{.Name}}Version{{end}}
+}
+`))
+
+var genTest = template.Must(template.New("test").Funcs(genFuncs).Parse(`// Code generated by "{{.Command}}"; DO NOT EDIT.
+
+package {{.Package}}
+
+import "testing"
+
+// 期望值由 snowflake 库的编码器计算，覆盖每个字段的 0、1 和最大值的全部组合
+var test{{.Name}}Vectors = []struct {
+	id     uint64
+	millis int64
+	fields []int64
+}{
+{{- range .Vectors}}
+	{ {{- printf "%#x" .ID}}, {{.Millis}}, []int64{ {{- range $i, $f := .Fields}}{{if $i}}, {{end}}{{$f}}{{end -}} } },
+{{- end}}
+}
+
+func Test_{{.Name}}Layout(t *testing.T) {
+	for _, v := range test{{.Name}}Vectors {
+		if id := {{.Name}}Compose(v.millis{{range $i, $f := .Fields}}, v.fields[{{$i}}]{{end}}); id != v.id {
+			t.Errorf("{{.Name}}Compose(%d, %v) = %#x, want %#x", v.millis, v.fields, id, v.id)
+		}
+		ts{{range .Fields}}, {{.Param}}{{end}} := {{.Name}}Decompose(v.id)
+		if ts.UnixMilli() != v.millis{{range $i, $f := .Fields}} || {{.Param}} != v.fields[{{$i}}]{{end}} {
+			t.Errorf("{{.Name}}Decompose(%#x) = %v{{range .Fields}} %d{{end}}, want %d %v", v.id, ts{{range .Fields}}, {{.Param}}{{end}}, v.millis, v.fields)
+		}
+		if !{{.Name}}Valid(v.id) {
+			t.Errorf("{{.Name}}Valid(%#x) = false", v.id)
+		}
+	}
+}
+`))
+
+// render 执行模板并格式化生成的代码
+func (g *genLayout) render(t *template.Template) ([]byte, error) {
+	var b bytes.Buffer
+	if err := t.Execute(&b, g); err != nil {
+		return nil, err
+	}
+	return format.Source(b.Bytes())
+}
+
+// Version 版本字段，仅在布局有版本位时用于模板
+func (g *genLayout) Version() genField {
+	return genField{Name: "Version", Shift: g.Timestamp.Shift + g.Timestamp.Bits, Bits: g.Layout.VersionBits}
+}
+
+// genLayoutCmd 为布局生成内联移位的 Go 代码，-o 指定文件时同时生成覆盖边界值的测试
+func genLayoutCmd(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("genlayout", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	pkg := fs.String("package", "main", "package name of the generated code")
+	name := fs.String("name", "ID", "prefix of the generated constants and functions")
+	out := fs.String("o", "", "output file, writes a _test.go file next to it; default stdout without tests")
+	lf := addLayoutFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return flag.ErrHelp
+	}
+	if !token.IsIdentifier(*pkg) || !token.IsIdentifier(*name) || !token.IsExported(*name) {
+		return fmt.Errorf("-package must be an identifier and -name an exported one")
+	}
+	l, err := lf.layout()
+	if err != nil {
+		return err
+	}
+	if err := l.Validate(); err != nil {
+		return err
+	}
+	g, err := newGenLayout(*pkg, *name, "snowflake genlayout "+strings.Join(args, " "), l)
+	if err != nil {
+		return err
+	}
+	src, err := g.render(genSource)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err := stdout.Write(src)
+		return err
+	}
+	test, err := g.render(genTest)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(strings.TrimSuffix(*out, ".go")+"_test.go", test, 0o644)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func Test_genLayoutCmd(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"genlayout", "-package", "ids", "-name", "Order", "-datacenter-bits", "0", "-worker-bits", "10"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	src := stdout.String()
+	if _, err := parser.ParseFile(token.NewFileSet(), "order.go", src, 0); err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, src)
+	}
+	for _, want := range []string{`DO NOT EDIT`, `package ids`, `OrderWorkerShift\s+= 12\n`, `func OrderCompose\(millis, workerID, sequence int64\) uint64`} {
+		if !regexp.MustCompile(want).MatchString(src) {
+			t.Errorf("generated code lacks %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(src, "Datacenter") {
+		t.Errorf("generated code has a datacenter field without datacenter bits:\n%s", src)
+	}
+	if code := run([]string{"genlayout", "-name", "lower"}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("unexported -name: exit %d", code)
+	}
+}
+
+// 生成的代码和测试在独立模块中编译并通过
+func Test_genLayoutCompiles(t *testing.T) {
+	gobin, err := exec.LookPath("go")
+	if err != nil || testing.Short() {
+		t.Skip("needs the go command")
+	}
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	args := []string{"genlayout", "-package", "ids", "-o", filepath.Join(dir, "id.go"), "-version-bits", "2", "-version", "1", "-timestamp-bits", "40", "-unsigned"}
+	if code := run(args, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module ids\n\ngo 1.22\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(gobin, "test", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GO111MODULE=on", "GOFLAGS=", "GOWORK=off")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go test: %v\n%s", err, out)
+	}
+}
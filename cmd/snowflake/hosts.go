@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/perlyna/snowflake"
+)
+
+// hostsCmd 读取主机清单，输出 HashMachineID 的映射表，有冲突时以非零状态退出
+func hostsCmd(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("hosts", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	lf := addLayoutFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return flag.ErrHelp
+	}
+	l, err := lf.layout()
+	if err != nil {
+		return err
+	}
+	var inputs []io.Reader
+	for _, name := range fs.Args() {
+		if name == "-" {
+			inputs = append(inputs, stdin)
+			continue
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		inputs = append(inputs, f)
+	}
+	if len(inputs) == 0 {
+		inputs = append(inputs, stdin)
+	}
+	var hosts []string
+	sc := bufio.NewScanner(io.MultiReader(inputs...))
+	for sc.Scan() {
+		// 每行一个主机名，# 之后为注释
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		hosts = append(hosts, line)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	table := snowflake.MachineIDTable(hosts, l)
+	collisions := 0
+	fmt.Fprintf(stdout, "%-10s %s\n", "machine_id", "host")
+	for _, row := range table {
+		if len(row.Collides) == 0 {
+			fmt.Fprintf(stdout, "%-10d %s\n", row.MachineID, row.Host)
+			continue
+		}
+		collisions++
+		fmt.Fprintf(stdout, "%-10d %s (collides with %s)\n", row.MachineID, row.Host, strings.Join(row.Collides, ", "))
+	}
+	fmt.Fprintf(stdout, "\nhosts: %d\ncolliding hosts: %d\n", len(table), collisions)
+	if collisions > 0 {
+		return errAuditFindings
+	}
+	return nil
+}
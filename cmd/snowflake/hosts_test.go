@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/perlyna/snowflake"
+)
+
+func Test_hostsCmd(t *testing.T) {
+	in := "web-01 # frontend\nweb-02\n\nWEB-01.\n"
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"hosts"}, strings.NewReader(in), &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	want := fmt.Sprintf("%-10d web-01\n", snowflake.HashMachineID("web-01", snowflake.DefaultLayout))
+	if !strings.Contains(stdout.String(), want) || !strings.Contains(stdout.String(), "hosts: 2\ncolliding hosts: 0\n") {
+		t.Errorf("output:\n%s", stdout.String())
+	}
+
+	// 1 位机器 id 空间，3 台主机必有冲突
+	stdout.Reset()
+	args := []string{"hosts", "-datacenter-bits", "0", "-worker-bits", "1", "-"}
+	if code := run(args, strings.NewReader("a\nb\nc\n"), &stdout, &stderr); code != 1 {
+		t.Errorf("collisions: exit %d", code)
+	}
+	if !strings.Contains(stdout.String(), "(collides with ") {
+		t.Errorf("output:\n%s", stdout.String())
+	}
+}
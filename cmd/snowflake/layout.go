@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+// layoutFlags 布局描述参数，默认与 DefaultLayout 一致
+type layoutFlags struct {
+	epoch                                       string
+	timestamp, datacenter, worker, sequenceBits uint
+	versionBits, version                        uint
+	unsigned                                    bool
+}
+
+func addLayoutFlags(fs *flag.FlagSet) *layoutFlags {
+	d := snowflake.DefaultLayout
+	f := &layoutFlags{}
+	fs.StringVar(&f.epoch, "epoch", d.Epoch.UTC().Format(time.RFC3339), "layout epoch, unix milliseconds or RFC 3339")
+	fs.UintVar(&f.timestamp, "timestamp-bits", uint(d.TimestampBits), "layout timestamp bits")
+	fs.UintVar(&f.datacenter, "datacenter-bits", uint(d.DatacenterBits), "layout datacenter bits")
+	fs.UintVar(&f.worker, "worker-bits", uint(d.WorkerBits), "layout worker bits")
+	fs.UintVar(&f.sequenceBits, "sequence-bits", uint(d.SequenceBits), "layout sequence bits")
+	fs.UintVar(&f.versionBits, "version-bits", 0, "layout version bits above the timestamp")
+	fs.UintVar(&f.version, "version", 0, "layout version")
+	fs.BoolVar(&f.unsigned, "unsigned", false, "layout uses the sign bit")
+	return f
+}
+
+func (f *layoutFlags) layout() (snowflake.Layout, error) {
+	l := snowflake.Layout{Unsigned: f.unsigned}
+	if ms, err := strconv.ParseInt(f.epoch, 10, 64); err == nil {
+		l.Epoch = time.UnixMilli(ms)
+	} else if t, err := time.Parse(time.RFC3339, f.epoch); err == nil {
+		l.Epoch = t
+	} else {
+		return l, fmt.Errorf("invalid epoch %q", f.epoch)
+	}
+	for _, b := range []struct {
+		dst *uint8
+		v   uint
+	}{{&l.TimestampBits, f.timestamp}, {&l.DatacenterBits, f.datacenter}, {&l.WorkerBits, f.worker}, {&l.SequenceBits, f.sequenceBits}, {&l.VersionBits, f.versionBits}, {&l.Version, f.version}} {
+		if b.v > 255 {
+			return l, fmt.Errorf("invalid layout value %d", b.v)
+		}
+		*b.dst = uint8(b.v)
+	}
+	return l, l.Validate()
+}
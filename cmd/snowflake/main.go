@@ -0,0 +1,186 @@
+// Command snowflake generates and decodes snowflake ids.
+//
+//	snowflake gen [-n count] [-worker id] [-datacenter id] [-format text|csv|jsonl|parquet] [-decompose] > ids.txt
+//	snowflake decode id...
+//	snowflake udf [-dialect clickhouse|bigquery|postgres] [-prefix name] [layout flags]
+//	snowflake audit [-max-worker n] [-max-datacenter n] [-bucket d] [-future d] [layout flags] [file...]
+//	snowflake hosts [layout flags] [inventory...]
+//	snowflake layout [layout flags]
+//	snowflake order [-skew d] [-listen addr] [layout flags] [file...]
+//	snowflake who -inventory file [layout flags] id...
+//	snowflake genlayout [-package name] [-name prefix] [-o file.go] [layout flags]
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/perlyna/snowflake"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+const usage = `usage:
+  snowflake gen [-n count] [-worker id] [-datacenter id] [-format text|csv|jsonl|parquet] [-decompose]
+  snowflake decode id...
+  snowflake udf [-dialect clickhouse|bigquery|postgres] [-prefix name] [layout flags]
+  snowflake audit [-max-worker n] [-max-datacenter n] [-bucket d] [-future d] [layout flags] [file...]
+  snowflake hosts [layout flags] [inventory...]
+  snowflake layout [layout flags]
+  snowflake order [-skew d] [-listen addr] [layout flags] [file...]
+  snowflake who -inventory file [layout flags] id...
+  snowflake genlayout [-package name] [-name prefix] [-o file.go] [layout flags]
+`
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	var err error
+	switch args[0] {
+	case "gen":
+		err = gen(args[1:], stdout, stderr)
+	case "decode":
+		err = decode(args[1:], stdout)
+	case "udf":
+		err = udf(args[1:], stdout, stderr)
+	case "audit":
+		err = auditCmd(args[1:], stdin, stdout, stderr)
+	case "hosts":
+		err = hostsCmd(args[1:], stdin, stdout, stderr)
+	case "layout":
+		err = layoutCmd(args[1:], stdout, stderr)
+	case "order":
+		err = orderCmd(args[1:], stdin, stdout, stderr)
+	case "who":
+		err = whoCmd(args[1:], stdout, stderr)
+	case "genlayout":
+		err = genLayoutCmd(args[1:], stdout, stderr)
+	default:
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if err == flag.ErrHelp {
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "snowflake %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// countFlag 接受 1e7 形式的整数
+type countFlag int64
+
+func (c *countFlag) String() string { return strconv.FormatInt(int64(*c), 10) }
+
+func (c *countFlag) Set(s string) error {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		*c = countFlag(n)
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f != math.Trunc(f) || f > math.MaxInt64 {
+		return fmt.Errorf("invalid count %q", s)
+	}
+	*c = countFlag(f)
+	return nil
+}
+
+func gen(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("gen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	n := countFlag(1)
+	fs.Var(&n, "n", "number of ids, negative for unlimited")
+	workerID := fs.Uint("worker", 0, "worker id")
+	datacenterID := fs.Uint("datacenter", 0, "datacenter id")
+	format := fs.String("format", "text", "output format: text, csv, jsonl or parquet")
+	decompose := fs.Bool("decompose", false, "add time, datacenter, worker and sequence columns")
+	if err := fs.Parse(args); err != nil {
+		// flag 已输出错误和用法
+		return flag.ErrHelp
+	}
+	if *workerID > math.MaxUint8 || *datacenterID > math.MaxUint8 {
+		return fmt.Errorf("worker and datacenter ids must be below 256")
+	}
+	g, err := snowflake.NewGenerator(uint8(*workerID), uint8(*datacenterID))
+	if err != nil {
+		return err
+	}
+	defer g.Close()
+	w := bufio.NewWriterSize(stdout, 64<<10)
+	if *format == "text" && !*decompose {
+		if _, err := snowflake.NewIDStream(g, int64(n)).WriteTo(w); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+	if *format == "text" {
+		return fmt.Errorf("-decompose requires -format csv, jsonl or parquet")
+	}
+	if n < 0 {
+		return fmt.Errorf("-format %s requires a count", *format)
+	}
+	rw, err := newRowWriter(*format, w, *decompose)
+	if err != nil {
+		return err
+	}
+	if err := export(g, int64(n), rw, *decompose); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// export 分批生成 n 个 id 并逐行写出
+func export(g snowflake.Generator, n int64, rw rowWriter, decompose bool) error {
+	ids := make([]int64, 1024)
+	for n > 0 {
+		batch := ids
+		if n < int64(len(batch)) {
+			batch = batch[:n]
+		}
+		if err := snowflake.Fill(g, batch); err != nil {
+			return err
+		}
+		for _, id := range batch {
+			var p snowflake.Parts
+			if decompose {
+				p = snowflake.DefaultLayout.Decompose(snowflake.ID(id))
+			}
+			if err := rw.write(snowflake.ID(id), p); err != nil {
+				return err
+			}
+		}
+		n -= int64(len(batch))
+	}
+	return rw.close()
+}
+
+func decode(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no ids")
+	}
+	for _, s := range args {
+		id, err := snowflake.Parse(s)
+		if err != nil {
+			// 不是十进制时按支持工单中常见的分组十六进制解析，如 0184-3F2A-9C01-7000
+			if hex, herr := snowflake.GroupedHex.Parse(s); herr == nil {
+				id, err = hex, nil
+			}
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "%s hex=%s\n", snowflake.Describe(id), snowflake.GroupedHex.Format(id))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/perlyna/snowflake"
+)
+
+func Test_gen(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"gen", "-n", "1e4", "-worker", "3"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if len(lines) != 10000 {
+		t.Fatalf("got %d ids", len(lines))
+	}
+	id, err := snowflake.Parse(lines[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := snowflake.Decompose(id); p.WorkerID != 3 {
+		t.Errorf("worker id = %d", p.WorkerID)
+	}
+
+	if code := run([]string{"gen", "-n", "1.5"}, nil, &stdout, &stderr); code != 2 {
+		t.Errorf("fractional count: exit %d", code)
+	}
+	if code := run([]string{"gen", "-worker", "99"}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("invalid worker: exit %d", code)
+	}
+}
+
+func Test_decode(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"decode", "4603909"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "id=4603909 ") {
+		t.Errorf("decode output %q", stdout.String())
+	}
+	if !strings.HasSuffix(stdout.String(), " hex=0000-0000-0046-4005\n") {
+		t.Errorf("decode output %q", stdout.String())
+	}
+	stdout.Reset()
+	if code := run([]string{"decode", "0000-0000-0046-4005"}, nil, &stdout, &stderr); code != 0 || !strings.HasPrefix(stdout.String(), "id=4603909 ") {
+		t.Errorf("decode grouped hex: exit %d, output %q", code, stdout.String())
+	}
+	if code := run([]string{"decode", "x"}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("invalid id: exit %d", code)
+	}
+	if code := run(nil, nil, &stdout, &stderr); code != 2 {
+		t.Errorf("no command: exit %d", code)
+	}
+}
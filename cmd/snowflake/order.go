@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+// orderRun 汇总一次 order 检查，违例随发现随输出
+type orderRun struct {
+	mu        sync.Mutex
+	c         *snowflake.OrderChecker
+	out       io.Writer
+	total     int
+	malformed int
+	counts    map[snowflake.OrderViolationKind]int
+}
+
+func newOrderRun(l snowflake.Layout, skew time.Duration, out io.Writer) *orderRun {
+	return &orderRun{c: snowflake.NewOrderChecker(l, skew), out: out, counts: map[snowflake.OrderViolationKind]int{}}
+}
+
+// read 逐行读取 r 中的 id，作为来源 source 检查
+func (o *orderRun) read(source string, r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		s := strings.TrimSpace(sc.Text())
+		if s == "" {
+			continue
+		}
+		id, err := snowflake.Parse(s)
+		o.mu.Lock()
+		if err != nil {
+			o.malformed++
+			fmt.Fprintf(o.out, "%s: malformed %q\n", source, s)
+		} else {
+			o.total++
+			for _, v := range o.c.Check(source, id) {
+				o.counts[v.Kind]++
+				fmt.Fprintln(o.out, v)
+			}
+		}
+		o.mu.Unlock()
+	}
+	return sc.Err()
+}
+
+// serve 接受连接直到 ctx 结束，所有连接的行按到达顺序合并为一个来源
+func (o *orderRun) serve(ctx context.Context, ln net.Listener) error {
+	source := ln.Addr().String()
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	stop := context.AfterFunc(ctx, func() { ln.Close() })
+	defer stop()
+	var conns sync.Map
+	defer conns.Range(func(c, _ any) bool {
+		c.(net.Conn).Close()
+		return true
+	})
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		conns.Store(conn, nil)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conns.Delete(conn)
+			defer conn.Close()
+			o.read(source, conn)
+		}()
+	}
+}
+
+// ok 没有任何违例
+func (o *orderRun) ok() bool {
+	return o.malformed == 0 && len(o.counts) == 0
+}
+
+func (o *orderRun) summary(w io.Writer) {
+	fmt.Fprintf(w, "ids: %d\n", o.total)
+	fmt.Fprintf(w, "malformed lines: %d\n", o.malformed)
+	for _, k := range []snowflake.OrderViolationKind{snowflake.NodeRegression, snowflake.SkewExceeded, snowflake.InvalidID} {
+		fmt.Fprintf(w, "%s: %d\n", k, o.counts[k])
+	}
+}
+
+func orderCmd(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("order", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	skew := fs.Duration("skew", time.Second, "tolerated clock skew between workers")
+	listen := fs.String("listen", "", "read newline-delimited ids from TCP connections on this address until interrupted, instead of files")
+	lf := addLayoutFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return flag.ErrHelp
+	}
+	l, err := lf.layout()
+	if err != nil {
+		return err
+	}
+	o := newOrderRun(l, *skew, stdout)
+	switch {
+	case *listen != "":
+		if fs.NArg() > 0 {
+			return fmt.Errorf("-listen does not take files")
+		}
+		ln, err := net.Listen("tcp", *listen)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "listening on %s, interrupt to stop\n", ln.Addr())
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+		if err := o.serve(ctx, ln); err != nil {
+			return err
+		}
+	case fs.NArg() == 0:
+		if err := o.read("stdin", stdin); err != nil {
+			return err
+		}
+	default:
+		for _, name := range fs.Args() {
+			if name == "-" {
+				if err := o.read("stdin", stdin); err != nil {
+					return err
+				}
+				continue
+			}
+			f, err := os.Open(name)
+			if err != nil {
+				return err
+			}
+			err = o.read(name, f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	o.summary(stdout)
+	if !o.ok() {
+		return errAuditFindings
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+func Test_orderCmd(t *testing.T) {
+	g1, _ := snowflake.NewGenerator(1, 0)
+	g2, _ := snowflake.NewGenerator(2, 0)
+	ids1, _ := g1.NextN(50)
+	ids2, _ := g2.NextN(50)
+	var in strings.Builder
+	for i := range ids1 {
+		fmt.Fprintln(&in, ids1[i])
+		fmt.Fprintln(&in, ids2[i])
+	}
+	path := filepath.Join(t.TempDir(), "ids.txt")
+	if err := os.WriteFile(path, []byte(in.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"order", path}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "ids: 100\n") || !strings.Contains(stdout.String(), "node regression: 0\n") {
+		t.Errorf("output:\n%s", stdout.String())
+	}
+
+	// worker 1 的 id 重复出现在末尾
+	stdout.Reset()
+	bad := strings.NewReader(in.String() + fmt.Sprintln(ids1[3]))
+	if code := run([]string{"order", "-"}, bad, &stdout, &stderr); code != 1 {
+		t.Errorf("regression: exit %d", code)
+	}
+	want := fmt.Sprintf("stdin:101: node regression: %d (datacenter 0 worker 1) after %d at line 99", ids1[3], ids1[49])
+	if !strings.Contains(stdout.String(), want) || !strings.Contains(stdout.String(), "node regression: 1\n") {
+		t.Errorf("output:\n%s", stdout.String())
+	}
+}
+
+func Test_orderServe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	o := newOrderRun(snowflake.DefaultLayout, time.Second, &out)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- o.serve(ctx, ln) }()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, _ := snowflake.NewGenerator(1, 1)
+	ids, _ := g.NextN(3)
+	fmt.Fprintf(conn, "%d\n%d\n%d\n", ids[1], ids[0], ids[2])
+	conn.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		o.mu.Lock()
+		total := o.total
+		o.mu.Unlock()
+		if total == 3 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if o.total != 3 || o.counts[snowflake.NodeRegression] != 1 || !strings.Contains(out.String(), "node regression") {
+		t.Errorf("total %d counts %v output:\n%s", o.total, o.counts, out.String())
+	}
+}
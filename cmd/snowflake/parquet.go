@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"sort"
+
+	"github.com/perlyna/snowflake"
+)
+
+// 最小的 Parquet 写入器：全部列为 REQUIRED INT64，PLAIN 编码、不压缩，
+// 每 parquetRowGroup 行输出一个 row group，元数据使用 Thrift compact 协议，
+// 文件 key_value_metadata 中记录 DefaultLayout 的 Layout.Metadata
+
+const parquetRowGroup = 1 << 20
+
+const (
+	parquetInt64           = 2 // Type.INT64
+	parquetRequired        = 0 // FieldRepetitionType.REQUIRED
+	parquetTimestampMillis = 9 // ConvertedType.TIMESTAMP_MILLIS
+	parquetPlain           = 0 // Encoding.PLAIN
+	parquetRLE             = 3 // Encoding.RLE
+	parquetUncompressed    = 0 // CompressionCodec.UNCOMPRESSED
+	parquetDataPage        = 0 // PageType.DATA_PAGE
+)
+
+var parquetMagic = []byte("PAR1")
+
+type parquetWriter struct {
+	w         io.Writer
+	offset    int64
+	err       error
+	ncols     int
+	values    [][]byte // 当前 row group 各列的 PLAIN 编码值
+	rows      int64
+	total     int64
+	rowGroups []parquetRowGroupMeta
+}
+
+type parquetColumnMeta struct {
+	offset int64 // data page 起始位置
+	size   int64 // page header 加数据的字节数
+}
+
+type parquetRowGroupMeta struct {
+	rows    int64
+	columns []parquetColumnMeta
+}
+
+func newParquetWriter(w io.Writer, decompose bool) *parquetWriter {
+	p := &parquetWriter{w: w, ncols: 1}
+	if decompose {
+		p.ncols = len(columns)
+	}
+	p.values = make([][]byte, p.ncols)
+	p.emit(parquetMagic)
+	return p
+}
+
+func (p *parquetWriter) emit(b []byte) {
+	if p.err != nil {
+		return
+	}
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	p.err = err
+}
+
+func (p *parquetWriter) write(id snowflake.ID, parts snowflake.Parts) error {
+	row := [...]int64{int64(id), parts.Time.UnixMilli(), parts.DatacenterID, parts.WorkerID, parts.Sequence}
+	for i := range p.values {
+		p.values[i] = binary.LittleEndian.AppendUint64(p.values[i], uint64(row[i]))
+	}
+	p.rows++
+	if p.rows == parquetRowGroup {
+		p.flushRowGroup()
+	}
+	return p.err
+}
+
+func (p *parquetWriter) flushRowGroup() {
+	if p.rows == 0 {
+		return
+	}
+	rg := parquetRowGroupMeta{rows: p.rows}
+	for i, values := range p.values {
+		var h thriftWriter
+		h.fieldI32(1, parquetDataPage)
+		h.fieldI32(2, int32(len(values)))
+		h.fieldI32(3, int32(len(values)))
+		h.fieldStruct(5)
+		h.fieldI32(1, int32(p.rows))
+		h.fieldI32(2, parquetPlain)
+		h.fieldI32(3, parquetRLE)
+		h.fieldI32(4, parquetRLE)
+		h.end()
+		h.end()
+		col := parquetColumnMeta{offset: p.offset, size: int64(len(h.buf) + len(values))}
+		p.emit(h.buf)
+		p.emit(values)
+		rg.columns = append(rg.columns, col)
+		p.values[i] = values[:0]
+	}
+	p.rowGroups = append(p.rowGroups, rg)
+	p.total += p.rows
+	p.rows = 0
+}
+
+func (p *parquetWriter) close() error {
+	p.flushRowGroup()
+	var m thriftWriter
+	m.fieldI32(1, 1)
+	m.fieldList(2, thriftStruct, p.ncols+1)
+	m.fieldBinary(4, "schema")
+	m.fieldI32(5, int32(p.ncols))
+	m.end()
+	for i := 0; i < p.ncols; i++ {
+		m.fieldI32(1, parquetInt64)
+		m.fieldI32(3, parquetRequired)
+		m.fieldBinary(4, columns[i])
+		if columns[i] == "time" {
+			m.fieldI32(6, parquetTimestampMillis)
+		}
+		m.end()
+	}
+	m.fieldI64(3, p.total)
+	m.fieldList(4, thriftStruct, len(p.rowGroups))
+	for _, rg := range p.rowGroups {
+		var size int64
+		m.fieldList(1, thriftStruct, len(rg.columns))
+		for i, col := range rg.columns {
+			size += col.size
+			m.fieldI64(2, col.offset)
+			m.fieldStruct(3)
+			m.fieldI32(1, parquetInt64)
+			m.fieldList(2, thriftI32, 2)
+			m.varint(parquetPlain)
+			m.varint(parquetRLE)
+			m.fieldList(3, thriftBinary, 1)
+			m.binary(columns[i])
+			m.fieldI32(4, parquetUncompressed)
+			m.fieldI64(5, rg.rows)
+			m.fieldI64(6, col.size)
+			m.fieldI64(7, col.size)
+			m.fieldI64(9, col.offset)
+			m.end()
+			m.end()
+		}
+		m.fieldI64(2, size)
+		m.fieldI64(3, rg.rows)
+		m.end()
+	}
+	// 布局元数据，下游据此解码 id 列
+	meta := snowflake.DefaultLayout.Metadata()
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	m.fieldList(5, thriftStruct, len(keys))
+	for _, k := range keys {
+		m.fieldBinary(1, k)
+		m.fieldBinary(2, meta[k])
+		m.end()
+	}
+	m.fieldBinary(6, "github.com/perlyna/snowflake")
+	m.end()
+	p.emit(m.buf)
+	p.emit(binary.LittleEndian.AppendUint32(nil, uint32(len(m.buf))))
+	p.emit(parquetMagic)
+	return p.err
+}
+
+// Thrift compact 协议的元素类型
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter 按 Thrift compact 协议编码结构体，last 记录各层结构体上一个字段 id
+type thriftWriter struct {
+	buf  []byte
+	last []int16
+}
+
+func (t *thriftWriter) varint(v int64) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(v<<1^v>>63))
+}
+
+func (t *thriftWriter) binary(s string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	var last int16
+	if n := len(t.last); n > 0 {
+		last = t.last[n-1]
+		t.last[n-1] = id
+	} else {
+		t.last = append(t.last, id)
+	}
+	if delta := id - last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+		return
+	}
+	t.buf = append(t.buf, typ)
+	t.varint(int64(id))
+}
+
+func (t *thriftWriter) fieldI32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) fieldI64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) fieldBinary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.binary(s)
+}
+
+// fieldStruct 开始嵌套结构体字段，以 end 结束
+func (t *thriftWriter) fieldStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.last = append(t.last, 0)
+}
+
+// fieldList 开始列表字段；元素为结构体时每个元素以 end 结束
+func (t *thriftWriter) fieldList(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elem)
+	} else {
+		t.buf = append(t.buf, 0xF0|elem)
+		t.buf = binary.AppendUvarint(t.buf, uint64(n))
+	}
+	if elem == thriftStruct {
+		// 列表中的每个结构体各自从字段 0 开始
+		for i := 0; i < n; i++ {
+			t.last = append(t.last, 0)
+		}
+	}
+}
+
+// end 结束当前结构体
+func (t *thriftWriter) end() {
+	t.buf = append(t.buf, 0)
+	if n := len(t.last); n > 0 {
+		t.last = t.last[:n-1]
+	}
+}
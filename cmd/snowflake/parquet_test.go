@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+// thriftReader 解码 Thrift compact 结构体为 map，仅用于校验写入结果
+type thriftReader struct {
+	b   []byte
+	err error
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		r.err = fmt.Errorf("bad varint")
+		return 0
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := r.uvarint()
+		if r.err != nil || uint64(len(r.b)) < n {
+			r.err = fmt.Errorf("short binary")
+			return nil
+		}
+		s := string(r.b[:n])
+		r.b = r.b[n:]
+		return s
+	case thriftList:
+		h := r.b[0]
+		r.b = r.b[1:]
+		n := uint64(h >> 4)
+		if n == 15 {
+			n = r.uvarint()
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = r.value(h & 0x0F)
+		}
+		return list
+	case thriftStruct:
+		m := map[int16]any{}
+		var last int16
+		for r.err == nil && len(r.b) > 0 {
+			h := r.b[0]
+			r.b = r.b[1:]
+			if h == 0 {
+				return m
+			}
+			id := last + int16(h>>4)
+			if h>>4 == 0 {
+				id = int16(r.varint())
+			}
+			m[id] = r.value(h & 0x0F)
+			last = id
+		}
+		r.err = fmt.Errorf("unterminated struct")
+		return nil
+	}
+	r.err = fmt.Errorf("unsupported type %d", typ)
+	return nil
+}
+
+func Test_genParquet(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"gen", "-n", "2000", "-worker", "5", "-format", "parquet", "-decompose"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	b := stdout.Bytes()
+	if !bytes.HasPrefix(b, parquetMagic) || !bytes.HasSuffix(b, parquetMagic) {
+		t.Fatal("missing PAR1 magic")
+	}
+	footer := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	r := &thriftReader{b: b[len(b)-8-footer : len(b)-8]}
+	meta, _ := r.value(thriftStruct).(map[int16]any)
+	if r.err != nil || len(r.b) != 0 {
+		t.Fatalf("footer: %v, %d bytes left", r.err, len(r.b))
+	}
+	if meta[3] != int64(2000) {
+		t.Errorf("num_rows = %v", meta[3])
+	}
+	kv := map[string]string{}
+	for _, e := range meta[5].([]any) {
+		kv[e.(map[int16]any)[1].(string)] = e.(map[int16]any)[2].(string)
+	}
+	if l, err := snowflake.LayoutFromMetadata(kv); err != nil || l.Fingerprint() != snowflake.DefaultLayout.Fingerprint() {
+		t.Errorf("key_value_metadata layout = %+v, %v", l, err)
+	}
+	schema := meta[2].([]any)
+	if len(schema) != len(columns)+1 || schema[2].(map[int16]any)[4] != "time" || schema[2].(map[int16]any)[6] != int64(parquetTimestampMillis) {
+		t.Fatalf("schema = %v", schema)
+	}
+
+	chunks := meta[4].([]any)[0].(map[int16]any)[1].([]any)
+	if len(chunks) != len(columns) {
+		t.Fatalf("%d column chunks", len(chunks))
+	}
+	// 读取 id 和 worker 列的第一页，核对解码结果
+	column := func(i int) []int64 {
+		cm := chunks[i].(map[int16]any)[3].(map[int16]any)
+		off := cm[9].(int64)
+		pr := &thriftReader{b: b[off:]}
+		page := pr.value(thriftStruct).(map[int16]any)
+		if pr.err != nil || page[2] != int64(2000*8) {
+			t.Fatalf("page header %v: %v", page, pr.err)
+		}
+		values := make([]int64, 2000)
+		for j := range values {
+			values[j] = int64(binary.LittleEndian.Uint64(pr.b[j*8:]))
+		}
+		return values
+	}
+	ids, times, workers := column(0), column(1), column(3)
+	for j, id := range ids {
+		p := snowflake.DefaultLayout.Decompose(snowflake.ID(id))
+		if j > 0 && id <= ids[j-1] || workers[j] != 5 || times[j] != p.Time.UnixMilli() {
+			t.Fatalf("row %d: id %d, time %d, worker %d", j, id, times[j], workers[j])
+		}
+	}
+	if time.Since(time.UnixMilli(times[0])) > time.Minute {
+		t.Errorf("time column %d", times[0])
+	}
+}
+
+func Test_parquetRowGroups(t *testing.T) {
+	var buf bytes.Buffer
+	p := newParquetWriter(&buf, false)
+	for i := int64(1); i <= parquetRowGroup+1; i++ {
+		if err := p.write(snowflake.ID(i), snowflake.Parts{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.close(); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	footer := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	r := &thriftReader{b: b[len(b)-8-footer : len(b)-8]}
+	meta := r.value(thriftStruct).(map[int16]any)
+	groups := meta[4].([]any)
+	if r.err != nil || len(groups) != 2 || groups[1].(map[int16]any)[3] != int64(1) {
+		t.Errorf("row groups = %v, %v", len(groups), r.err)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+)
+
+// layoutCmd 输出布局的容量和互操作分析，有警告时以非零状态退出，便于在 CI 中检查配置
+func layoutCmd(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("layout", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	lf := addLayoutFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return flag.ErrHelp
+	}
+	l, err := lf.layout()
+	if err != nil {
+		return err
+	}
+	r := l.Report()
+	fmt.Fprint(stdout, r)
+	if len(r.Warnings) > 0 {
+		return errAuditFindings
+	}
+	return nil
+}
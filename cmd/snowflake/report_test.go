@@ -0,0 +1,24 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_layoutCmd(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"layout"}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "timestamp exhausted: 2088-09-06\n") {
+		t.Errorf("output:\n%s", stdout.String())
+	}
+	stdout.Reset()
+	if code := run([]string{"layout", "-timestamp-bits", "42", "-datacenter-bits", "0", "-worker-bits", "10", "-unsigned"}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("sign bit: exit %d", code)
+	}
+	if !strings.Contains(stdout.String(), "warning: ids are negative") {
+		t.Errorf("output:\n%s", stdout.String())
+	}
+}
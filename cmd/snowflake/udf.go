@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/perlyna/snowflake"
+)
+
+// udfField 一个解码函数：id 右移 shift 位后取 bits 位
+type udfField struct {
+	name  string
+	shift uint8
+	bits  uint8
+}
+
+func udfFields(l snowflake.Layout) []udfField {
+	return []udfField{
+		{"version", l.SequenceBits + l.WorkerBits + l.DatacenterBits + l.TimestampBits, l.VersionBits},
+		{"time", l.SequenceBits + l.WorkerBits + l.DatacenterBits, l.TimestampBits},
+		{"datacenter", l.SequenceBits + l.WorkerBits, l.DatacenterBits},
+		{"worker", l.SequenceBits, l.WorkerBits},
+		{"sequence", 0, l.SequenceBits},
+	}
+}
+
+// extract 返回取出字段的表达式，掩码同时去掉有符号列右移带来的符号扩展
+func (f udfField) extract(id string, shift, and func(a, b string) string) string {
+	mask := fmt.Sprint(uint64(1)<<f.bits - 1)
+	if f.shift == 0 {
+		return and(id, mask)
+	}
+	return and(shift(id, fmt.Sprint(f.shift)), mask)
+}
+
+// writeUDF 输出指定方言的 CREATE FUNCTION 语句，函数名为 prefix 加字段名
+func writeUDF(w io.Writer, dialect, prefix string, l snowflake.Layout) error {
+	epoch := l.Epoch.UnixMilli()
+	infix := func(op string) func(a, b string) string {
+		return func(a, b string) string { return "(" + a + " " + op + " " + b + ")" }
+	}
+	var b strings.Builder
+	for _, f := range udfFields(l) {
+		if f.bits == 0 {
+			continue
+		}
+		name := prefix + f.name
+		switch dialect {
+		case "clickhouse":
+			expr := f.extract("id", func(a, b string) string { return "bitShiftRight(" + a + ", " + b + ")" },
+				func(a, b string) string { return "bitAnd(" + a + ", " + b + ")" })
+			if f.name == "time" {
+				expr = fmt.Sprintf("fromUnixTimestamp64Milli(toInt64(%s) + %d, 'UTC')", expr, epoch)
+			}
+			fmt.Fprintf(&b, "CREATE OR REPLACE FUNCTION %s AS (id) -> %s;\n", name, expr)
+		case "bigquery":
+			expr := f.extract("id", infix(">>"), infix("&"))
+			typ := "INT64"
+			if f.name == "time" {
+				expr, typ = fmt.Sprintf("TIMESTAMP_MILLIS(%s + %d)", expr, epoch), "TIMESTAMP"
+			}
+			fmt.Fprintf(&b, "CREATE OR REPLACE FUNCTION %s(id INT64) RETURNS %s AS (%s);\n", name, typ, expr)
+		case "postgres":
+			expr := f.extract("id", infix(">>"), infix("&"))
+			typ := "bigint"
+			if f.name == "time" {
+				expr, typ = fmt.Sprintf("to_timestamp((%s + %d) / 1000.0)", expr, epoch), "timestamptz"
+			}
+			fmt.Fprintf(&b, "CREATE OR REPLACE FUNCTION %s(id bigint) RETURNS %s LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$ SELECT %s $$;\n", name, typ, expr)
+		default:
+			return fmt.Errorf("unknown dialect %q, want clickhouse, bigquery or postgres", dialect)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func udf(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("udf", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dialect := fs.String("dialect", "postgres", "SQL dialect: clickhouse, bigquery or postgres")
+	prefix := fs.String("prefix", "snowflake_", "function name prefix, may include a schema or dataset")
+	lf := addLayoutFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return flag.ErrHelp
+	}
+	l, err := lf.layout()
+	if err != nil {
+		return err
+	}
+	return writeUDF(stdout, *dialect, *prefix, l)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+func Test_udfFieldsMatchLayout(t *testing.T) {
+	layouts := []snowflake.Layout{
+		snowflake.DefaultLayout,
+		{Epoch: time.UnixMilli(1288834974657), TimestampBits: 41, WorkerBits: 10, SequenceBits: 12},
+		{Epoch: time.UnixMilli(0), TimestampBits: 42, DatacenterBits: 3, WorkerBits: 7, SequenceBits: 12, Unsigned: true},
+	}
+	ids := []uint64{1, 4603909, 1<<62 | 12345, 1<<63 | 1<<40 | 7}
+	for _, l := range layouts {
+		for _, id := range ids {
+			p := l.Decompose(snowflake.ID(id))
+			for _, f := range udfFields(l) {
+				// 与 SQL 表达式相同：有符号右移后取掩码
+				got := int64(id) >> f.shift & int64(uint64(1)<<f.bits-1)
+				var want int64
+				switch f.name {
+				case "time":
+					want = p.Time.UnixMilli() - l.Epoch.UnixMilli()
+				case "datacenter":
+					want = p.DatacenterID
+				case "worker":
+					want = p.WorkerID
+				case "sequence":
+					want = p.Sequence
+				}
+				if l.Unsigned || id < 1<<63 {
+					if got != want {
+						t.Errorf("layout %+v id %d %s = %d, want %d", l, id, f.name, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_udf(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-dialect", "postgres"}, []string{
+			"CREATE OR REPLACE FUNCTION snowflake_time(id bigint) RETURNS timestamptz LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$ SELECT to_timestamp((((id >> 22) & 2199023255551) + 1546272000000) / 1000.0) $$;",
+			"CREATE OR REPLACE FUNCTION snowflake_datacenter(id bigint) RETURNS bigint LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$ SELECT ((id >> 17) & 31) $$;",
+			"CREATE OR REPLACE FUNCTION snowflake_sequence(id bigint) RETURNS bigint LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$ SELECT (id & 4095) $$;",
+		}},
+		{[]string{"-dialect", "bigquery", "-prefix", "ds.sf_"}, []string{
+			"CREATE OR REPLACE FUNCTION ds.sf_time(id INT64) RETURNS TIMESTAMP AS (TIMESTAMP_MILLIS(((id >> 22) & 2199023255551) + 1546272000000));",
+			"CREATE OR REPLACE FUNCTION ds.sf_worker(id INT64) RETURNS INT64 AS (((id >> 12) & 31));",
+		}},
+		{[]string{"-dialect", "clickhouse", "-epoch", "0", "-datacenter-bits", "0", "-worker-bits", "10"}, []string{
+			"CREATE OR REPLACE FUNCTION snowflake_time AS (id) -> fromUnixTimestamp64Milli(toInt64(bitAnd(bitShiftRight(id, 22), 2199023255551)) + 0, 'UTC');",
+			"CREATE OR REPLACE FUNCTION snowflake_worker AS (id) -> bitAnd(bitShiftRight(id, 12), 1023);",
+		}},
+		{[]string{"-dialect", "clickhouse", "-timestamp-bits", "40", "-version-bits", "1", "-version", "1"}, []string{
+			"CREATE OR REPLACE FUNCTION snowflake_version AS (id) -> bitAnd(bitShiftRight(id, 62), 1);",
+		}},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := run(append([]string{"udf"}, tt.args...), nil, &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: exit %d: %s", tt.args, code, stderr.String())
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(stdout.String(), want+"\n") {
+				t.Errorf("%v: output missing %q:\n%s", tt.args, want, stdout.String())
+			}
+		}
+	}
+	var stdout bytes.Buffer
+	run([]string{"udf", "-datacenter-bits", "0"}, nil, &stdout, &bytes.Buffer{})
+	if strings.Contains(stdout.String(), "datacenter") {
+		t.Error("layouts without datacenter bits should not get a datacenter function")
+	}
+	if code := run([]string{"udf", "-dialect", "mysql"}, nil, &stdout, &bytes.Buffer{}); code != 1 {
+		t.Errorf("unknown dialect: exit %d", code)
+	}
+	if code := run([]string{"udf", "-timestamp-bits", "60"}, nil, &stdout, &bytes.Buffer{}); code != 1 {
+		t.Errorf("invalid layout: exit %d", code)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+// whoCmd 按机群清单输出签发每个 id 的主机，有 id 找不到主机时以非零状态退出
+func whoCmd(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("who", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	path := fs.String("inventory", "", "JSON array of worker id assignments")
+	lf := addLayoutFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return flag.ErrHelp
+	}
+	if *path == "" || fs.NArg() == 0 {
+		return fmt.Errorf("-inventory and at least one id are required")
+	}
+	l, err := lf.layout()
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(*path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	inv, err := snowflake.LoadInventory(f, l)
+	if err != nil {
+		return err
+	}
+	var unknown bool
+	for _, s := range fs.Args() {
+		id, err := snowflake.Parse(s)
+		if err != nil {
+			return err
+		}
+		p := l.Decompose(id)
+		a, ok := inv.WhoIssued(id)
+		if !ok {
+			unknown = true
+			fmt.Fprintf(stdout, "%d: unknown issuer (datacenter %d worker %d at %s)\n", id, p.DatacenterID, p.WorkerID, p.Time.UTC().Format(time.RFC3339Nano))
+			continue
+		}
+		fmt.Fprintf(stdout, "%d: host=%s", id, a.Host)
+		if a.Pod != "" {
+			fmt.Fprintf(stdout, " pod=%s", a.Pod)
+		}
+		keys := make([]string, 0, len(a.Metadata))
+		for k := range a.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var meta strings.Builder
+		for _, k := range keys {
+			fmt.Fprintf(&meta, " %s=%s", k, a.Metadata[k])
+		}
+		fmt.Fprintf(stdout, "%s datacenter=%d worker=%d time=%s\n", meta.String(), p.DatacenterID, p.WorkerID, p.Time.UTC().Format(time.RFC3339Nano))
+	}
+	if unknown {
+		return errAuditFindings
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/perlyna/snowflake"
+)
+
+func Test_whoCmd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fleet.json")
+	inventory := `[{"datacenter": 2, "worker": 5, "host": "web-7", "pod": "api-5d9f", "metadata": {"region": "eu"}}]`
+	if err := os.WriteFile(path, []byte(inventory), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g, _ := snowflake.NewGenerator(5, 2)
+	id, _ := g.Next()
+	other, _ := snowflake.NewGenerator(6, 2)
+	stray, _ := other.Next()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"who", "-inventory", path, fmt.Sprint(id)}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	if want := fmt.Sprintf("%d: host=web-7 pod=api-5d9f region=eu datacenter=2 worker=5 time=", id); !strings.HasPrefix(stdout.String(), want) {
+		t.Errorf("output %q, want prefix %q", stdout.String(), want)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"who", "-inventory", path, fmt.Sprint(stray)}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("unknown issuer: exit %d", code)
+	}
+	if !strings.Contains(stdout.String(), "unknown issuer (datacenter 2 worker 6") {
+		t.Errorf("output %q", stdout.String())
+	}
+}
@@ -0,0 +1,142 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Headers carrying the ids of a Correlation besides RequestIDHeader
+const (
+	CorrelationIDHeader = "X-Correlation-Id"
+	CausationIDHeader   = "X-Causation-Id"
+)
+
+// Correlation ids relating one request or message to the chain of work it belongs to
+type Correlation struct {
+	RequestID     ID // this request or message
+	CausationID   ID // the request that directly caused it, 0 at the root of the chain
+	CorrelationID ID // the root request, shared by the whole chain
+}
+
+// NewCorrelation return the ids of a root request: a new request id from w, which is also
+// the correlation id
+func NewCorrelation(w Worker) (Correlation, error) {
+	id, err := w.Next()
+	if err != nil {
+		return Correlation{}, err
+	}
+	return Correlation{RequestID: ID(id), CorrelationID: ID(id)}, nil
+}
+
+// Child return the ids of a request caused by c, e.g. an outgoing call made while serving
+// c: a new request id from w, caused by c.RequestID, in c's correlation
+func (c Correlation) Child(w Worker) (Correlation, error) {
+	id, err := w.Next()
+	if err != nil {
+		return Correlation{}, err
+	}
+	return Correlation{RequestID: ID(id), CausationID: c.RequestID, CorrelationID: c.CorrelationID}, nil
+}
+
+// Metadata return the ids as lower case header names and decimal values, the form of gRPC
+// metadata (metadata.New(c.Metadata())) and message broker headers; CausationID is omitted at the root
+func (c Correlation) Metadata() map[string]string {
+	m := map[string]string{
+		strings.ToLower(RequestIDHeader):     strconv.FormatInt(int64(c.RequestID), 10),
+		strings.ToLower(CorrelationIDHeader): strconv.FormatInt(int64(c.CorrelationID), 10),
+	}
+	if c.CausationID != 0 {
+		m[strings.ToLower(CausationIDHeader)] = strconv.FormatInt(int64(c.CausationID), 10)
+	}
+	return m
+}
+
+// SetHeader stamp the ids into h, for the request c describes
+func (c Correlation) SetHeader(h http.Header) {
+	for k, v := range c.Metadata() {
+		h.Set(k, v)
+	}
+	if c.CausationID == 0 {
+		h.Del(CausationIDHeader)
+	}
+}
+
+// CorrelationFromMetadata read the ids written by Metadata or SetHeader through get, e.g.
+// http.Header.Get or the first value of a gRPC metadata key; false unless the request id
+// parses. A missing correlation id falls back to the request id, starting a chain there.
+func CorrelationFromMetadata(get func(key string) string) (Correlation, bool) {
+	var c Correlation
+	var err error
+	if c.RequestID, err = Parse(get(strings.ToLower(RequestIDHeader))); err != nil {
+		return Correlation{}, false
+	}
+	if c.CorrelationID, err = Parse(get(strings.ToLower(CorrelationIDHeader))); err != nil {
+		c.CorrelationID = c.RequestID
+	}
+	c.CausationID, _ = Parse(get(strings.ToLower(CausationIDHeader)))
+	return c, true
+}
+
+type correlationKey struct{}
+
+// ContextWithCorrelation return ctx carrying c, and c.RequestID for IDFromContext
+func ContextWithCorrelation(ctx context.Context, c Correlation) context.Context {
+	return context.WithValue(ContextWithID(ctx, c.RequestID), correlationKey{}, c)
+}
+
+// CorrelationFromContext return the ids carried by ctx, false when there are none
+func CorrelationFromContext(ctx context.Context) (Correlation, bool) {
+	c, ok := ctx.Value(correlationKey{}).(Correlation)
+	return c, ok
+}
+
+// CorrelationMiddleware return net/http middleware putting each request's Correlation on its
+// context and response headers: read from the incoming headers, or a new root from w when the
+// caller sent no request id. If w fails the request is served without ids.
+func CorrelationMiddleware(w Worker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			c, ok := CorrelationFromMetadata(r.Header.Get)
+			if !ok {
+				var err error
+				if c, err = NewCorrelation(w); err != nil {
+					next.ServeHTTP(rw, r)
+					return
+				}
+				c.SetHeader(r.Header)
+			}
+			c.SetHeader(rw.Header())
+			next.ServeHTTP(rw, r.WithContext(ContextWithCorrelation(r.Context(), c)))
+		})
+	}
+}
+
+// CorrelationTransport return an http.RoundTripper stamping every outgoing request made with
+// a context carrying a Correlation with a child of it from w; base nil means
+// http.DefaultTransport. Requests without one, or when w fails, are sent unchanged.
+func CorrelationTransport(w Worker, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return correlationTransport{w: w, base: base}
+}
+
+type correlationTransport struct {
+	w    Worker
+	base http.RoundTripper
+}
+
+func (t correlationTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if c, ok := CorrelationFromContext(r.Context()); ok {
+		if child, err := c.Child(t.w); err == nil {
+			// RoundTripper 不得修改传入的请求
+			r = r.Clone(r.Context())
+			child.SetHeader(r.Header)
+		}
+	}
+	return t.base.RoundTrip(r)
+}
@@ -0,0 +1,86 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_Correlation(t *testing.T) {
+	w := NewWorker(0, 0)
+	root, err := NewCorrelation(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.RequestID == 0 || root.CorrelationID != root.RequestID || root.CausationID != 0 {
+		t.Fatalf("root = %+v", root)
+	}
+	child, err := root.Child(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if child.RequestID <= root.RequestID || child.CausationID != root.RequestID || child.CorrelationID != root.CorrelationID {
+		t.Fatalf("child = %+v", child)
+	}
+
+	m := child.Metadata()
+	if got, ok := CorrelationFromMetadata(func(k string) string { return m[k] }); !ok || got != child {
+		t.Errorf("metadata round trip = %+v, %v", got, ok)
+	}
+	if _, ok := root.Metadata()["x-causation-id"]; ok {
+		t.Error("root metadata carries a causation id")
+	}
+	h := http.Header{}
+	child.SetHeader(h)
+	root.SetHeader(h)
+	if got, ok := CorrelationFromMetadata(h.Get); !ok || got != root {
+		t.Errorf("header round trip = %+v, %v", got, ok)
+	}
+	if _, ok := CorrelationFromMetadata(http.Header{}.Get); ok {
+		t.Error("empty headers parsed")
+	}
+	h = http.Header{RequestIDHeader: {"42"}}
+	if got, _ := CorrelationFromMetadata(h.Get); got.CorrelationID != 42 {
+		t.Errorf("missing correlation id = %+v", got)
+	}
+}
+
+func Test_CorrelationMiddleware(t *testing.T) {
+	w := NewWorker(0, 0)
+	// 下游服务记录收到的 id
+	var downstream Correlation
+	backend := httptest.NewServer(CorrelationMiddleware(w)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		downstream, _ = CorrelationFromContext(r.Context())
+	})))
+	defer backend.Close()
+	client := &http.Client{Transport: CorrelationTransport(w, nil)}
+
+	var upstream Correlation
+	h := CorrelationMiddleware(w)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		upstream, _ = CorrelationFromContext(r.Context())
+		if id, ok := IDFromContext(r.Context()); !ok || id != upstream.RequestID {
+			t.Errorf("IDFromContext = %d, %v", id, ok)
+		}
+		req, _ := http.NewRequestWithContext(r.Context(), "GET", backend.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+		if req.Header.Get(RequestIDHeader) != "" {
+			t.Error("transport modified the caller's request")
+		}
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if upstream.RequestID == 0 || rec.Header().Get(RequestIDHeader) == "" {
+		t.Fatalf("upstream = %+v, headers %v", upstream, rec.Header())
+	}
+	if downstream.CausationID != upstream.RequestID || downstream.CorrelationID != upstream.CorrelationID || downstream.RequestID == upstream.RequestID {
+		t.Errorf("downstream = %+v, upstream = %+v", downstream, upstream)
+	}
+}
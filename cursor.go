@@ -0,0 +1,77 @@
+package snowflake
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+)
+
+// cursorMACLen 签名游标中截断后的 HMAC-SHA256 长度
+const cursorMACLen = 16
+
+// ErrInvalidCursor cursor is malformed or its signature does not match
+var ErrInvalidCursor = errors.New("snowflake: invalid cursor")
+
+// EncodeCursor return opaque pagination cursor embedding id and optional extra fields,
+// cursors sort by the embedded id
+func EncodeCursor(id int64, extra ...[]byte) string {
+	return SortableEncoding.EncodeToString(appendCursor(nil, id, extra))
+}
+
+// DecodeCursor parse cursor produced by EncodeCursor
+func DecodeCursor(s string) (int64, [][]byte, error) {
+	b, err := SortableEncoding.DecodeString(s)
+	if err != nil {
+		return 0, nil, ErrInvalidCursor
+	}
+	return parseCursor(b)
+}
+
+// EncodeSignedCursor same as EncodeCursor, with a HMAC-SHA256 tag so clients can't forge cursors
+func EncodeSignedCursor(key []byte, id int64, extra ...[]byte) string {
+	b := appendCursor(nil, id, extra)
+	b = append(b, mac(key, "cursor", b)[:cursorMACLen]...)
+	return SortableEncoding.EncodeToString(b)
+}
+
+// DecodeSignedCursor verify and parse cursor produced by EncodeSignedCursor
+func DecodeSignedCursor(key []byte, s string) (int64, [][]byte, error) {
+	b, err := SortableEncoding.DecodeString(s)
+	if err != nil || len(b) < 8+cursorMACLen {
+		return 0, nil, ErrInvalidCursor
+	}
+	payload, tag := b[:len(b)-cursorMACLen], b[len(b)-cursorMACLen:]
+	if !hmac.Equal(tag, mac(key, "cursor", payload)[:cursorMACLen]) {
+		return 0, nil, ErrInvalidCursor
+	}
+	return parseCursor(payload)
+}
+
+// appendCursor 游标格式: 8 字节大端 id + 每个附加字段(uvarint 长度 + 内容)
+func appendCursor(b []byte, id int64, extra [][]byte) []byte {
+	b = binary.BigEndian.AppendUint64(b, uint64(id))
+	for _, e := range extra {
+		b = binary.AppendUvarint(b, uint64(len(e)))
+		b = append(b, e...)
+	}
+	return b
+}
+
+func parseCursor(b []byte) (int64, [][]byte, error) {
+	if len(b) < 8 {
+		return 0, nil, ErrInvalidCursor
+	}
+	id := int64(binary.BigEndian.Uint64(b))
+	b = b[8:]
+	var extra [][]byte
+	for len(b) > 0 {
+		n, size := binary.Uvarint(b)
+		if size <= 0 || n > uint64(len(b)-size) {
+			return 0, nil, ErrInvalidCursor
+		}
+		b = b[size:]
+		extra = append(extra, b[:n:n])
+		b = b[n:]
+	}
+	return id, extra, nil
+}
@@ -0,0 +1,53 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_Cursor(t *testing.T) {
+	id, err := Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra := [][]byte{[]byte("created_at"), {}, []byte("desc")}
+
+	s := EncodeCursor(id, extra...)
+	got, gotExtra, err := DecodeCursor(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != id || len(gotExtra) != len(extra) {
+		t.Fatalf("DecodeCursor(%q) = %d, %q", s, got, gotExtra)
+	}
+	for i := range extra {
+		if !bytes.Equal(gotExtra[i], extra[i]) {
+			t.Errorf("extra[%d] = %q, want %q", i, gotExtra[i], extra[i])
+		}
+	}
+
+	if _, _, err := DecodeCursor(s[:5]); err != ErrInvalidCursor {
+		t.Errorf("truncated cursor: got %v", err)
+	}
+	if EncodeCursor(1) >= EncodeCursor(2) {
+		t.Error("cursors should sort by id")
+	}
+}
+
+func Test_SignedCursor(t *testing.T) {
+	key := []byte("secret")
+	s := EncodeSignedCursor(key, 42, []byte("page"))
+	id, extra, err := DecodeSignedCursor(key, s)
+	if err != nil || id != 42 || len(extra) != 1 || string(extra[0]) != "page" {
+		t.Fatalf("DecodeSignedCursor = %d, %q, %v", id, extra, err)
+	}
+	if _, _, err := DecodeSignedCursor([]byte("other"), s); err != ErrInvalidCursor {
+		t.Errorf("wrong key: got %v", err)
+	}
+	forged := EncodeSignedCursor([]byte("other"), 43, []byte("page"))
+	if _, _, err := DecodeSignedCursor(key, forged); err != ErrInvalidCursor {
+		t.Errorf("forged cursor: got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// defaultWorker 首次使用时按环境变量创建，配置非法时 panic，避免所有实例静默使用 0/0
+var defaultWorker = sync.OnceValue(func() *worker {
+	w, err := workerFromEnv(os.Getenv)
+	if err != nil {
+		panic(fmt.Sprintf("snowflake: invalid default worker configuration: %v", err))
+	}
+	return w
+})
+
+func workerFromEnv(getenv func(string) string) (*worker, error) {
+	workerID, err := envUint8(getenv, EnvWorkerID)
+	if err != nil {
+		return nil, err
+	}
+	datacenterID, err := envUint8(getenv, EnvDatacenterID)
+	if err != nil {
+		return nil, err
+	}
+	layout, err := envEpochLayout(getenv)
+	if err != nil {
+		return nil, err
+	}
+	opts := []Option{WithLayout(layout)}
+	if s := getenv(EnvMachineID); s != "" {
+		id, err := strconv.ParseUint(s, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("%s=%q: %v", EnvMachineID, s, err)
+		}
+		opts = append(opts, WithMachineID(uint16(id)))
+	}
+	return newWorker(workerID, datacenterID, opts...)
+}
+
+// defaultLayout DefaultWorker 使用的布局，解码函数据此解析 id
+func defaultLayout() Layout {
+	return defaultWorker().layout
+}
+
+// DefaultWorker worker behind the package level functions, created on first use from
+// SNOWFLAKE_WORKER_ID, SNOWFLAKE_DATACENTER_ID (default 0) and SNOWFLAKE_EPOCH
+// (default DefaultLayout.Epoch); invalid values panic on first use. It lives for the whole
+// process: Close is a no-op, so no caller can break the package level functions
+var DefaultWorker Generator = lazyWorker{}
+
+// lazyWorker 将调用转发给延迟创建的默认 worker
+type lazyWorker struct{}
+
+func (lazyWorker) Next() (int64, error) {
+	return defaultWorker().Next()
+}
+
+func (lazyWorker) NextN(n int) ([]int64, error) {
+	return defaultWorker().NextN(n)
+}
+
+func (lazyWorker) NextNContext(ctx context.Context, n int) ([]int64, error) {
+	return defaultWorker().NextNContext(ctx, n)
+}
+
+func (lazyWorker) TryNext() (int64, bool) {
+	return defaultWorker().TryNext()
+}
+
+func (lazyWorker) Fill(dst []int64) error {
+	return defaultWorker().Fill(dst)
+}
+
+func (lazyWorker) NextContext(ctx context.Context) (int64, error) {
+	return defaultWorker().NextContext(ctx)
+}
+
+// Close 不关闭进程共享的默认 worker，否则包级函数此后全部返回 ErrClosed
+func (lazyWorker) Close() error {
+	return nil
+}
+
+func (lazyWorker) Stats() Stats {
+	return defaultWorker().Stats()
+}
+
+func (lazyWorker) Saturation() float64 {
+	return defaultWorker().Saturation()
+}
+
+// Next return DefaultWorker new id
+func Next() (int64, error) {
+	return defaultWorker().Next()
+}
@@ -0,0 +1,23 @@
+//go:build snowflake_decode
+
+package snowflake
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// defaultLayout 解码专用构建没有默认 worker，按相同的环境变量得到布局，配置非法时 panic
+var defaultLayout = sync.OnceValue(func() Layout {
+	layout, err := envEpochLayout(os.Getenv)
+	if err != nil {
+		panic(fmt.Sprintf("snowflake: invalid default layout configuration: %v", err))
+	}
+	if os.Getenv(EnvMachineID) != "" {
+		// 与 WithMachineID 相同，机器 id 同时占用机房位和机器位
+		layout.WorkerBits += layout.DatacenterBits
+		layout.DatacenterBits = 0
+	}
+	return layout
+})
@@ -0,0 +1,38 @@
+//go:build snowflake_decode
+
+package snowflake
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_defaultLayoutDecode(t *testing.T) {
+	if l := defaultLayout(); l != DefaultLayout {
+		t.Fatalf("defaultLayout() = %+v, want DefaultLayout", l)
+	}
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	id := ID(DefaultLayout.compose(at.UnixMilli()-DefaultLayout.Epoch.UnixMilli(), 3, 7, 42))
+	if !IsValid(int64(id)) {
+		t.Fatalf("IsValid(%d) = false", id)
+	}
+	p := Decompose(id)
+	if !p.Time.Equal(at) || p.DatacenterID != 3 || p.WorkerID != 7 || p.Sequence != 42 {
+		t.Fatalf("Decompose(%d) = %+v", id, p)
+	}
+}
+
+func Test_envEpochLayout(t *testing.T) {
+	l, err := envEpochLayout(func(k string) string {
+		if k == EnvEpoch {
+			return "1700000000000"
+		}
+		return ""
+	})
+	if err != nil || l.Epoch.UnixMilli() != 1700000000000 {
+		t.Fatalf("envEpochLayout = %+v, %v", l, err)
+	}
+	if _, err := envEpochLayout(func(string) string { return "soon" }); err == nil {
+		t.Fatal("invalid epoch accepted")
+	}
+}
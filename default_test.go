@@ -0,0 +1,54 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_workerFromEnv(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	w, err := workerFromEnv(env(nil))
+	if err != nil || w.workerID != 0 || w.datacenterID != 0 || !w.layout.Epoch.Equal(DefaultLayout.Epoch) {
+		t.Fatalf("defaults = %+v, %v", w, err)
+	}
+
+	w, err = workerFromEnv(env(map[string]string{EnvWorkerID: "7", EnvDatacenterID: "3", EnvEpoch: "1700000000000"}))
+	if err != nil || w.workerID != 7 || w.datacenterID != 3 || w.epoch != 1700000000000 {
+		t.Fatalf("from env = %+v, %v", w, err)
+	}
+	w, err = workerFromEnv(env(map[string]string{EnvEpoch: "2024-01-01T00:00:00Z"}))
+	if err != nil || !w.layout.Epoch.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("RFC 3339 epoch = %+v, %v", w, err)
+	}
+
+	for _, vars := range []map[string]string{
+		{EnvWorkerID: "32"},
+		{EnvWorkerID: "-1"},
+		{EnvDatacenterID: "x"},
+		{EnvEpoch: "yesterday"},
+	} {
+		if _, err := workerFromEnv(env(vars)); err == nil {
+			t.Errorf("workerFromEnv(%v) expected error", vars)
+		}
+	}
+}
+
+func Test_DefaultWorker(t *testing.T) {
+	id, err := DefaultWorker.Next()
+	if err != nil || !IsValid(id) {
+		t.Fatalf("DefaultWorker.Next() = %d, %v", id, err)
+	}
+	if DefaultWorker.Stats().Issued == 0 {
+		t.Error("DefaultWorker stats should count issued ids")
+	}
+	if err := DefaultWorker.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := Next(); err != nil || !IsValid(id) {
+		t.Errorf("Next() after DefaultWorker.Close = %d, %v", id, err)
+	}
+}
@@ -0,0 +1,334 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotLeader returned by Election.CheckLease once another process leads
+var ErrNotLeader = errors.New("snowflake: not the elected leader")
+
+// Election leader election among replicas sharing one worker id, so a single one issues ids.
+// As a LeaseChecker it keeps the leadership alive and fails once it is lost, for WithLeaseCheck
+type Election interface {
+	LeaseChecker
+	// Campaign block until this process is elected or ctx is done
+	Campaign(ctx context.Context) error
+	// Resign give up the leadership, letting a standby take over without waiting for expiry
+	Resign(ctx context.Context) error
+}
+
+// Elect campaign until e elects this process, then wait fencing before returning, to cover
+// clock rate differences between the previous leader and the election backend. Create the
+// generator afterwards with WithLeaseCheck(e, interval, ttl), ttl shorter than the election
+// TTL: a leader whose checks fail stops issuing before its key expires and a standby wins.
+//
+//	if err := snowflake.Elect(ctx, e, time.Second); err != nil { ... }
+//	g, err := snowflake.NewGenerator(workerID, datacenterID, snowflake.WithLeaseCheck(e, time.Second, 5*time.Second))
+func Elect(ctx context.Context, e Election, fencing time.Duration) error {
+	if err := e.Campaign(ctx); err != nil {
+		return err
+	}
+	t := time.NewTimer(fencing)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+		e.Resign(context.Background())
+		return ctx.Err()
+	}
+	return e.CheckLease(ctx)
+}
+
+// campaignPoll Campaign 重试的间隔，默认为 TTL 的三分之一
+func campaignPoll(poll, ttl time.Duration) time.Duration {
+	if poll > 0 {
+		return poll
+	}
+	return max(ttl/3, 10*time.Millisecond)
+}
+
+// sleepContext 等待 d 或 ctx 结束
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// EtcdElection Election on an etcd key bound to a lease, through the etcd v3 JSON gateway:
+// the first replica to create the key leads, CheckLease renews the lease, and the key
+// disappears with the lease when the leader stops renewing it
+type EtcdElection struct {
+	Endpoint string        // e.g. http://127.0.0.1:2379
+	Key      string        // election key, e.g. /snowflake/leader/1/3
+	Value    string        // identity of this replica stored under the key, e.g. the hostname
+	TTL      time.Duration // lease ttl, at least 1s
+	Poll     time.Duration // Campaign retry interval, default TTL/3
+	Client   *http.Client  // nil means http.DefaultClient
+
+	mu    sync.Mutex
+	lease int64 // 当选时的租约，0 表示未当选
+}
+
+// Campaign create the key under a new lease once it does not exist; the lease is revoked
+// when Campaign fails
+func (e *EtcdElection) Campaign(ctx context.Context) (err error) {
+	var grant struct {
+		ID int64 `json:"ID,string"`
+	}
+	if err := e.call(ctx, "/v3/lease/grant", map[string]any{"TTL": max(int64(e.TTL/time.Second), 1)}, &grant); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			// ctx 可能已结束，撤销用独立的 context，最多等到租约自然过期
+			revoke, cancel := context.WithTimeout(context.Background(), e.TTL)
+			defer cancel()
+			e.call(revoke, "/v3/lease/revoke", map[string]string{"ID": fmt.Sprint(grant.ID)}, nil)
+		}
+	}()
+	key := base64.StdEncoding.EncodeToString([]byte(e.Key))
+	txn := map[string]any{
+		"compare": []map[string]any{{"key": key, "target": "CREATE", "create_revision": "0"}},
+		"success": []map[string]any{{"request_put": map[string]any{
+			"key": key, "value": base64.StdEncoding.EncodeToString([]byte(e.Value)), "lease": fmt.Sprint(grant.ID),
+		}}},
+	}
+	for {
+		// 等待期间续租，当选时租约仍然有效
+		if err := e.keepAlive(ctx, grant.ID); err != nil {
+			return err
+		}
+		var resp struct {
+			Succeeded bool `json:"succeeded"`
+		}
+		if err := e.call(ctx, "/v3/kv/txn", txn, &resp); err != nil {
+			return err
+		}
+		if resp.Succeeded {
+			e.mu.Lock()
+			e.lease = grant.ID
+			e.mu.Unlock()
+			return nil
+		}
+		if err := sleepContext(ctx, campaignPoll(e.Poll, e.TTL)); err != nil {
+			return err
+		}
+	}
+}
+
+// CheckLease renew the lease and confirm the key is still held under it
+func (e *EtcdElection) CheckLease(ctx context.Context) error {
+	e.mu.Lock()
+	lease := e.lease
+	e.mu.Unlock()
+	if lease == 0 {
+		return ErrNotLeader
+	}
+	if err := e.keepAlive(ctx, lease); err != nil {
+		return err
+	}
+	var resp struct {
+		Kvs []struct {
+			Lease int64 `json:"lease,string"`
+		} `json:"kvs"`
+	}
+	if err := e.call(ctx, "/v3/kv/range", map[string]string{"key": base64.StdEncoding.EncodeToString([]byte(e.Key))}, &resp); err != nil {
+		return err
+	}
+	if len(resp.Kvs) == 0 || resp.Kvs[0].Lease != lease {
+		return ErrNotLeader
+	}
+	return nil
+}
+
+// Resign revoke the lease, deleting the key
+func (e *EtcdElection) Resign(ctx context.Context) error {
+	e.mu.Lock()
+	lease := e.lease
+	e.lease = 0
+	e.mu.Unlock()
+	if lease == 0 {
+		return nil
+	}
+	return e.call(ctx, "/v3/lease/revoke", map[string]string{"ID": fmt.Sprint(lease)}, nil)
+}
+
+// keepAlive 续租一次，租约已过期时返回 ErrNotLeader
+func (e *EtcdElection) keepAlive(ctx context.Context, lease int64) error {
+	var resp struct {
+		Result struct {
+			TTL int64 `json:"TTL,string"`
+		} `json:"result"`
+	}
+	if err := e.call(ctx, "/v3/lease/keepalive", map[string]string{"ID": fmt.Sprint(lease)}, &resp); err != nil {
+		return err
+	}
+	if resp.Result.TTL <= 0 {
+		return fmt.Errorf("%w: lease %d expired", ErrNotLeader, lease)
+	}
+	return nil
+}
+
+func (e *EtcdElection) call(ctx context.Context, path string, req, resp any) error {
+	return etcdCall(ctx, e.Client, e.Endpoint, path, req, resp)
+}
+
+// ConsulElection Election on a Consul KV lock held by a session: the first replica to acquire
+// the key leads, CheckLease renews the session, and Consul releases the key when the session
+// expires. Consul then refuses to hand the lock to anyone else for the session's LockDelay,
+// an extra fencing period on top of Elect's
+type ConsulElection struct {
+	Address   string        // e.g. http://127.0.0.1:8500
+	Key       string        // KV key, e.g. snowflake/leader/1/3
+	Value     string        // identity of this replica stored under the key
+	TTL       time.Duration // session ttl, between 10s and 24h
+	LockDelay time.Duration // 0 means Consul's default of 15s
+	Poll      time.Duration // Campaign retry interval, default TTL/3
+	Token     string        // ACL token, sent as X-Consul-Token
+	Client    *http.Client  // nil means http.DefaultClient
+
+	mu      sync.Mutex
+	session string // 当选时的会话，空表示未当选
+}
+
+// Campaign create a session and acquire the key with it once no other session holds it;
+// the session is destroyed when Campaign fails
+func (e *ConsulElection) Campaign(ctx context.Context) (err error) {
+	create := map[string]string{"Name": e.Key, "TTL": e.TTL.String(), "Behavior": "delete"}
+	if e.LockDelay > 0 {
+		create["LockDelay"] = e.LockDelay.String()
+	}
+	var session struct{ ID string }
+	if err := e.call(ctx, http.MethodPut, "/v1/session/create", create, &session); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			destroy, cancel := context.WithTimeout(context.Background(), e.TTL)
+			defer cancel()
+			e.call(destroy, http.MethodPut, "/v1/session/destroy/"+session.ID, nil, nil)
+		}
+	}()
+	for {
+		if err := e.call(ctx, http.MethodPut, "/v1/session/renew/"+session.ID, nil, nil); err != nil {
+			return err
+		}
+		var acquired bool
+		if err := e.call(ctx, http.MethodPut, e.kvPath()+"?acquire="+url.QueryEscape(session.ID), e.Value, &acquired); err != nil {
+			return err
+		}
+		if acquired {
+			e.mu.Lock()
+			e.session = session.ID
+			e.mu.Unlock()
+			return nil
+		}
+		if err := sleepContext(ctx, campaignPoll(e.Poll, e.TTL)); err != nil {
+			return err
+		}
+	}
+}
+
+// CheckLease renew the session and confirm it still holds the key
+func (e *ConsulElection) CheckLease(ctx context.Context) error {
+	e.mu.Lock()
+	session := e.session
+	e.mu.Unlock()
+	if session == "" {
+		return ErrNotLeader
+	}
+	if err := e.call(ctx, http.MethodPut, "/v1/session/renew/"+session, nil, nil); err != nil {
+		return err
+	}
+	var kvs []struct{ Session string }
+	if err := e.call(ctx, http.MethodGet, e.kvPath(), nil, &kvs); err != nil {
+		return err
+	}
+	if len(kvs) == 0 || kvs[0].Session != session {
+		return ErrNotLeader
+	}
+	return nil
+}
+
+// Resign release the key and destroy the session
+func (e *ConsulElection) Resign(ctx context.Context) error {
+	e.mu.Lock()
+	session := e.session
+	e.session = ""
+	e.mu.Unlock()
+	if session == "" {
+		return nil
+	}
+	if err := e.call(ctx, http.MethodPut, e.kvPath()+"?release="+url.QueryEscape(session), e.Value, nil); err != nil {
+		return err
+	}
+	return e.call(ctx, http.MethodPut, "/v1/session/destroy/"+session, nil, nil)
+}
+
+func (e *ConsulElection) kvPath() string {
+	return "/v1/kv/" + strings.TrimPrefix(e.Key, "/")
+}
+
+// call 发送 Consul HTTP API 请求，body 为 string 时原样发送，其他值编码为 JSON；
+// 会话或键不存在(404)时返回 ErrNotLeader
+func (e *ConsulElection) call(ctx context.Context, method, path string, body, resp any) error {
+	var payload io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		payload = strings.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+	r, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(e.Address, "/")+path, payload)
+	if err != nil {
+		return err
+	}
+	if e.Token != "" {
+		r.Header.Set("X-Consul-Token", e.Token)
+	}
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: consul: %s %s not found", ErrNotLeader, method, path)
+	case res.StatusCode != http.StatusOK:
+		return fmt.Errorf("consul: %s: %s", res.Status, bytes.TrimSpace(data))
+	case resp == nil:
+		return nil
+	}
+	return json.Unmarshal(data, resp)
+}
@@ -0,0 +1,234 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeEtcd etcd v3 JSON 网关中选举用到的租约和事务接口
+type fakeEtcd struct {
+	mu     sync.Mutex
+	leases map[string]bool
+	next   int
+	key    string // 选举键当前的租约，空表示不存在
+}
+
+// expire 使持有选举键的租约立即过期
+func (f *fakeEtcd) expire() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.leases, f.key)
+	f.key = ""
+}
+
+func (f *fakeEtcd) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID      json.RawMessage
+		Success []struct {
+			RequestPut struct{ Lease string } `json:"request_put"`
+		}
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	id, _ := strconv.Unquote(string(req.ID))
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.URL.Path {
+	case "/v3/lease/grant":
+		f.next++
+		if f.leases == nil {
+			f.leases = map[string]bool{}
+		}
+		f.leases[strconv.Itoa(f.next)] = true
+		rw.Write([]byte(`{"ID":"` + strconv.Itoa(f.next) + `","TTL":"1"}`))
+	case "/v3/lease/keepalive":
+		if f.leases[id] {
+			rw.Write([]byte(`{"result":{"ID":"` + id + `","TTL":"1"}}` + "\n"))
+		} else {
+			rw.Write([]byte(`{"result":{"ID":"` + id + `"}}` + "\n"))
+		}
+	case "/v3/lease/revoke":
+		delete(f.leases, id)
+		if f.key == id {
+			f.key = ""
+		}
+		rw.Write([]byte(`{}`))
+	case "/v3/kv/txn":
+		if f.key != "" {
+			rw.Write([]byte(`{"succeeded":false}`))
+			return
+		}
+		f.key = req.Success[0].RequestPut.Lease
+		rw.Write([]byte(`{"succeeded":true}`))
+	case "/v3/kv/range":
+		if f.key == "" {
+			rw.Write([]byte(`{}`))
+			return
+		}
+		rw.Write([]byte(`{"kvs":[{"lease":"` + f.key + `"}]}`))
+	default:
+		http.NotFound(rw, r)
+	}
+}
+
+func Test_EtcdElection(t *testing.T) {
+	etcd := &fakeEtcd{}
+	srv := httptest.NewServer(etcd)
+	defer srv.Close()
+	ctx := context.Background()
+	leader := &EtcdElection{Endpoint: srv.URL, Key: "/snowflake/leader/1/3", Value: "a", TTL: time.Second, Poll: 5 * time.Millisecond}
+	standby := &EtcdElection{Endpoint: srv.URL, Key: "/snowflake/leader/1/3", Value: "b", TTL: time.Second, Poll: 5 * time.Millisecond}
+	if err := standby.CheckLease(ctx); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("CheckLease before Campaign = %v", err)
+	}
+	if err := Elect(ctx, leader, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	short, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	// 截止时间可能落在 HTTP 调用中，错误被包装
+	if err := standby.Campaign(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("standby Campaign while leader holds the key = %v", err)
+	}
+	etcd.mu.Lock()
+	leases := len(etcd.leases)
+	etcd.mu.Unlock()
+	if leases != 1 {
+		t.Errorf("%d leases after failed Campaign, want the standby's revoked", leases)
+	}
+	if err := leader.CheckLease(ctx); err != nil {
+		t.Errorf("leader CheckLease = %v", err)
+	}
+
+	// 领导者停止续租，租约过期后备用副本接管
+	done := make(chan error, 1)
+	go func() { done <- Elect(ctx, standby, 10*time.Millisecond) }()
+	etcd.expire()
+	if err := <-done; err != nil {
+		t.Fatalf("standby Elect = %v", err)
+	}
+	if err := leader.CheckLease(ctx); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("CheckLease after expiry = %v", err)
+	}
+	err := standby.Resign(ctx)
+	etcd.mu.Lock()
+	key := etcd.key
+	etcd.mu.Unlock()
+	if err != nil || key != "" {
+		t.Errorf("Resign = %v, key lease %q", err, key)
+	}
+}
+
+// fakeConsul Consul 会话与 KV 锁接口
+type fakeConsul struct {
+	mu       sync.Mutex
+	sessions map[string]bool
+	next     int
+	holder   string
+}
+
+func (f *fakeConsul) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.sessions == nil {
+		f.sessions = map[string]bool{}
+	}
+	body, _ := io.ReadAll(r.Body)
+	path := r.URL.Path
+	switch {
+	case path == "/v1/session/create":
+		var req struct{ TTL, Behavior string }
+		json.Unmarshal(body, &req)
+		if req.TTL != "10s" || req.Behavior != "delete" {
+			http.Error(rw, "bad session "+string(body), http.StatusBadRequest)
+			return
+		}
+		f.next++
+		id := "s" + strconv.Itoa(f.next)
+		f.sessions[id] = true
+		rw.Write([]byte(`{"ID":"` + id + `"}`))
+	case strings.HasPrefix(path, "/v1/session/renew/"):
+		if !f.sessions[strings.TrimPrefix(path, "/v1/session/renew/")] {
+			http.NotFound(rw, r)
+			return
+		}
+		rw.Write([]byte(`[{}]`))
+	case strings.HasPrefix(path, "/v1/session/destroy/"):
+		id := strings.TrimPrefix(path, "/v1/session/destroy/")
+		delete(f.sessions, id)
+		if f.holder == id {
+			f.holder = ""
+		}
+		rw.Write([]byte(`true`))
+	case path == "/v1/kv/snowflake/leader/1/3":
+		q := r.URL.Query()
+		switch {
+		case q.Has("acquire"):
+			ok := f.holder == "" && f.sessions[q.Get("acquire")]
+			if ok {
+				f.holder = q.Get("acquire")
+			}
+			json.NewEncoder(rw).Encode(ok)
+		case q.Has("release"):
+			if f.holder == q.Get("release") {
+				f.holder = ""
+			}
+			rw.Write([]byte(`true`))
+		default:
+			json.NewEncoder(rw).Encode([]map[string]string{{"Session": f.holder}})
+		}
+	default:
+		http.NotFound(rw, r)
+	}
+}
+
+func Test_ConsulElection(t *testing.T) {
+	consul := &fakeConsul{}
+	srv := httptest.NewServer(consul)
+	defer srv.Close()
+	ctx := context.Background()
+	leader := &ConsulElection{Address: srv.URL, Key: "snowflake/leader/1/3", Value: "a", TTL: 10 * time.Second, Poll: 5 * time.Millisecond}
+	standby := &ConsulElection{Address: srv.URL, Key: "/snowflake/leader/1/3", Value: "b", TTL: 10 * time.Second, Poll: 5 * time.Millisecond}
+	if err := Elect(ctx, leader, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	// 作为租约校验限制 worker 只在当选期间签发
+	w, err := NewGenerator(3, 1, WithLeaseCheck(leader, time.Hour, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	if _, err := NewGenerator(3, 1, WithLeaseCheck(standby, time.Hour, time.Hour)); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("standby generator = %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- Elect(ctx, standby, time.Millisecond) }()
+	if err := leader.Resign(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("standby Elect = %v", err)
+	}
+	if err := leader.CheckLease(ctx); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("CheckLease after Resign = %v", err)
+	}
+	// 会话过期后不再是领导者
+	consul.mu.Lock()
+	delete(consul.sessions, consul.holder)
+	consul.mu.Unlock()
+	if err := standby.CheckLease(ctx); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("CheckLease after session expiry = %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package snowflake
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// Encoder encode ids as short codes over a custom alphabet
+type Encoder struct {
+	alphabet []byte
+	index    [256]int16
+}
+
+// NewEncoder return encoder over alphabet (at least 2 distinct ASCII characters);
+// a non-empty shuffleKey permutes the alphabet deterministically so each product
+// gets its own codes, the same key always yields the same encoder
+func NewEncoder(alphabet string, shuffleKey []byte) (*Encoder, error) {
+	if len(alphabet) < 2 {
+		return nil, fmt.Errorf("alphabet must have at least 2 characters")
+	}
+	e := &Encoder{alphabet: []byte(alphabet)}
+	for i := range e.index {
+		e.index[i] = -1
+	}
+	for i, c := range e.alphabet {
+		if c >= 0x80 {
+			return nil, fmt.Errorf("alphabet must be ASCII")
+		}
+		if e.index[c] >= 0 {
+			return nil, fmt.Errorf("alphabet has duplicate character %q", c)
+		}
+		e.index[c] = int16(i)
+	}
+	if len(shuffleKey) > 0 {
+		e.shuffle(shuffleKey)
+	}
+	return e, nil
+}
+
+// shuffle 用 HMAC-SHA256 计数器模式生成的随机流做 Fisher-Yates 洗牌
+func (e *Encoder) shuffle(key []byte) {
+	m := hmac.New(sha256.New, key)
+	var block []byte
+	var counter uint64
+	for i := len(e.alphabet) - 1; i > 0; i-- {
+		if len(block) < 8 {
+			m.Reset()
+			m.Write(binary.BigEndian.AppendUint64(nil, counter))
+			block = m.Sum(nil)
+			counter++
+		}
+		j := int(binary.BigEndian.Uint64(block) % uint64(i+1))
+		block = block[8:]
+		e.alphabet[i], e.alphabet[j] = e.alphabet[j], e.alphabet[i]
+	}
+	for i, c := range e.alphabet {
+		e.index[c] = int16(i)
+	}
+}
+
+// Alphabet return the (possibly shuffled) alphabet
+func (e *Encoder) Alphabet() string {
+	return string(e.alphabet)
+}
+
+// Encode return code of id, ids are treated as unsigned
+func (e *Encoder) Encode(id ID) string {
+	var b [64]byte
+	return string(e.Append(b[:0], id))
+}
+
+// Append append code of id to dst without allocating beyond dst growth
+func (e *Encoder) Append(dst []byte, id ID) []byte {
+	var b [64]byte
+	i := len(b)
+	base := uint64(len(e.alphabet))
+	v := uint64(id)
+	for {
+		i--
+		b[i] = e.alphabet[v%base]
+		v /= base
+		if v == 0 {
+			break
+		}
+	}
+	return append(dst, b[i:]...)
+}
+
+// Decode parse code produced by Encode
+func (e *Encoder) Decode(s string) (ID, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty code")
+	}
+	base := uint64(len(e.alphabet))
+	var v uint64
+	for i := 0; i < len(s); i++ {
+		n := e.index[s[i]]
+		if n < 0 {
+			return 0, fmt.Errorf("invalid code %q: unexpected %q", s, s[i])
+		}
+		hi, lo := bits.Mul64(v, base)
+		if hi != 0 || lo+uint64(n) < lo {
+			return 0, fmt.Errorf("invalid code %q: overflows 64 bits", s)
+		}
+		v = lo + uint64(n)
+	}
+	return ID(v), nil
+}
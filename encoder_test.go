@@ -0,0 +1,47 @@
+package snowflake
+
+import (
+	"testing"
+	"testing/quick"
+)
+
+func Test_Encoder(t *testing.T) {
+	const alphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+	plain, err := NewEncoder(alphabet, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := plain.Encode(0); got != "2" {
+		t.Errorf("Encode(0) = %q", got)
+	}
+	branded, err := NewEncoder(alphabet, []byte("brand"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := NewEncoder(alphabet, []byte("brand"))
+	if branded.Alphabet() == alphabet || branded.Alphabet() != again.Alphabet() {
+		t.Errorf("shuffled alphabet %q should differ from %q and be deterministic", branded.Alphabet(), alphabet)
+	}
+
+	for _, e := range []*Encoder{plain, branded} {
+		roundTrip := func(v uint64) bool {
+			got, err := e.Decode(e.Encode(ID(v)))
+			return err == nil && got == ID(v)
+		}
+		if err := quick.Check(roundTrip, nil); err != nil {
+			t.Error(err)
+		}
+		if _, err := e.Decode("zzzzzzzzzzzzzzzz"); err == nil {
+			t.Error("expected overflow error")
+		}
+		if _, err := e.Decode("1"); err == nil {
+			t.Error("expected invalid character error")
+		}
+	}
+
+	for _, a := range []string{"", "a", "aba", "ab\xff"} {
+		if _, err := NewEncoder(a, nil); err == nil {
+			t.Errorf("NewEncoder(%q) expected error", a)
+		}
+	}
+}
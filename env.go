@@ -0,0 +1,42 @@
+package snowflake
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 默认 worker 的环境变量
+const (
+	EnvWorkerID     = "SNOWFLAKE_WORKER_ID"
+	EnvDatacenterID = "SNOWFLAKE_DATACENTER_ID"
+	EnvEpoch        = "SNOWFLAKE_EPOCH"      // unix 毫秒时间戳或 RFC 3339 时间
+	EnvMachineID    = "SNOWFLAKE_MACHINE_ID" // 设置后使用 WithMachineID，不能与上面两个 id 同时设置
+)
+
+// envEpochLayout DefaultLayout，起始时间取自 SNOWFLAKE_EPOCH
+func envEpochLayout(getenv func(string) string) (Layout, error) {
+	layout := DefaultLayout
+	if s := getenv(EnvEpoch); s != "" {
+		if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+			layout.Epoch = time.UnixMilli(ms)
+		} else if t, err := time.Parse(time.RFC3339, s); err == nil {
+			layout.Epoch = t
+		} else {
+			return layout, fmt.Errorf("%s=%q is neither unix milliseconds nor RFC 3339", EnvEpoch, s)
+		}
+	}
+	return layout, nil
+}
+
+func envUint8(getenv func(string) string, key string) (uint8, error) {
+	s := getenv(key)
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("%s=%q: %v", key, s, err)
+	}
+	return uint8(v), nil
+}
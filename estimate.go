@@ -0,0 +1,70 @@
+package snowflake
+
+import (
+	"fmt"
+	"time"
+)
+
+// EstimateElapsed return the time between the timestamps of a and b (negative if b is
+// older) under the layout, exact to the millisecond
+func (l Layout) EstimateElapsed(a, b ID) time.Duration {
+	ea, _, _, _ := l.decompose(uint64(a))
+	eb, _, _, _ := l.decompose(uint64(b))
+	return time.Duration(eb-ea) * time.Millisecond
+}
+
+// EstimateElapsed return the time between a and b under DefaultWorker's layout
+func EstimateElapsed(a, b ID) time.Duration {
+	return defaultLayout().EstimateElapsed(a, b)
+}
+
+// Issued bounds of the ids a worker issued after one id up to and including another
+type Issued struct {
+	Elapsed time.Duration
+	Min     uint64 // ids known to exist: the sequence restarts at 0 every millisecond
+	Max     uint64 // every sequence number in between used
+}
+
+// MinRate Min per second over Elapsed, 0 when both ids share a millisecond
+func (i Issued) MinRate() float64 {
+	if i.Elapsed <= 0 {
+		return 0
+	}
+	return float64(i.Min) / i.Elapsed.Seconds()
+}
+
+// MaxRate Max per second over Elapsed, 0 when both ids share a millisecond
+func (i Issued) MaxRate() float64 {
+	if i.Elapsed <= 0 {
+		return 0
+	}
+	return float64(i.Max) / i.Elapsed.Seconds()
+}
+
+// EstimateIssued bound how many ids the worker of a and b issued after the older one up to
+// and including the newer one; both must come from the same worker and datacenter.
+// Workers rerolling random machine bits (WithRandomMachine) are not covered.
+func (l Layout) EstimateIssued(a, b ID) (Issued, error) {
+	if b < a {
+		a, b = b, a
+	}
+	ea, da, wa, sa := l.decompose(uint64(a))
+	eb, db, wb, sb := l.decompose(uint64(b))
+	if da != db || wa != wb {
+		return Issued{}, fmt.Errorf("ids %d and %d come from different workers", a, b)
+	}
+	est := Issued{Elapsed: time.Duration(eb-ea) * time.Millisecond}
+	if ea == eb {
+		est.Min, est.Max = uint64(sb-sa), uint64(sb-sa)
+		return est, nil
+	}
+	perMilli := uint64(l.sequenceMask()) + 1
+	est.Min = uint64(sb) + 1
+	est.Max = uint64(l.sequenceMask()-sa) + uint64(eb-ea-1)*perMilli + uint64(sb) + 1
+	return est, nil
+}
+
+// EstimateIssued bound the ids issued between a and b under DefaultWorker's layout
+func EstimateIssued(a, b ID) (Issued, error) {
+	return defaultLayout().EstimateIssued(a, b)
+}
@@ -0,0 +1,54 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_EstimateIssued(t *testing.T) {
+	l := DefaultLayout
+	id := func(elapsed, sequence int64) ID { return ID(l.compose(elapsed, 1, 2, sequence)) }
+
+	if d := l.EstimateElapsed(id(1000, 5), id(1250, 0)); d != 250*time.Millisecond {
+		t.Errorf("EstimateElapsed = %v", d)
+	}
+	if d := l.EstimateElapsed(id(1250, 0), id(1000, 5)); d != -250*time.Millisecond {
+		t.Errorf("EstimateElapsed reversed = %v", d)
+	}
+
+	est, err := l.EstimateIssued(id(1000, 5), id(1000, 9))
+	if err != nil || est.Min != 4 || est.Max != 4 || est.MinRate() != 0 {
+		t.Errorf("same millisecond = %+v, %v", est, err)
+	}
+	est, err = l.EstimateIssued(id(1002, 3), id(1000, 4090))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 4090 之后本毫秒最多 5 个，中间 1 毫秒最多 4096 个，最后一毫秒 0..3 共 4 个
+	if est.Elapsed != 2*time.Millisecond || est.Min != 4 || est.Max != 5+4096+4 {
+		t.Errorf("across milliseconds = %+v", est)
+	}
+	if est.MinRate() != 2000 || est.MaxRate() != float64(est.Max)*500 {
+		t.Errorf("rates = %v, %v", est.MinRate(), est.MaxRate())
+	}
+
+	if _, err := l.EstimateIssued(id(1000, 0), ID(l.compose(1000, 1, 3, 1))); err == nil {
+		t.Error("expected error for different workers")
+	}
+
+	// 实际发号落在估计范围内
+	w, err := newWorker(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := w.NextN(10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	est, err = EstimateIssued(ID(ids[0]), ID(ids[len(ids)-1]))
+	if err != nil || est.Min > 9999 || est.Max < 9999 {
+		t.Errorf("EstimateIssued over 10000 ids = %+v, %v", est, err)
+	}
+}
@@ -0,0 +1,121 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// EtcdStateStore StateStore keeping the mark under an etcd key, through the etcd v3
+// JSON gateway (/v3/kv/range, /v3/kv/txn) so no gRPC client is needed
+type EtcdStateStore struct {
+	Endpoint string       // e.g. http://127.0.0.1:2379
+	Key      string       // etcd key
+	Client   *http.Client // nil means http.DefaultClient
+}
+
+// Load read the mark, 0 when the key does not exist
+func (s *EtcdStateStore) Load(ctx context.Context) (int64, error) {
+	mark, _, err := s.load(ctx)
+	return mark, err
+}
+
+// load 读取上限及其 mod_revision，键不存在时均为 0
+func (s *EtcdStateStore) load(ctx context.Context) (mark, revision int64, err error) {
+	var resp struct {
+		Kvs []struct {
+			Value       string `json:"value"`
+			ModRevision int64  `json:"mod_revision,string"`
+		} `json:"kvs"`
+	}
+	if err := s.call(ctx, "/v3/kv/range", map[string]string{"key": s.encode(s.Key)}, &resp); err != nil {
+		return 0, 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return 0, 0, nil
+	}
+	v, err := base64.StdEncoding.DecodeString(resp.Kvs[0].Value)
+	if err != nil {
+		return 0, 0, fmt.Errorf("etcd: %v", err)
+	}
+	mark, err = strconv.ParseInt(string(v), 10, 64)
+	return mark, resp.Kvs[0].ModRevision, err
+}
+
+// Store raise the mark with a transaction comparing the revision it read, retrying when
+// another writer got in between, and keep a higher stored mark
+func (s *EtcdStateStore) Store(ctx context.Context, millis int64) error {
+	key := s.encode(s.Key)
+	for {
+		mark, revision, err := s.load(ctx)
+		if err != nil || mark >= millis {
+			return err
+		}
+		compare := map[string]any{"key": key, "target": "MOD", "mod_revision": strconv.FormatInt(revision, 10)}
+		if revision == 0 {
+			compare = map[string]any{"key": key, "target": "CREATE", "create_revision": "0"}
+		}
+		txn := map[string]any{
+			"compare": []map[string]any{compare},
+			"success": []map[string]any{{"request_put": map[string]any{"key": key, "value": s.encode(strconv.FormatInt(millis, 10))}}},
+		}
+		var resp struct {
+			Succeeded bool `json:"succeeded"`
+		}
+		if err := s.call(ctx, "/v3/kv/txn", txn, &resp); err != nil {
+			return err
+		}
+		if resp.Succeeded {
+			return nil
+		}
+	}
+}
+
+func (s *EtcdStateStore) encode(v string) string {
+	return base64.StdEncoding.EncodeToString([]byte(v))
+}
+
+func (s *EtcdStateStore) call(ctx context.Context, path string, req, resp any) error {
+	return etcdCall(ctx, s.Client, s.Endpoint, path, req, resp)
+}
+
+// etcdCall 向 etcd v3 JSON 网关 POST req，将响应解码到 resp(可为 nil)
+func etcdCall(ctx context.Context, client *http.Client, endpoint, path string, req, resp any) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("etcd: %s: %s", res.Status, bytes.TrimSpace(data))
+	}
+	if resp == nil {
+		return nil
+	}
+	// 流式接口(如 lease/keepalive)每条消息一个 JSON 值，只取第一条
+	return json.NewDecoder(bytes.NewReader(data)).Decode(resp)
+}
@@ -0,0 +1,50 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import "time"
+
+// EventType kind of issuance anomaly
+type EventType string
+
+const (
+	// EventClockBackwards the clock moved backwards and Next refused to issue an id
+	EventClockBackwards EventType = "clock_backwards"
+	// EventSequenceExhausted the sequence of the current millisecond was used up
+	EventSequenceExhausted EventType = "sequence_exhausted"
+	// EventLeaseLost a background lease check failed, see WithLeaseCheck
+	EventLeaseLost EventType = "lease_lost"
+	// EventClockSmeared the clock moved backwards within the WithClockSmear tolerance,
+	// reported once per step
+	EventClockSmeared EventType = "clock_smeared"
+	// EventClockSkew the clock drifted beyond the WithSkewGuard threshold
+	EventClockSkew EventType = "clock_skew"
+)
+
+// Event issuance anomaly reported to the handler set by WithEventHandler
+type Event struct {
+	Type         EventType `json:"type"`
+	Time         time.Time `json:"time"`
+	WorkerID     int64     `json:"worker_id"`
+	DatacenterID int64     `json:"datacenter_id"`
+	// Millis 时钟回拨的毫秒数或与集群中位数的偏差，其他事件为 0
+	Millis int64 `json:"millis,omitempty"`
+}
+
+// WithEventHandler call h for every anomaly; h runs while the worker is locked,
+// so it must not block, see PublishEvents for forwarding events to a message bus
+func WithEventHandler(h func(Event)) Option {
+	return func(w *worker) {
+		w.onEvent = h
+	}
+}
+
+func (w *worker) emit(t EventType, millis int64) {
+	if w.logger != nil {
+		w.log(eventLevels[t], "snowflake: "+string(t), "millis", millis)
+	}
+	if w.onEvent == nil {
+		return
+	}
+	w.onEvent(Event{Type: t, Time: time.Now(), WorkerID: w.workerID, DatacenterID: w.datacenterID, Millis: millis})
+}
@@ -0,0 +1,267 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_EventHandler(t *testing.T) {
+	var events []Event
+	w, err := newWorker(1, 2, WithEventHandler(func(e Event) { events = append(events, e) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	frozen := currentMillis()
+	w.now = func() int64 { return frozen }
+	for i := 0; i <= sequenceMask+1; i++ {
+		if _, err := w.Next(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.now = func() int64 { return frozen - 10 }
+	w.lastTimestamp = currentMillis() + 10
+	if _, err := w.Next(); !errors.Is(err, ErrClockBackwards) {
+		t.Errorf("Next = %v, want ErrClockBackwards", err)
+	}
+
+	if len(events) != 2 || events[0].Type != EventSequenceExhausted || events[1].Type != EventClockBackwards {
+		t.Fatalf("events = %+v", events)
+	}
+	if events[0].WorkerID != 1 || events[0].DatacenterID != 2 || events[1].Millis <= 0 {
+		t.Errorf("events = %+v", events)
+	}
+}
+
+type memPublisher struct {
+	mu   sync.Mutex
+	msgs map[string][][]byte
+}
+
+func (m *memPublisher) Publish(subject string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.msgs[subject] = append(m.msgs[subject], data)
+	return nil
+}
+
+func Test_PublishEvents(t *testing.T) {
+	m := &memPublisher{msgs: make(map[string][][]byte)}
+	ep := PublishEvents(m, "snowflake.events", 1)
+	ep.Handle(Event{Type: EventClockBackwards, Millis: 5})
+	for i := 0; i < 100; i++ {
+		ep.Handle(Event{Type: EventSequenceExhausted})
+	}
+	ep.Close()
+	msgs := m.msgs["snowflake.events"]
+	if len(msgs) == 0 || uint64(len(msgs))+ep.Dropped() != 101 {
+		t.Fatalf("published %d, dropped %d", len(msgs), ep.Dropped())
+	}
+	// Close 之后仍在发出事件的 generator 不能因此 panic
+	dropped := ep.Dropped()
+	ep.Handle(Event{Type: EventSequenceExhausted})
+	if ep.Dropped() != dropped+1 || ep.Close() != nil {
+		t.Errorf("Handle after Close dropped %d, want %d", ep.Dropped(), dropped+1)
+	}
+	var e Event
+	if err := json.Unmarshal(msgs[0], &e); err != nil || e.Type != EventClockBackwards || e.Millis != 5 {
+		t.Errorf("first event = %s, %v", msgs[0], err)
+	}
+}
+
+func Test_NATSPublisher(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	lines := make(chan string, 3)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		r := bufio.NewReader(conn)
+		for i := 0; i < 3; i++ {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			lines <- strings.TrimRight(line, "\r\n")
+		}
+	}()
+
+	p, err := DialNATS(ln.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if err := p.Publish("snowflake.events", []byte(`{"type":"clock_backwards"}`)); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"CONNECT", "PUB snowflake.events 26", `{"type":"clock_backwards"}`}
+	for _, w := range want {
+		select {
+		case got := <-lines:
+			if !strings.HasPrefix(got, w) {
+				t.Errorf("got %q, want prefix %q", got, w)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for server")
+		}
+	}
+	if err := p.Publish("bad subject", nil); err == nil {
+		t.Error("expected invalid subject error")
+	}
+}
+
+func Test_NATSPublisherPingAndReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	lines := make(chan string, 16)
+	sendErr := make(chan struct{})
+	go func() {
+		for n := 0; ; n++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+				r := bufio.NewReader(conn)
+				read := func() bool {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return false
+					}
+					lines <- strings.TrimRight(line, "\r\n")
+					return true
+				}
+				if !read() {
+					return
+				}
+				if n == 0 {
+					// 第一个连接：PING 须得到 PONG，之后返回 -ERR
+					conn.Write([]byte("PING\r\n"))
+					if !read() {
+						return
+					}
+					<-sendErr
+					conn.Write([]byte("-ERR 'Authorization Violation'\r\n"))
+				}
+				for read() {
+				}
+			}()
+		}
+	}()
+	expect := func(prefix string) {
+		t.Helper()
+		select {
+		case got := <-lines:
+			if !strings.HasPrefix(got, prefix) {
+				t.Fatalf("got %q, want prefix %q", got, prefix)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %q", prefix)
+		}
+	}
+
+	p, err := DialNATS(ln.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	expect("CONNECT")
+	expect("PONG")
+
+	close(sendErr)
+	deadline := time.Now().Add(time.Second)
+	for {
+		err = p.Publish("snowflake.events", []byte("x"))
+		if err != nil || time.Now().After(deadline) {
+			break
+		}
+		expect("PUB snowflake.events 1")
+		expect("x")
+		time.Sleep(time.Millisecond)
+	}
+	if err == nil || !strings.Contains(err.Error(), "Authorization Violation") {
+		t.Fatalf("Publish after -ERR = %v", err)
+	}
+	if !strings.Contains(err.Error(), "earlier publish") {
+		t.Errorf("Publish after -ERR = %v, want an earlier publish error", err)
+	}
+	// 上报 -ERR 的那次 Publish 仍然重新连接并发送自己的消息
+	expect("CONNECT")
+	expect("PUB snowflake.events 1")
+	expect("x")
+
+	if err := p.Publish("snowflake.events", []byte("y")); err != nil {
+		t.Fatal(err)
+	}
+	expect("PUB snowflake.events 1")
+	expect("y")
+
+	p.Close()
+	if err := p.Publish("snowflake.events", nil); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Publish after Close = %v", err)
+	}
+}
+
+func Test_NATSPublisherStalledServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// 发送 INFO 后不再读取，写缓冲最终被填满
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		<-stop
+	}()
+
+	p, err := DialNATS(ln.Addr().String(), 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, 1<<20)
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		for i := 0; i < 256 && err == nil; i++ {
+			err = p.Publish("snowflake.events", data)
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("Publish to a stalled server never failed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publish blocked on a stalled server")
+	}
+	if err := p.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,149 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// FeedPolicy what a feeder does once its channel is full
+type FeedPolicy int
+
+const (
+	// FeedStall block until a consumer takes an id (default). Buffered ids keep the time they
+	// were issued, so after an idle period the first ones read can be far older than now.
+	FeedStall FeedPolicy = iota
+	// FeedDrop replace buffered ids once they are older than DefaultFeedMaxAge (see FeedFresh),
+	// at the cost of issuing ids nobody reads: about buffer ids per max age while idle
+	FeedDrop
+)
+
+// DefaultFeedMaxAge age at which FeedDrop replaces a buffered id
+const DefaultFeedMaxAge = 100 * time.Millisecond
+
+// feedPoll 缓冲区满时检查空位和过期 id 的间隔
+const feedPoll = time.Millisecond
+
+// Feed start a goroutine issuing ids from g into a channel of the given buffer, a dedicated
+// producer for consumers that prefer receiving to calling Next. The first id is issued before
+// Feed returns, so configuration errors surface as its error. When ctx is done the goroutine
+// stops and closes the channel; ids already buffered stay readable and are valid. Errors from g
+// other than ErrClosed (clock moved backwards, lease lost) are retried every millisecond, which
+// consumers see as a stall; ErrClosed closes the channel.
+func Feed(ctx context.Context, g Generator, buffer int, policy FeedPolicy) (<-chan int64, error) {
+	if policy != FeedStall && policy != FeedDrop {
+		return nil, fmt.Errorf("invalid feed policy %d", policy)
+	}
+	return startFeed(ctx, g, buffer, policy, DefaultFeedMaxAge)
+}
+
+// FeedFresh Feed with the FeedDrop policy, replacing buffered ids older than maxAge; while
+// the channel is full and fresh the goroutine sleeps, so an idle feeder issues about
+// buffer ids per maxAge instead of competing with other callers of g
+func FeedFresh(ctx context.Context, g Generator, buffer int, maxAge time.Duration) (<-chan int64, error) {
+	if maxAge <= 0 {
+		return nil, fmt.Errorf("invalid max age %v", maxAge)
+	}
+	return startFeed(ctx, g, buffer, FeedDrop, maxAge)
+}
+
+func startFeed(ctx context.Context, g Generator, buffer int, policy FeedPolicy, maxAge time.Duration) (<-chan int64, error) {
+	if buffer < 1 {
+		return nil, fmt.Errorf("invalid buffer %d", buffer)
+	}
+	first, err := g.NextContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan int64, buffer)
+	ch <- first
+	if policy == FeedDrop {
+		go feedDrop(ctx, g, ch, maxAge, time.Now())
+	} else {
+		go feed(ctx, g, ch)
+	}
+	return ch, nil
+}
+
+// StartFeeder Feed from DefaultWorker with the FeedStall policy
+func StartFeeder(ctx context.Context, buffer int) (<-chan int64, error) {
+	return StartFeederPolicy(ctx, buffer, FeedStall)
+}
+
+// StartFeederPolicy Feed from DefaultWorker with the given policy
+func StartFeederPolicy(ctx context.Context, buffer int, policy FeedPolicy) (<-chan int64, error) {
+	return Feed(ctx, DefaultWorker, buffer, policy)
+}
+
+// feedNext 签发下一个 id，出错时等待 1ms 后由调用方重试；ok 为 false 时应关闭通道
+func feedNext(ctx context.Context, g Generator) (id int64, issued, ok bool) {
+	id, err := g.NextContext(ctx)
+	if errors.Is(err, ErrClosed) {
+		return 0, false, false
+	}
+	if err != nil {
+		sleepContext(ctx, time.Millisecond)
+		return 0, false, true
+	}
+	return id, true, true
+}
+
+func feed(ctx context.Context, g Generator, ch chan int64) {
+	defer close(ch)
+	for ctx.Err() == nil {
+		id, issued, ok := feedNext(ctx, g)
+		if !ok {
+			return
+		}
+		if !issued {
+			continue
+		}
+		select {
+		case ch <- id:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// feedDrop 缓冲区满且未过期时休眠，最旧的 id 超过 maxAge 后丢弃并补入新的
+func feedDrop(ctx context.Context, g Generator, ch chan int64, maxAge time.Duration, first time.Time) {
+	defer close(ch)
+	// 通道中 id 的签发时间，最旧的在前；只有本协程发送，消费者取走的是队首
+	sent := make([]time.Time, 1, cap(ch)+1)
+	sent[0] = first
+	for ctx.Err() == nil {
+		if n := len(ch); n < len(sent) {
+			sent = append(sent[:0], sent[len(sent)-n:]...)
+		}
+		full := len(sent) == cap(ch)
+		if full {
+			if age := time.Since(sent[0]); age < maxAge {
+				sleepContext(ctx, min(maxAge-age, feedPoll))
+				continue
+			}
+		}
+		id, issued, ok := feedNext(ctx, g)
+		if !ok {
+			return
+		}
+		if !issued {
+			continue
+		}
+		if full {
+			// 丢弃最旧的一个；消费者同时取走时直接放入
+			select {
+			case <-ch:
+			default:
+			}
+			sent = append(sent[:0], sent[1:]...)
+		}
+		select {
+		case ch <- id:
+			sent = append(sent, time.Now())
+		case <-ctx.Done():
+		}
+	}
+}
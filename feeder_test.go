@@ -0,0 +1,116 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_Feed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g, _ := NewGenerator(1, 1)
+	ch, err := Feed(ctx, g, 16, FeedStall)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last int64
+	for i := 0; i < 1000; i++ {
+		id := <-ch
+		if id <= last {
+			t.Fatalf("id %d after %d", id, last)
+		}
+		last = id
+	}
+	cancel()
+	// 取消后仍可读出缓冲中的 id，随后通道关闭
+	for id := range ch {
+		if id <= last {
+			t.Fatalf("buffered id %d after %d", id, last)
+		}
+		last = id
+	}
+
+	if _, err := Feed(context.Background(), g, 0, FeedStall); err == nil {
+		t.Error("zero buffer accepted")
+	}
+	g.Close()
+	if _, err := Feed(context.Background(), g, 1, FeedStall); !errors.Is(err, ErrClosed) {
+		t.Errorf("closed generator: %v", err)
+	}
+}
+
+func Test_StartFeeder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := StartFeeder(ctx, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := <-ch; !IsValid(id) {
+		t.Errorf("fed id %d is not valid", id)
+	}
+	cancel()
+	for range ch {
+	}
+	if _, err := StartFeederPolicy(context.Background(), 4, FeedPolicy(7)); err == nil {
+		t.Error("invalid policy accepted")
+	}
+}
+
+func Test_FeedClosed(t *testing.T) {
+	g, _ := NewGenerator(1, 1)
+	ch, err := Feed(context.Background(), g, 4, FeedStall)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Close()
+	n := 0
+	for range ch {
+		n++
+	}
+	if n == 0 || n > 5 {
+		t.Errorf("read %d ids after Close", n)
+	}
+}
+
+func Test_FeedDrop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g, _ := NewGenerator(1, 1)
+	ch, err := FeedFresh(ctx, g, 4, 20*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	// 旧 id 被替换，缓冲区中的 id 不超过最大时长
+	id := <-ch
+	if age := time.Since(Decompose(ID(id)).Time); age > 50*time.Millisecond {
+		t.Errorf("buffered id is %v old", age)
+	}
+	// 缓冲区满时休眠，约每 20ms 替换一轮，而不是占满生成器
+	if s := g.Stats(); s.Issued < 8 || s.Issued > 100 {
+		t.Errorf("drop policy issued %d ids in 100ms", s.Issued)
+	}
+	// 消费者取走后立即补充，顺序不变
+	last := id
+	for i := 0; i < 1000; i++ {
+		id := <-ch
+		if id <= last {
+			t.Fatalf("id %d after %d", id, last)
+		}
+		last = id
+	}
+
+	if _, err := FeedFresh(ctx, g, 4, 0); err == nil {
+		t.Error("zero max age accepted")
+	}
+	dch, err := Feed(ctx, g, 4, FeedDrop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := <-dch; id <= last {
+		t.Errorf("Feed FeedDrop id %d after %d", id, last)
+	}
+}
@@ -0,0 +1,28 @@
+package snowflake
+
+import "strconv"
+
+// base58Alphabet Bitcoin base58 字母表，去掉了易混淆的 0 O I l
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var base58, _ = NewEncoder(base58Alphabet, nil)
+
+// AppendDecimal append decimal form of id to dst
+func AppendDecimal(dst []byte, id ID) []byte {
+	return strconv.AppendInt(dst, int64(id), 10)
+}
+
+// AppendBase58 append base58 (Bitcoin alphabet) form of id to dst
+func AppendBase58(dst []byte, id ID) []byte {
+	return base58.Append(dst, id)
+}
+
+// FormatBase58 return base58 form of id
+func FormatBase58(id ID) string {
+	return base58.Encode(id)
+}
+
+// ParseBase58 parse base58 form of id
+func ParseBase58(s string) (ID, error) {
+	return base58.Decode(s)
+}
@@ -0,0 +1,43 @@
+package snowflake
+
+import (
+	"testing"
+	"testing/quick"
+)
+
+func Test_AppendDecimal(t *testing.T) {
+	buf := make([]byte, 0, 64)
+	if got := string(AppendDecimal(buf, 4603909)); got != "4603909" {
+		t.Errorf("AppendDecimal = %q", got)
+	}
+	id := ID(1234567890123456789)
+	if n := testing.AllocsPerRun(100, func() { buf = AppendDecimal(buf[:0], id) }); n != 0 {
+		t.Errorf("AppendDecimal allocs = %v", n)
+	}
+	if n := testing.AllocsPerRun(100, func() { buf = AppendBase58(buf[:0], id) }); n != 0 {
+		t.Errorf("AppendBase58 allocs = %v", n)
+	}
+	var ok bool
+	if n := testing.AllocsPerRun(100, func() { ok = id.String() == "1234567890123456789" }); n != 0 || !ok {
+		t.Errorf("String allocs = %v", n)
+	}
+}
+
+func Test_Base58(t *testing.T) {
+	if got := FormatBase58(57); got != "z" {
+		t.Errorf("FormatBase58(57) = %q", got)
+	}
+	if got := FormatBase58(58); got != "21" {
+		t.Errorf("FormatBase58(58) = %q", got)
+	}
+	roundTrip := func(v int64) bool {
+		got, err := ParseBase58(string(AppendBase58(nil, ID(v))))
+		return err == nil && got == ID(v)
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+	if _, err := ParseBase58("0OIl"); err == nil {
+		t.Error("expected error for characters outside the alphabet")
+	}
+}
@@ -0,0 +1,329 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// ErrClosed returned by generators after Close
+var ErrClosed = errors.New("snowflake: generator closed")
+
+// Generator snowflake generator, a superset of Worker: wrap Worker implementations with
+// AsGenerator. Later capabilities are optional interfaces, such as Filler, ContextBatcher
+// and SaturationReporter, with package
+// helpers falling back to Next, so implementations outside this package keep compiling
+type Generator interface {
+	Worker
+	// NextN return n ids
+	NextN(n int) ([]int64, error)
+	// NextContext return new id, giving up when ctx is done
+	NextContext(ctx context.Context) (int64, error)
+	// Close release resources, later calls return ErrClosed
+	Close() error
+	// Stats return statistics since creation
+	Stats() Stats
+}
+
+// Filler implemented by generators created by NewGenerator, NewPool and NewShardedWorker
+type Filler interface {
+	Fill(dst []int64) error
+}
+
+// Fill fill dst with ids of w, in one pass when w implements Filler and through Next otherwise;
+// on error dst is filled up to the failed position
+func Fill(w Worker, dst []int64) error {
+	if f, ok := w.(Filler); ok {
+		return f.Fill(dst)
+	}
+	for i := range dst {
+		id, err := w.Next()
+		if err != nil {
+			return err
+		}
+		dst[i] = id
+	}
+	return nil
+}
+
+// ContextBatcher implemented by generators created by NewGenerator, NewPool and NewShardedWorker
+type ContextBatcher interface {
+	NextNContext(ctx context.Context, n int) ([]int64, error)
+}
+
+// NextNContext return n ids of w, or the ids generated so far and ctx.Err() once ctx is done;
+// workers not implementing ContextBatcher are called through Next, checking ctx before each id
+func NextNContext(ctx context.Context, w Worker, n int) ([]int64, error) {
+	if b, ok := w.(ContextBatcher); ok {
+		return b.NextNContext(ctx, n)
+	}
+	return nextEach(ctx, w, n)
+}
+
+// nextEach 逐个调用 Next，每个 id 前检查 ctx
+func nextEach(ctx context.Context, w Worker, n int) ([]int64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid n %d", n)
+	}
+	ids := make([]int64, 0, min(n, 1<<16))
+	for len(ids) < n {
+		if err := ctx.Err(); err != nil {
+			return ids, err
+		}
+		id, err := w.Next()
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Stats generator statistics, a plain value safe to copy, e.g. for debug endpoints
+type Stats struct {
+	Issued           uint64        // ids returned
+	Rollovers        uint64        // times a millisecond's sequence was used up
+	MaxSequence      int64         // largest sequence of an issued id
+	ClockRegressions uint64        // ids refused because the clock was behind the last issued timestamp
+	Uptime           time.Duration // time since creation
+	LastIssued       time.Time     // timestamp of the latest id, zero before the first
+	WaitStats
+	SmearStats
+	BorrowStats
+}
+
+// add 累加 o，用于汇总多个 worker
+func (s *Stats) add(o Stats) {
+	s.Issued += o.Issued
+	s.Rollovers += o.Rollovers
+	s.MaxSequence = max(s.MaxSequence, o.MaxSequence)
+	s.ClockRegressions += o.ClockRegressions
+	s.Uptime = max(s.Uptime, o.Uptime)
+	if o.LastIssued.After(s.LastIssued) {
+		s.LastIssued = o.LastIssued
+	}
+	s.Waits += o.Waits
+	s.Waited += o.Waited
+	s.Steps += o.Steps
+	s.Frozen += o.Frozen
+	s.Borrowed += o.Borrowed
+	s.BorrowDepth = max(s.BorrowDepth, o.BorrowDepth)
+}
+
+// NewGenerator return new snowflake generator, same as NewWorker but returns
+// configuration errors instead of panicking
+func NewGenerator(workerID uint8, datacenterID uint8, opts ...Option) (Generator, error) {
+	w, err := newWorker(workerID, datacenterID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// AsGenerator return w as Generator, adapting plain Worker implementations
+func AsGenerator(w Worker) Generator {
+	if g, ok := w.(Generator); ok {
+		return g
+	}
+	return &generatorAdapter{w: w}
+}
+
+// NextN return n ids, all generated under one lock acquisition
+func (w *worker) NextN(n int) ([]int64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid n %d", n)
+	}
+	if w.unsignedOnly() {
+		return nil, fmt.Errorf("layout is unsigned, use NextUint64")
+	}
+	ids := make([]int64, n)
+	if err := w.Fill(ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// nextNChunk NextNContext 每检查一次 ctx 生成的 id 数
+const nextNChunk = 256
+
+// NextNContext return n ids, or the ids generated so far and ctx.Err() once ctx is done,
+// checking ctx every few hundred ids so a batch spanning many milliseconds stops near the deadline
+func (w *worker) NextNContext(ctx context.Context, n int) ([]int64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid n %d", n)
+	}
+	// 按需扩容，截止时间先到时不必为整批分配内存
+	ids := make([]int64, 0, min(n, 1<<16))
+	for len(ids) < n {
+		if err := ctx.Err(); err != nil {
+			return ids, err
+		}
+		chunk := min(n-len(ids), nextNChunk)
+		ids = slices.Grow(ids, chunk)
+		if err := w.Fill(ids[len(ids) : len(ids)+chunk]); err != nil {
+			return ids, err
+		}
+		ids = ids[:len(ids)+chunk]
+	}
+	return ids, nil
+}
+
+// Fill fill dst with ids under one lock acquisition (released only to leave reserved
+// sequence space to high priority callers), for bulk jobs that pre-allocate;
+// on error dst is filled up to the failed position
+func (w *worker) Fill(dst []int64) error {
+	if w.unsignedOnly() {
+		return fmt.Errorf("layout is unsigned, use NextUint64")
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for i := range dst {
+		w.yieldLane(context.Background(), PriorityNormal)
+		id, err := signed(w.next(context.Background()))
+		if err != nil {
+			return err
+		}
+		dst[i] = id
+	}
+	return nil
+}
+
+// NextContext return new id unless ctx is done, waiting for the next millisecond takes at most 1ms;
+// the priority carried by ctx applies, see WithPriorityReserve
+func (w *worker) NextContext(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if w.unsignedOnly() {
+		return 0, fmt.Errorf("layout is unsigned, use NextUint64")
+	}
+	w.lockLane(ctx, PriorityFrom(ctx))
+	defer w.mutex.Unlock()
+	return signed(w.next(ctx))
+}
+
+// Close mark the worker closed, stop background checks, release its host lock and its
+// reference to the cached clock
+func (w *worker) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.closed = true
+	w.stopLease()
+	w.stopSkewGuard()
+	w.releaseHostLock()
+	w.releaseClock()
+	if w.redisSeq != nil {
+		return w.redisSeq.c.close()
+	}
+	return nil
+}
+
+// statsCounter next 维护的统计，无锁读取
+type statsCounter struct {
+	rollovers   atomic.Uint64
+	regressions atomic.Uint64
+	maxSequence atomic.Int64
+	last        atomic.Int64 // 最近签发的 id 的毫秒时间戳，0 表示尚未签发
+}
+
+// issue 记录一次成功签发，调用方需持有 w.mutex
+func (c *statsCounter) issue(timestamp, sequence int64) {
+	if sequence > c.maxSequence.Load() {
+		c.maxSequence.Store(sequence)
+	}
+	c.last.Store(timestamp)
+}
+
+// Stats return worker statistics
+func (w *worker) Stats() Stats {
+	s := Stats{
+		Issued:           w.issued.Load(),
+		Rollovers:        w.counters.rollovers.Load(),
+		MaxSequence:      w.counters.maxSequence.Load(),
+		ClockRegressions: w.counters.regressions.Load(),
+		Uptime:           time.Duration(w.now()-w.created) * time.Millisecond,
+		WaitStats:        w.WaitStats(),
+		SmearStats:       w.SmearStats(),
+		BorrowStats:      w.BorrowStats(),
+	}
+	if last := w.counters.last.Load(); last != 0 {
+		s.LastIssued = time.UnixMilli(last)
+	}
+	return s
+}
+
+// generatorAdapter 将只实现 Worker 的类型适配为 Generator
+type generatorAdapter struct {
+	w      Worker
+	issued atomic.Uint64
+	closed atomic.Bool
+}
+
+func (a *generatorAdapter) Next() (int64, error) {
+	if a.closed.Load() {
+		return 0, ErrClosed
+	}
+	id, err := a.w.Next()
+	if err == nil {
+		a.issued.Add(1)
+	}
+	return id, err
+}
+
+func (a *generatorAdapter) NextN(n int) ([]int64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid n %d", n)
+	}
+	ids := make([]int64, n)
+	if err := a.Fill(ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (a *generatorAdapter) NextNContext(ctx context.Context, n int) ([]int64, error) {
+	return nextEach(ctx, a, n)
+}
+
+func (a *generatorAdapter) Fill(dst []int64) error {
+	for i := range dst {
+		id, err := a.Next()
+		if err != nil {
+			return err
+		}
+		dst[i] = id
+	}
+	return nil
+}
+
+func (a *generatorAdapter) NextContext(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return a.Next()
+}
+
+// Close close the wrapped worker if it implements io.Closer
+func (a *generatorAdapter) Close() error {
+	if a.closed.Swap(true) {
+		return nil
+	}
+	if c, ok := a.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (a *generatorAdapter) Stats() Stats {
+	s := Stats{Issued: a.issued.Load()}
+	if r, ok := a.w.(WaitReporter); ok {
+		s.WaitStats = r.WaitStats()
+	}
+	return s
+}
@@ -0,0 +1,209 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_Generator(t *testing.T) {
+	g, err := NewGenerator(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := g.NextN(10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("ids[%d] = %d not greater than %d", i, ids[i], ids[i-1])
+		}
+	}
+	if _, err := g.NextContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := g.NextContext(ctx); err != context.Canceled {
+		t.Errorf("NextContext with canceled context: got %v", err)
+	}
+	if s := g.Stats(); s.Issued != 10001 {
+		t.Errorf("Stats().Issued = %d, want 10001", s.Issued)
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Next(); err != ErrClosed {
+		t.Errorf("Next after Close: got %v", err)
+	}
+
+	if _, err := NewGenerator(maxWorkerID+1, 0); err == nil {
+		t.Error("expected error for invalid worker id")
+	}
+}
+
+type countingWorker struct{ n int64 }
+
+func (c *countingWorker) Next() (int64, error) {
+	c.n++
+	return c.n, nil
+}
+
+func Test_AsGenerator(t *testing.T) {
+	w := NewWorker(0, 0)
+	if AsGenerator(w) != w.(Generator) {
+		t.Error("AsGenerator should return native generators unchanged")
+	}
+
+	g := AsGenerator(&countingWorker{})
+	ids, err := g.NextN(3)
+	if err != nil || len(ids) != 3 || ids[2] != 3 {
+		t.Fatalf("NextN = %v, %v", ids, err)
+	}
+	if s := g.Stats(); s.Issued != 3 {
+		t.Errorf("Stats().Issued = %d, want 3", s.Issued)
+	}
+	g.Close()
+	if _, err := g.Next(); err != ErrClosed {
+		t.Errorf("Next after Close: got %v", err)
+	}
+}
+
+func Test_Decompose(t *testing.T) {
+	g, err := NewGenerator(7, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := g.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Decompose(ID(id))
+	if p.WorkerID != 7 || p.DatacenterID != 3 || p.Sequence != 0 {
+		t.Errorf("Decompose(%d) = %+v", id, p)
+	}
+	if d := p.Time.Sub(DefaultLayout.Epoch); d <= 0 {
+		t.Errorf("Decompose(%d).Time = %v is before epoch", id, p.Time)
+	}
+}
+
+func Test_Fill(t *testing.T) {
+	g, err := NewGenerator(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]int64, 10000)
+	if err := Fill(g, ids); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("ids[%d] = %d not greater than %d", i, ids[i], ids[i-1])
+		}
+	}
+	if allocs := testing.AllocsPerRun(10, func() { Fill(g, ids[:100]) }); allocs != 0 {
+		t.Errorf("Fill allocated %v times", allocs)
+	}
+
+	a := AsGenerator(&countingWorker{})
+	dst := make([]int64, 3)
+	if err := Fill(a, dst); err != nil || dst[2] != 3 {
+		t.Errorf("adapter Fill = %v, %v", dst, err)
+	}
+	// 未实现 Filler 的 Worker 逐个调用 Next
+	if err := Fill(&countingWorker{}, dst); err != nil || dst[0] != 1 || dst[2] != 3 {
+		t.Errorf("Fill on plain Worker = %v, %v", dst, err)
+	}
+}
+
+func Benchmark_Fill(b *testing.B) {
+	g, _ := NewGenerator(1, 2)
+	dst := make([]int64, 4096)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := Fill(g, dst); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Test_NextNContext(t *testing.T) {
+	g, err := NewGenerator(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := NextNContext(context.Background(), g, 1000)
+	if err != nil || len(ids) != 1000 {
+		t.Fatalf("NextNContext = %d ids, %v", len(ids), err)
+	}
+
+	// 约 4096 个/毫秒，10ms 内无法生成 1 亿个
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	ids, err = NextNContext(ctx, g, 100_000_000)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("err = %v", err)
+	}
+	if len(ids) == 0 || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("partial batch of %d ids after %v", len(ids), time.Since(start))
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("ids[%d] = %d not greater than %d", i, ids[i], ids[i-1])
+		}
+	}
+
+	a := AsGenerator(&countingWorker{})
+	if ids, err := NextNContext(ctx, a, 3); err != context.DeadlineExceeded || len(ids) != 0 {
+		t.Errorf("adapter NextNContext with expired ctx = %v, %v", ids, err)
+	}
+	if ids, err := NextNContext(context.Background(), &countingWorker{}, 3); err != nil || len(ids) != 3 || ids[2] != 3 {
+		t.Errorf("NextNContext on plain Worker = %v, %v", ids, err)
+	}
+	if ids, err := NextNContext(ctx, &countingWorker{}, 3); err != context.DeadlineExceeded || len(ids) != 0 {
+		t.Errorf("NextNContext on plain Worker with expired ctx = %v, %v", ids, err)
+	}
+}
+
+func Test_Stats(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var calls, back atomic.Int64
+	// 每 16 次读取前进 1ms，每毫秒只有 4 个序列号，必然有序列耗尽
+	clock := func() time.Time {
+		return base.Add(time.Duration(calls.Add(1)/16-back.Load()) * time.Millisecond)
+	}
+	l := DefaultLayout
+	l.SequenceBits = 2
+	g, err := NewGenerator(1, 1, WithLayout(l), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := g.Stats(); !s.LastIssued.IsZero() || s.Issued != 0 {
+		t.Fatalf("before first id: %+v", s)
+	}
+	ids, err := g.NextN(40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := g.Stats()
+	if s.Issued != 40 || s.Rollovers == 0 || s.Rollovers != s.Waits || s.MaxSequence != 3 {
+		t.Errorf("Stats() = %+v", s)
+	}
+	if want := l.Decompose(ID(ids[39])).Time; !s.LastIssued.Equal(want) || s.Uptime <= 0 {
+		t.Errorf("LastIssued = %v want %v, Uptime = %v", s.LastIssued, want, s.Uptime)
+	}
+
+	back.Store(1000)
+	if _, err := g.Next(); !errors.Is(err, ErrClockBackwards) {
+		t.Fatalf("got %v, want ErrClockBackwards", err)
+	}
+	if s := g.Stats(); s.ClockRegressions != 1 || s.Issued != 40 {
+		t.Errorf("after regression: %+v", s)
+	}
+}
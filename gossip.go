@@ -0,0 +1,185 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultGossipGroup multicast group used by Gossip when Group is empty
+const DefaultGossipGroup = "239.255.76.67:7667"
+
+// ErrWorkerIDConflict returned by Gossip.CheckLease once an older process announced the same ids
+var ErrWorkerIDConflict = errors.New("snowflake: worker id claimed by another process")
+
+// gossipSettle 首次校验前等待已有进程应答的时长，局域网往返远小于此值
+const gossipSettle = 200 * time.Millisecond
+
+// GossipConflict another process on the LAN announced the same datacenter and worker ids
+type GossipConflict struct {
+	DatacenterID int64
+	WorkerID     int64
+	Peer         net.Addr
+	Yielded      bool // true when this process gave way to the older peer and refuses to issue ids
+}
+
+// Gossip announce the claimed datacenter and worker ids over UDP multicast and watch for
+// other processes claiming the same ones, for bare-metal clusters without etcd or Redis
+// catching copy-pasted configurations. The process started last yields: its CheckLease
+// returns ErrWorkerIDConflict, so combined with WithLeaseCheck it refuses to start (or
+// stops within the lease ttl) while the older process keeps issuing; the lease interval
+// must exceed the 200ms the first check waits for answers.
+// Use the datacenter and worker ids given to NewWorker, or 0 and the machine id with WithMachineID.
+type Gossip struct {
+	Group        string         // multicast address, default DefaultGossipGroup
+	Interface    *net.Interface // interface to join the group on, nil for the system default
+	DatacenterID int64
+	WorkerID     int64
+	Interval     time.Duration        // announce interval, default 1s
+	OnConflict   func(GossipConflict) // called on every conflicting announcement, for alerting
+
+	started  int64  // 启动时刻(纳秒)，先启动的进程保留 id
+	nonce    uint64 // 区分同一时刻启动的进程，并过滤组播回环收到的自身消息
+	conn     *net.UDPConn
+	send     *net.UDPConn
+	mu       sync.Mutex
+	conflict error
+	stop     chan struct{}
+	done     sync.WaitGroup
+}
+
+// Start join the group, announce the ids and answer other processes in the background until Close
+func (g *Gossip) Start() error {
+	group := g.Group
+	if group == "" {
+		group = DefaultGossipGroup
+	}
+	addr, err := net.ResolveUDPAddr("udp", group)
+	if err != nil {
+		return err
+	}
+	if g.Interval <= 0 {
+		g.Interval = time.Second
+	}
+	var b [8]byte
+	rand.Read(b[:])
+	g.nonce = binary.BigEndian.Uint64(b[:])
+	g.started = time.Now().UnixNano()
+	g.stop = make(chan struct{})
+	if g.conn, err = net.ListenMulticastUDP("udp", g.Interface, addr); err != nil {
+		return err
+	}
+	if g.send, err = net.DialUDP("udp", nil, addr); err != nil {
+		g.conn.Close()
+		return err
+	}
+	g.done.Add(2)
+	go g.listen()
+	go g.announceLoop()
+	return nil
+}
+
+// CheckLease return ErrWorkerIDConflict once an older process announced the same ids;
+// the first call waits briefly for running processes to answer the initial announcement
+func (g *Gossip) CheckLease(ctx context.Context) error {
+	if wait := time.Until(time.Unix(0, g.started).Add(gossipSettle)); wait > 0 {
+		t := time.NewTimer(wait)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.conflict
+}
+
+// Close stop announcing and leave the group
+func (g *Gossip) Close() error {
+	close(g.stop)
+	g.conn.Close()
+	err := g.send.Close()
+	g.done.Wait()
+	return err
+}
+
+// gossipMessage 组播消息：协议名、版本、数据标识、机器号、启动时刻、随机数
+const gossipMessage = "snowflake-gossip 1 %d %d %d %d"
+
+func (g *Gossip) announce() {
+	fmt.Fprintf(g.send, gossipMessage, g.DatacenterID, g.WorkerID, g.started, g.nonce)
+}
+
+func (g *Gossip) announceLoop() {
+	defer g.done.Done()
+	g.announce()
+	ticker := time.NewTicker(g.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			g.announce()
+		case <-g.stop:
+			return
+		}
+	}
+}
+
+func (g *Gossip) listen() {
+	defer g.done.Done()
+	buf := make([]byte, 256)
+	var backoff time.Duration
+	for {
+		n, peer, err := g.conn.ReadFromUDP(buf)
+		if err != nil {
+			// 连接已关闭时退出，其他错误退避重试，避免持续报错时空转
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			backoff = min(max(2*backoff, 10*time.Millisecond), g.Interval)
+			select {
+			case <-g.stop:
+				return
+			case <-time.After(backoff):
+				continue
+			}
+		}
+		backoff = 0
+		var datacenterID, workerID, started int64
+		var nonce uint64
+		if _, err := fmt.Sscanf(string(buf[:n]), gossipMessage, &datacenterID, &workerID, &started, &nonce); err != nil {
+			continue
+		}
+		if datacenterID != g.DatacenterID || workerID != g.WorkerID || nonce == g.nonce {
+			continue
+		}
+		// 先启动的进程保留 id，同时启动时随机数小的保留
+		yield := started < g.started || started == g.started && nonce < g.nonce
+		if yield {
+			g.mu.Lock()
+			g.conflict = fmt.Errorf("%w: datacenter %d worker %d announced by %v", ErrWorkerIDConflict, datacenterID, workerID, peer)
+			g.mu.Unlock()
+		} else {
+			// 立即应答，新进程无需等待下一个周期
+			g.announce()
+		}
+		if g.OnConflict != nil {
+			g.report(GossipConflict{DatacenterID: datacenterID, WorkerID: workerID, Peer: peer, Yielded: yield})
+		}
+	}
+}
+
+// report 忽略告警回调的 panic，避免后台 goroutine 使进程退出
+func (g *Gossip) report(c GossipConflict) {
+	defer func() { recover() }()
+	g.OnConflict(c)
+}
@@ -0,0 +1,84 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func Test_Gossip(t *testing.T) {
+	// 随机端口，避免与同一局域网上的其他测试互相干扰
+	probe, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		t.Skip(err)
+	}
+	group := fmt.Sprintf("239.255.76.67:%d", probe.LocalAddr().(*net.UDPAddr).Port)
+	probe.Close()
+
+	alerts := make(chan GossipConflict, 16)
+	old := &Gossip{Group: group, DatacenterID: 1, WorkerID: 3, Interval: 50 * time.Millisecond,
+		OnConflict: func(c GossipConflict) { alerts <- c }}
+	if err := old.Start(); err != nil {
+		t.Skipf("multicast unavailable: %v", err)
+	}
+	defer old.Close()
+	other := &Gossip{Group: group, DatacenterID: 1, WorkerID: 4}
+	if err := other.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if err := old.CheckLease(context.Background()); err != nil {
+		t.Fatalf("first process: %v", err)
+	}
+
+	dup := &Gossip{Group: group, DatacenterID: 1, WorkerID: 3}
+	if err := dup.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer dup.Close()
+	_, err = NewGenerator(3, 1, WithLeaseCheck(dup, time.Second, 3*time.Second))
+	if !errors.Is(err, ErrWorkerIDConflict) {
+		t.Fatalf("duplicate NewGenerator error = %v, want ErrWorkerIDConflict", err)
+	}
+	if err := old.CheckLease(context.Background()); err != nil {
+		t.Errorf("older process yielded: %v", err)
+	}
+	if err := other.CheckLease(context.Background()); err != nil {
+		t.Errorf("unrelated ids: %v", err)
+	}
+	select {
+	case c := <-alerts:
+		if c.Yielded || c.DatacenterID != 1 || c.WorkerID != 3 {
+			t.Errorf("alert = %+v", c)
+		}
+	case <-time.After(time.Second):
+		t.Error("older process not alerted")
+	}
+}
+
+func Test_GossipListenClosed(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Skip(err)
+	}
+	conn.Close()
+	// 未经 Close 而连接已失效：listen 须退出，而非空转
+	g := &Gossip{Interval: time.Second, conn: conn, stop: make(chan struct{})}
+	g.done.Add(1)
+	go g.listen()
+	done := make(chan struct{})
+	go func() {
+		g.done.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("listen kept running on a closed connection")
+	}
+}
@@ -0,0 +1,106 @@
+package snowflake
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GroupedFormat radix, padding and digit grouping of an id written for humans, e.g.
+// "0184-3F2A-9C01-7000" with GroupedHex, for support tooling and ids read off screens
+type GroupedFormat struct {
+	Base      int    // radix from 2 to 36, default 16
+	Width     int    // minimum number of digits, padded on the left with Pad
+	Pad       byte   // padding character, default '0'
+	Group     int    // digits per group counted from the right, 0 for no grouping
+	Separator string // written between groups, default "-"
+	Upper     bool   // upper case letter digits
+}
+
+// GroupedHex 16 upper case hexadecimal digits in groups of 4
+var GroupedHex = GroupedFormat{Base: 16, Width: 16, Group: 4, Upper: true}
+
+// groupedSeparators Parse 总是忽略的分隔符
+const groupedSeparators = " -_.:,/"
+
+func (f GroupedFormat) base() int {
+	if f.Base == 0 {
+		return 16
+	}
+	return f.Base
+}
+
+func (f GroupedFormat) pad() byte {
+	if f.Pad == 0 {
+		return '0'
+	}
+	return f.Pad
+}
+
+func (f GroupedFormat) separator() string {
+	if f.Separator == "" {
+		return "-"
+	}
+	return f.Separator
+}
+
+// Format return id in the format, as an unsigned number
+func (f GroupedFormat) Format(id ID) string {
+	return string(f.Append(nil, id))
+}
+
+// Append append Format output to dst
+func (f GroupedFormat) Append(dst []byte, id ID) []byte {
+	var buf [64]byte
+	digits := strconv.AppendUint(buf[:0], uint64(id), f.base())
+	if f.Upper {
+		for i, c := range digits {
+			if 'a' <= c && c <= 'z' {
+				digits[i] = c - 'a' + 'A'
+			}
+		}
+	}
+	n := max(len(digits), f.Width)
+	for i := range n {
+		if f.Group > 0 && i > 0 && (n-i)%f.Group == 0 {
+			dst = append(dst, f.separator()...)
+		}
+		if pad := n - len(digits); i < pad {
+			dst = append(dst, f.pad())
+		} else {
+			dst = append(dst, digits[i-pad])
+		}
+	}
+	return dst
+}
+
+// Parse parse an id written in the format's radix, tolerating any case, padding, the
+// separator and spaces, '-', '_', '.', ':', ',' or '/' anywhere, so ids copied from
+// tickets or read over the phone parse however they were grouped
+func (f GroupedFormat) Parse(s string) (ID, error) {
+	var b strings.Builder
+	b.Grow(len(s))
+	rest := s
+	if pad := f.pad(); pad != '0' {
+		rest = strings.TrimLeft(rest, string(pad))
+	}
+	sep := f.separator()
+	for rest != "" {
+		if strings.HasPrefix(rest, sep) {
+			rest = rest[len(sep):]
+			continue
+		}
+		if c := rest[0]; strings.IndexByte(groupedSeparators, c) < 0 {
+			b.WriteByte(c)
+		}
+		rest = rest[1:]
+	}
+	if b.Len() == 0 {
+		return 0, fmt.Errorf("invalid id %q: no digits", s)
+	}
+	u, err := strconv.ParseUint(b.String(), f.base(), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q: %v", s, err)
+	}
+	return ID(u), nil
+}
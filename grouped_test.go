@@ -0,0 +1,53 @@
+package snowflake
+
+import (
+	"testing"
+	"testing/quick"
+)
+
+func Test_GroupedFormat(t *testing.T) {
+	for _, c := range []struct {
+		f    GroupedFormat
+		id   ID
+		want string
+	}{
+		{GroupedHex, 0x01843f2a9c017000, "0184-3F2A-9C01-7000"},
+		{GroupedHex, 0, "0000-0000-0000-0000"},
+		{GroupedHex, -1, "FFFF-FFFF-FFFF-FFFF"},
+		{GroupedFormat{Width: 12, Group: 4, Upper: true}, 0x01843f2a9c01, "0184-3F2A-9C01"},
+		{GroupedFormat{Base: 10, Group: 3, Separator: ","}, 4603909, "4,603,909"},
+		{GroupedFormat{Base: 36, Width: 8, Pad: ' ', Group: 4, Separator: " "}, 35, "        z"},
+		{GroupedFormat{Base: 2, Width: 8}, 5, "00000101"},
+	} {
+		if got := c.f.Format(c.id); got != c.want {
+			t.Errorf("%+v.Format(%d) = %q, want %q", c.f, c.id, got, c.want)
+		}
+		if got, err := c.f.Parse(c.want); err != nil || got != c.id {
+			t.Errorf("%+v.Parse(%q) = %d, %v", c.f, c.want, got, err)
+		}
+	}
+	roundTrip := func(v int64) bool {
+		got, err := GroupedHex.Parse(GroupedHex.Format(ID(v)))
+		return err == nil && got == ID(v)
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_GroupedFormatParseTolerant(t *testing.T) {
+	for _, s := range []string{"0184-3F2A-9C01-7000", "0184 3f2a 9c01 7000", "01843f2a9c017000", "1843F2A9C017000", " 0184.3F2A_9C01:7000 ", "0184--3f2a/9c01,7000"} {
+		if got, err := GroupedHex.Parse(s); err != nil || got != 0x01843f2a9c017000 {
+			t.Errorf("Parse(%q) = %#x, %v", s, got, err)
+		}
+	}
+	words := GroupedFormat{Group: 4, Separator: "dash"}
+	if got, err := words.Parse("00ffdash0001"); err != nil || got != 0xff0001 {
+		t.Errorf("Parse with word separator = %#x, %v", got, err)
+	}
+	for _, s := range []string{"", "--", "0184-3G2A", "1-0000-0000-0000-0000"} {
+		if _, err := GroupedHex.Parse(s); err == nil {
+			t.Errorf("Parse(%q) accepted", s)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// WithHostLock refuse to create the worker while another process on this host holds the
+// same worker/datacenter id pair, detected with an advisory file lock in dir (default
+// os.TempDir()); the lock is released by Close or when the process exits. Only
+// supported on unix.
+func WithHostLock(dir string) Option {
+	return func(w *worker) {
+		if dir == "" {
+			dir = os.TempDir()
+		}
+		w.lockDir = dir
+	}
+}
+
+// acquireHostLock 对 snowflake-<dc>-<worker>.lock 加排他锁，并写入当前进程 pid 便于排查
+func (w *worker) acquireHostLock() error {
+	path := filepath.Join(w.lockDir, fmt.Sprintf("snowflake-%d-%d.lock", w.datacenterID, w.workerID))
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := lockFile(f); err != nil {
+		owner, _ := os.ReadFile(path)
+		f.Close()
+		if err == errLockHeld {
+			return fmt.Errorf("worker Id %d datacenter Id %d is already in use on this host by pid %s",
+				w.workerID, w.datacenterID, strings.TrimSpace(string(owner)))
+		}
+		return err
+	}
+	f.Truncate(0)
+	f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	w.lockFile = f
+	return nil
+}
+
+func (w *worker) releaseHostLock() {
+	if w.lockFile == nil {
+		return
+	}
+	unlockFile(w.lockFile)
+	w.lockFile.Close()
+	w.lockFile = nil
+}
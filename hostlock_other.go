@@ -0,0 +1,23 @@
+//go:build (!unix || tinygo) && !snowflake_decode
+
+package snowflake
+
+import (
+	"errors"
+	"os"
+)
+
+var errLockHeld = errors.New("lock held by another process")
+
+func lockFile(f *os.File) error {
+	return errors.New("host lock is not supported on this platform")
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
+
+// waitLockFile 不支持文件锁的平台上只依赖进程内的互斥
+func waitLockFile(f *os.File) error {
+	return nil
+}
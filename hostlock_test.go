@@ -0,0 +1,64 @@
+//go:build unix && !tinygo && !snowflake_decode
+
+package snowflake
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func Test_HostLock(t *testing.T) {
+	dir := t.TempDir()
+	a, err := NewGenerator(1, 2, WithHostLock(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// flock 按打开的文件描述符生效，同一进程内再次打开同样会冲突
+	if _, err := NewGenerator(1, 2, WithHostLock(dir)); err == nil || !strings.Contains(err.Error(), "already in use") {
+		t.Errorf("second worker with the same ids: got %v", err)
+	}
+	other, err := NewGenerator(2, 2, WithHostLock(dir))
+	if err != nil {
+		t.Fatalf("different worker id: %v", err)
+	}
+	other.Close()
+	a.Close()
+	b, err := NewGenerator(1, 2, WithHostLock(dir))
+	if err != nil {
+		t.Fatalf("after Close: %v", err)
+	}
+	b.Close()
+}
+
+// Test_HostLockProcess 子进程持有锁时本进程应拒绝
+func Test_HostLockProcess(t *testing.T) {
+	if dir := os.Getenv("SNOWFLAKE_HOSTLOCK_CHILD"); dir != "" {
+		if _, err := NewGenerator(3, 3, WithHostLock(dir)); err != nil {
+			os.Exit(1)
+		}
+		os.Stdout.WriteString("locked\n")
+		os.Stdin.Read(make([]byte, 1))
+		os.Exit(0)
+	}
+	dir := t.TempDir()
+	cmd := exec.Command(os.Args[0], "-test.run=^Test_HostLockProcess$")
+	cmd.Env = append(os.Environ(), "SNOWFLAKE_HOSTLOCK_CHILD="+dir)
+	stdin, _ := cmd.StdinPipe()
+	stdout, _ := cmd.StdoutPipe()
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		stdin.Close()
+		cmd.Wait()
+	}()
+	buf := make([]byte, 7)
+	if _, err := stdout.Read(buf); err != nil || string(buf) != "locked\n" {
+		t.Fatalf("child did not lock: %q, %v", buf, err)
+	}
+	if _, err := NewGenerator(3, 3, WithHostLock(dir)); err == nil {
+		t.Error("expected error while another process holds the lock")
+	}
+}
@@ -0,0 +1,71 @@
+package snowflake
+
+import (
+	"hash/fnv"
+	"os"
+	"strings"
+)
+
+// HashMachineID return the machine id (see WithMachineID) of host under the layout: the
+// FNV-1a hash of the lower-cased name, without a trailing dot, reduced to the datacenter
+// and worker bits (at most 16). Different hosts may collide, check the inventory with
+// MachineIDTable before deploying.
+func HashMachineID(host string, l Layout) uint16 {
+	h := fnv.New64a()
+	h.Write([]byte(normalizeHost(host)))
+	bits := min(int(l.DatacenterBits)+int(l.WorkerBits), 16)
+	return uint16(h.Sum64() % (uint64(1) << bits))
+}
+
+// HostnameMachineID return HashMachineID of os.Hostname
+func HostnameMachineID(l Layout) (uint16, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		return 0, err
+	}
+	return HashMachineID(host, l), nil
+}
+
+// normalizeHost 同一主机名的不同写法映射到同一机器 id
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// HostMachineID machine id HashMachineID assigns to a host
+type HostMachineID struct {
+	Host      string
+	MachineID uint16
+	Collides  []string // other hosts of the inventory hashed to the same machine id
+}
+
+// MachineIDTable return the machine ids of an inventory of hosts in input order, with
+// the collisions of each, as a preflight check for HashMachineID; spellings of the same
+// host (case, trailing dot) are listed once
+func MachineIDTable(hosts []string, l Layout) []HostMachineID {
+	var table []HostMachineID
+	seen := map[string]bool{}
+	byID := map[uint16][]int{}
+	for _, host := range hosts {
+		name := normalizeHost(host)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		id := HashMachineID(name, l)
+		byID[id] = append(byID[id], len(table))
+		table = append(table, HostMachineID{Host: name, MachineID: id})
+	}
+	for _, rows := range byID {
+		if len(rows) < 2 {
+			continue
+		}
+		for _, i := range rows {
+			for _, j := range rows {
+				if i != j {
+					table[i].Collides = append(table[i].Collides, table[j].Host)
+				}
+			}
+		}
+	}
+	return table
+}
@@ -0,0 +1,59 @@
+package snowflake
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func Test_HashMachineID(t *testing.T) {
+	id := HashMachineID("web-01.example.com", DefaultLayout)
+	if id > 1023 {
+		t.Fatalf("HashMachineID = %d, want at most 10 bits", id)
+	}
+	if got := HashMachineID(" WEB-01.example.com. ", DefaultLayout); got != id {
+		t.Errorf("normalized host = %d, want %d", got, id)
+	}
+	small := DefaultLayout
+	small.DatacenterBits, small.WorkerBits = 0, 3
+	for i := range 100 {
+		if got := HashMachineID(fmt.Sprintf("host-%d", i), small); got > 7 {
+			t.Fatalf("3 machine bits: got %d", got)
+		}
+	}
+	if _, err := HostnameMachineID(DefaultLayout); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_MachineIDTable(t *testing.T) {
+	small := DefaultLayout
+	small.DatacenterBits, small.WorkerBits = 0, 2
+	hosts := []string{"a", "b", "c", "d", "e", "A.", ""}
+	table := MachineIDTable(hosts, small)
+	if len(table) != 5 {
+		t.Fatalf("table = %+v, want 5 distinct hosts", table)
+	}
+	byID := map[uint16][]string{}
+	for _, row := range table {
+		if row.MachineID != HashMachineID(row.Host, small) {
+			t.Errorf("%s: machine id %d", row.Host, row.MachineID)
+		}
+		byID[row.MachineID] = append(byID[row.MachineID], row.Host)
+	}
+	// 5 台主机只有 4 个机器 id，必有冲突
+	collided := 0
+	for _, row := range table {
+		want := slices.DeleteFunc(slices.Clone(byID[row.MachineID]), func(h string) bool { return h == row.Host })
+		slices.Sort(row.Collides)
+		if !slices.Equal(row.Collides, want) {
+			t.Errorf("%s collides with %v, want %v", row.Host, row.Collides, want)
+		}
+		if len(row.Collides) > 0 {
+			collided++
+		}
+	}
+	if collided < 2 {
+		t.Errorf("expected collisions in %+v", table)
+	}
+}
@@ -0,0 +1,77 @@
+package snowflake
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ID snowflake id
+type ID int64
+
+// Int64 return id as int64
+func (id ID) Int64() int64 {
+	return int64(id)
+}
+
+// String return decimal form of id, allocation free when the result does not escape
+// (compared, written to a buffer); use AppendDecimal on hot logging paths
+func (id ID) String() string {
+	var b [20]byte
+	return string(appendDecimal(b[:0], id))
+}
+
+// appendDecimal 禁止内联，使 String 足够小可以内联到调用方，
+// 结果不逃逸时 string 转换复用栈上缓冲
+//
+//go:noinline
+func appendDecimal(dst []byte, id ID) []byte {
+	return strconv.AppendInt(dst, int64(id), 10)
+}
+
+// Parse parse decimal id
+func Parse(s string) (ID, error) {
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q: %v", s, err)
+	}
+	return ID(i), nil
+}
+
+// Time return creation time of id, decoded with the layout of DefaultWorker
+func (id ID) Time() time.Time {
+	return time.UnixMilli(id.UnixMilli())
+}
+
+// TimeIn return creation time of id in loc, decoded with the layout of DefaultWorker
+func (id ID) TimeIn(loc *time.Location) time.Time {
+	return id.Time().In(loc)
+}
+
+// UnixMilli return creation time of id as milliseconds since the Unix epoch, decoded with
+// the layout of DefaultWorker
+func (id ID) UnixMilli() int64 {
+	l := defaultLayout()
+	return l.Epoch.UnixMilli() + l.elapsed(uint64(id))
+}
+
+// TimestampRaw return the timestamp field of id as stored, milliseconds since the epoch of
+// the layout of DefaultWorker rather than the Unix epoch
+func (id ID) TimestampRaw() int64 {
+	return defaultLayout().elapsed(uint64(id))
+}
+
+// Describe return one line breakdown of id decoded with the layout of DefaultWorker,
+// with the datacenter named from Regions when registered, e.g.
+// "id=... time=2024-03-01T08:00:00.123Z local=2024-03-01T16:00:00.123+08:00 age=1h2m3s datacenter=1 worker=2 sequence=3"
+func Describe(id ID) string {
+	p := Decompose(id)
+	const layout = "2006-01-02T15:04:05.000Z07:00"
+	datacenter := strconv.FormatInt(p.DatacenterID, 10)
+	if p.Region != "" {
+		datacenter = p.Region
+	}
+	return fmt.Sprintf("id=%d time=%s local=%s age=%s datacenter=%s worker=%d sequence=%d",
+		int64(id), p.Time.UTC().Format(layout), p.Time.Local().Format(layout),
+		time.Since(p.Time).Truncate(time.Millisecond), datacenter, p.WorkerID, p.Sequence)
+}
@@ -0,0 +1,62 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Parse(t *testing.T) {
+	id, err := Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Parse(ID(id).String())
+	if err != nil || got.Int64() != id {
+		t.Errorf("Parse(%q) = %d, %v", ID(id).String(), got, err)
+	}
+	if _, err := Parse("12a"); err == nil {
+		t.Error("Parse(\"12a\") expected error")
+	}
+}
+
+func Test_TimeIn(t *testing.T) {
+	before := time.Now().Truncate(time.Millisecond)
+	id, err := Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loc := time.FixedZone("UTC+8", 8*3600)
+	got := ID(id).TimeIn(loc)
+	if got.Location() != loc || got.Before(before) || got.After(time.Now()) {
+		t.Errorf("TimeIn = %v, generated after %v", got, before)
+	}
+}
+
+func Test_IDTime(t *testing.T) {
+	id := ID(1234<<timestampLeftShift | 3<<datacenterIDShift | 4<<workerIDShift | 5)
+	if got := id.TimestampRaw(); got != 1234 {
+		t.Errorf("TimestampRaw = %d", got)
+	}
+	if got := id.UnixMilli(); got != twepoch+1234 {
+		t.Errorf("UnixMilli = %d", got)
+	}
+	if got := id.Time(); !got.Equal(time.UnixMilli(twepoch + 1234)) {
+		t.Errorf("Time = %v", got)
+	}
+	if got := id.Time(); !got.Equal(Decompose(id).Time) {
+		t.Errorf("Time = %v, Decompose %v", got, Decompose(id).Time)
+	}
+}
+
+func Test_Describe(t *testing.T) {
+	id := ID(1<<timestampLeftShift | 3<<datacenterIDShift | 4<<workerIDShift | 5)
+	got := Describe(id)
+	for _, want := range []string{"id=4603909", "time=2018-12-31T16:00:00.001Z", "datacenter=3", "worker=4", "sequence=5", "age="} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Describe(%d) = %q, missing %q", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package snowflake
+
+import "context"
+
+type idKey struct{}
+
+// ContextWithID return ctx carrying id, e.g. the request id set by Middleware, for
+// propagation to handlers, loggers and outgoing calls
+func ContextWithID(ctx context.Context, id ID) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// IDFromContext return the id carried by ctx, false when there is none
+func IDFromContext(ctx context.Context) (ID, bool) {
+	id, ok := ctx.Value(idKey{}).(ID)
+	return id, ok
+}
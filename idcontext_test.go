@@ -0,0 +1,16 @@
+package snowflake
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_IDContext(t *testing.T) {
+	if _, ok := IDFromContext(context.Background()); ok {
+		t.Error("empty context carries an id")
+	}
+	ctx := ContextWithID(context.Background(), 42)
+	if id, ok := IDFromContext(ctx); !ok || id != 42 {
+		t.Errorf("IDFromContext = %d, %v", id, ok)
+	}
+}
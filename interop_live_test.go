@@ -0,0 +1,92 @@
+//go:build interop && !snowflake_decode
+
+package snowflake
+
+// 与参考实现本身核对，需要构建环境中提供两个模块：
+//
+//	go test -tags interop -run Interop .
+
+import (
+	"testing"
+	"time"
+
+	bwsnowflake "github.com/bwmarrin/snowflake"
+	"github.com/sony/sonyflake"
+)
+
+// Test_InteropReference 记录的向量与参考实现的解码一致
+func Test_InteropReference(t *testing.T) {
+	if bwsnowflake.Epoch != twitterEpoch.UnixMilli() || bwsnowflake.NodeBits != 10 || bwsnowflake.StepBits != 12 {
+		t.Fatalf("bwmarrin/snowflake settings changed: epoch %d, node bits %d, step bits %d", bwsnowflake.Epoch, bwsnowflake.NodeBits, bwsnowflake.StepBits)
+	}
+	for _, v := range interopVectors {
+		switch v.scheme {
+		case "twitter", "bwmarrin":
+			id := bwsnowflake.ParseInt64(int64(v.id))
+			if id.Time() != v.at.UnixMilli() || id.Node() != v.dc<<5|v.worker || id.Step() != v.sequence {
+				t.Errorf("bwmarrin: %d = %d/%d/%d, want %+v", v.id, id.Time(), id.Node(), id.Step(), v)
+			}
+		case "sonyflake":
+			d := sonyflake.Decompose(v.id)
+			if ticks := uint64(v.at.Sub(sonyflakeEpoch) / (10 * time.Millisecond)); d["time"] != ticks || d["sequence"] != uint64(v.worker) || d["machine-id"] != uint64(v.sequence) {
+				t.Errorf("sonyflake: %d = %v, want %+v", v.id, d, v)
+			}
+		}
+	}
+}
+
+// Test_InteropGenerated 参考实现生成的 id 与本包互相解码一致
+func Test_InteropGenerated(t *testing.T) {
+	node, err := bwsnowflake.NewNode(113)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bw, _ := interopSchemes["bwmarrin"]()
+	for range 1000 {
+		id := node.Generate()
+		p := bw.Decode(uint64(id.Int64()))
+		if p.Time.UnixMilli() != id.Time() || p.WorkerID != id.Node() || p.Sequence != id.Step() {
+			t.Fatalf("bwmarrin id %d decoded as %+v", id, p)
+		}
+	}
+
+	sf := sonyflake.NewSonyflake(sonyflake.Settings{
+		StartTime: sonyflakeEpoch,
+		MachineID: func() (uint16, error) { return 0x1234, nil },
+	})
+	if sf == nil {
+		t.Fatal("sonyflake settings rejected")
+	}
+	sony, _ := interopSchemes["sonyflake"]()
+	for range 1000 {
+		id, err := sf.NextID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := sonyflake.Decompose(id)
+		p := sony.Decode(id)
+		if !p.Time.Equal(sonyflakeEpoch.Add(time.Duration(d["time"])*10*time.Millisecond)) || p.WorkerID != int64(d["sequence"]) || p.Sequence != int64(d["machine-id"]) {
+			t.Fatalf("sonyflake id %d decoded as %+v, want %v", id, p, d)
+		}
+	}
+
+	// 本包按 Twitter 布局生成的 id 由 bwmarrin/snowflake 解码
+	twitter, _ := interopSchemes["twitter"]()
+	l, _ := twitter.Layout()
+	w, err := NewGenerator(17, 3, WithLayout(l))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	for range 1000 {
+		id, err := w.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ref := bwsnowflake.ParseInt64(id)
+		p := l.Decompose(ID(id))
+		if ref.Time() != p.Time.UnixMilli() || ref.Node() != 3<<5|17 || ref.Step() != p.Sequence {
+			t.Fatalf("id %d decoded by bwmarrin/snowflake as %d/%d/%d", id, ref.Time(), ref.Node(), ref.Step())
+		}
+	}
+}
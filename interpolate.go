@@ -0,0 +1,62 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// interpolateResync 插值超过该时长后重新读取系统时间
+	interpolateResync = time.Second
+	// interpolateSlew 重新锚定时系统时间落后插值不超过该值视为读数粒度误差或计数器偏快，
+	// 保持连续并逐渐追回；超过则认为系统时间被调整，以系统时间为准
+	interpolateSlew = 50 * time.Millisecond
+	// interpolateSlewRate 追回时插值的流逝时间按 1/interpolateSlewRate 放慢，每秒最多追回 100ms
+	interpolateSlewRate = 10
+)
+
+// interpolatedClock 以低精度的系统时间为锚点，叠加高精度计数器的流逝时间
+type interpolatedClock struct {
+	counter func() int64 // 高精度计数器
+	freq    int64        // 计数器每秒的计数
+	wall    func() int64 // 系统时间(纳秒)
+	anchor  atomic.Pointer[clockAnchor]
+}
+
+type clockAnchor struct {
+	counter int64
+	wall    int64
+	ahead   int64 // 锚点领先系统时间的纳秒数，在之后的插值中逐渐扣除
+}
+
+// at 锚点之后 elapsed 纳秒的插值，扣除已追回的领先量，逐段单调
+func (a *clockAnchor) at(elapsed int64) int64 {
+	return a.wall + elapsed - min(elapsed/interpolateSlewRate, a.ahead)
+}
+
+func newInterpolatedClock(counter func() int64, freq int64, wall func() int64) *interpolatedClock {
+	c := &interpolatedClock{counter: counter, freq: freq, wall: wall}
+	c.anchor.Store(&clockAnchor{counter: counter(), wall: wall()})
+	return c
+}
+
+// nanos 返回插值后的纳秒时间戳
+func (c *interpolatedClock) nanos() int64 {
+	now := c.counter()
+	a := c.anchor.Load()
+	ticks := now - a.counter
+	// 分开计算整秒和余数，避免乘法溢出
+	elapsed := ticks/c.freq*1e9 + ticks%c.freq*1e9/c.freq
+	if elapsed >= 0 && elapsed < int64(interpolateResync) {
+		return a.at(elapsed)
+	}
+	next := &clockAnchor{counter: now, wall: c.wall()}
+	if interpolated := a.at(elapsed); elapsed >= 0 && next.wall < interpolated && interpolated-next.wall < int64(interpolateSlew) {
+		// 不回退，在下一段插值中追回领先量
+		next.wall, next.ahead = interpolated, interpolated-next.wall
+	}
+	c.anchor.CompareAndSwap(a, next)
+	return next.wall
+}
@@ -0,0 +1,70 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_interpolatedClock(t *testing.T) {
+	const freq = 10_000_000 // 100ns 计数，与常见 QPC 频率一致
+	var counter int64
+	// 系统时间只按 15.6ms 粒度前进
+	wall := func() int64 {
+		ns := counter * 100
+		return ns - ns%int64(15600*time.Microsecond)
+	}
+	c := newInterpolatedClock(func() int64 { return counter }, freq, wall)
+
+	var last int64
+	seen := map[int64]bool{}
+	for i := 0; i < 3000; i++ {
+		counter += 10_000 // 1ms
+		ns := c.nanos()
+		if ns < last {
+			t.Fatalf("clock went backwards at step %d: %d < %d", i, ns, last)
+		}
+		last = ns
+		seen[ns/1e6] = true
+	}
+	// 3 秒内每毫秒都应可见，而不是每 15.6ms 跳一次
+	if len(seen) < 2900 {
+		t.Errorf("saw %d distinct milliseconds, want ~3000", len(seen))
+	}
+	if drift := wall() - last; drift > int64(16*time.Millisecond) || drift < -int64(16*time.Millisecond) {
+		t.Errorf("drift from wall clock %v", time.Duration(drift))
+	}
+}
+
+func Test_interpolatedClockStep(t *testing.T) {
+	var counter, offset int64
+	wall := func() int64 { return counter*100 + offset }
+	c := newInterpolatedClock(func() int64 { return counter }, 10_000_000, wall)
+
+	// 系统时间被向后调整 1 小时，重新锚定后应跟随
+	offset = int64(-time.Hour)
+	counter += 2 * 10_000_000
+	if got := c.nanos(); got != wall() {
+		t.Errorf("after step got %d, want wall %d", got, wall())
+	}
+}
+
+func Test_interpolatedClockDrift(t *testing.T) {
+	// 计数器比系统时间快 1%，每次重新锚定都领先约 10ms
+	var counter int64
+	wall := func() int64 { return counter * 100 * 100 / 101 }
+	c := newInterpolatedClock(func() int64 { return counter }, 10_000_000, wall)
+	var last int64
+	for i := 0; i < 30_000; i++ {
+		counter += 10_000 // 1ms
+		ns := c.nanos()
+		if ns < last {
+			t.Fatalf("clock went backwards at step %d by %v", i, time.Duration(last-ns))
+		}
+		last = ns
+		if drift := ns - wall(); drift > int64(20*time.Millisecond) || drift < 0 {
+			t.Fatalf("drift from wall clock %v at step %d", time.Duration(drift), i)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package snowflake
+
+import "fmt"
+
+// InvariantError returned instead of an id that would be zero or negative
+type InvariantError struct {
+	ID     int64
+	Reason string
+}
+
+func (e *InvariantError) Error() string {
+	return fmt.Sprintf("snowflake: refusing to return invalid id %d: %s", e.ID, e.Reason)
+}
+
+// IsValid report whether id could have been generated with the layout of DefaultWorker:
+// positive and no bits set above the layout
+func IsValid(id int64) bool {
+	return defaultLayout().isValid(uint64(id))
+}
+
+func (l Layout) isValid(id uint64) bool {
+	if !l.Unsigned && int64(id) <= 0 {
+		return false
+	}
+	return id != 0 && id>>l.bits() == 0 && l.version(id) == l.Version
+}
+
+// IsValid report whether id could have been generated with the layout:
+// positive (unless unsigned), no bits set above the layout and the layout's version
+func (l Layout) IsValid(id int64) bool {
+	return l.isValid(uint64(id))
+}
@@ -0,0 +1,51 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func Test_IsValid(t *testing.T) {
+	id, err := Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsValid(id) {
+		t.Errorf("IsValid(%d) = false", id)
+	}
+	for _, id := range []int64{0, -1, math.MinInt64} {
+		if IsValid(id) {
+			t.Errorf("IsValid(%d) = true", id)
+		}
+	}
+	small := Layout{Epoch: time.UnixMilli(twepoch), TimestampBits: 30, SequenceBits: 10}
+	if small.isValid(1 << 40) {
+		t.Error("bits above the layout should be invalid")
+	}
+}
+
+func Test_NextInvariants(t *testing.T) {
+	future := Layout{Epoch: time.Now().Add(time.Hour), TimestampBits: 41, SequenceBits: 12}
+	w, err := newWorker(0, 0, WithLayout(future))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ie *InvariantError
+	if _, err := w.Next(); !errors.As(err, &ie) {
+		t.Errorf("clock before epoch: got %v, want InvariantError", err)
+	}
+
+	w, err = newWorker(0, 0, WithLayout(Layout{Epoch: time.Now(), TimestampBits: 41, SequenceBits: 12}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	frozen := w.epoch
+	w.now = func() int64 { return frozen }
+	if _, err := w.Next(); !errors.As(err, &ie) || ie.ID != 0 {
+		t.Errorf("zero id: got %v, want InvariantError", err)
+	}
+}
@@ -0,0 +1,86 @@
+package snowflake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Assignment a datacenter/worker id pair held by one host or pod for a time window, as
+// recorded by the allocator that handed it out
+type Assignment struct {
+	DatacenterID int64             `json:"datacenter"`
+	WorkerID     int64             `json:"worker"`
+	Host         string            `json:"host"`
+	Pod          string            `json:"pod,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"` // e.g. region, image, deploy
+	From         time.Time         `json:"from,omitzero"`      // zero for since the epoch
+	To           time.Time         `json:"to,omitzero"`        // zero for still held
+}
+
+// covers t 是否在窗口 [From, To) 内
+func (a Assignment) covers(t time.Time) bool {
+	return !t.Before(a.From) && (a.To.IsZero() || t.Before(a.To))
+}
+
+// Inventory fleet inventory mapping the ids of a layout to the machines that held them, so an
+// id can be traced to the host that issued it
+type Inventory struct {
+	layout Layout
+	nodes  map[[2]int64][]Assignment // 按 From 排序
+}
+
+// NewInventory return an inventory of assignments for ids of layout l; assignments of the
+// same ids must not overlap in time, as a worker id is only reused once released
+func NewInventory(l Layout, assignments []Assignment) (*Inventory, error) {
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+	inv := &Inventory{layout: l, nodes: map[[2]int64][]Assignment{}}
+	for _, a := range assignments {
+		if a.DatacenterID < 0 || a.DatacenterID > l.maxDatacenterID() || a.WorkerID < 0 || a.WorkerID > l.maxWorkerID() {
+			return nil, fmt.Errorf("host %q: datacenter %d worker %d outside the layout", a.Host, a.DatacenterID, a.WorkerID)
+		}
+		if !a.To.IsZero() && !a.To.After(a.From) {
+			return nil, fmt.Errorf("host %q: assignment ends before it starts", a.Host)
+		}
+		key := [2]int64{a.DatacenterID, a.WorkerID}
+		inv.nodes[key] = append(inv.nodes[key], a)
+	}
+	for key, list := range inv.nodes {
+		sort.Slice(list, func(i, j int) bool { return list[i].From.Before(list[j].From) })
+		for i := 1; i < len(list); i++ {
+			if prev := list[i-1]; prev.To.IsZero() || prev.To.After(list[i].From) {
+				return nil, fmt.Errorf("datacenter %d worker %d: assignments of %q and %q overlap", key[0], key[1], prev.Host, list[i].Host)
+			}
+		}
+	}
+	return inv, nil
+}
+
+// LoadInventory read a JSON array of assignments, e.g. exported from the allocator backend:
+// [{"datacenter": 1, "worker": 3, "host": "web-7", "pod": "api-5d9f", "from": "2024-03-01T00:00:00Z"}]
+func LoadInventory(r io.Reader, l Layout) (*Inventory, error) {
+	var assignments []Assignment
+	if err := json.NewDecoder(r).Decode(&assignments); err != nil {
+		return nil, fmt.Errorf("invalid inventory: %v", err)
+	}
+	return NewInventory(l, assignments)
+}
+
+// WhoIssued return the assignment holding id's datacenter and worker ids at id's timestamp,
+// false when id is invalid for the layout or no machine held them then
+func (inv *Inventory) WhoIssued(id ID) (Assignment, bool) {
+	if !inv.layout.isValid(uint64(id)) {
+		return Assignment{}, false
+	}
+	p := inv.layout.Decompose(id)
+	for _, a := range inv.nodes[[2]int64{p.DatacenterID, p.WorkerID}] {
+		if a.covers(p.Time) {
+			return a, true
+		}
+	}
+	return Assignment{}, false
+}
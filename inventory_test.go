@@ -0,0 +1,45 @@
+package snowflake
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Inventory(t *testing.T) {
+	l := DefaultLayout
+	cutover := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	inv, err := LoadInventory(strings.NewReader(`[
+		{"datacenter": 1, "worker": 3, "host": "web-1", "to": "2024-03-01T00:00:00Z"},
+		{"datacenter": 1, "worker": 3, "host": "web-7", "pod": "api-5d9f", "metadata": {"region": "eu"}, "from": "2024-03-01T00:00:00Z"}
+	]`), l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := func(at time.Time, datacenter, worker int64) ID {
+		return ID(l.compose(at.UnixMilli()-l.Epoch.UnixMilli(), datacenter, worker, 0))
+	}
+	if a, ok := inv.WhoIssued(id(cutover.Add(-time.Millisecond), 1, 3)); !ok || a.Host != "web-1" {
+		t.Errorf("before cutover = %+v, %v", a, ok)
+	}
+	if a, ok := inv.WhoIssued(id(cutover, 1, 3)); !ok || a.Host != "web-7" || a.Pod != "api-5d9f" || a.Metadata["region"] != "eu" {
+		t.Errorf("after cutover = %+v, %v", a, ok)
+	}
+	if _, ok := inv.WhoIssued(id(cutover, 1, 4)); ok {
+		t.Error("unassigned worker resolved")
+	}
+	if _, ok := inv.WhoIssued(-1); ok {
+		t.Error("invalid id resolved")
+	}
+
+	for _, bad := range []string{
+		`[{"datacenter": 1, "worker": 3, "host": "a"}, {"datacenter": 1, "worker": 3, "host": "b", "from": "2024-03-01T00:00:00Z"}]`,
+		`[{"datacenter": 32, "worker": 0, "host": "a"}]`,
+		`[{"datacenter": 0, "worker": 0, "host": "a", "from": "2024-03-01T00:00:00Z", "to": "2024-02-01T00:00:00Z"}]`,
+		`{}`,
+	} {
+		if _, err := LoadInventory(strings.NewReader(bad), l); err == nil {
+			t.Errorf("LoadInventory(%s) accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package snowflake
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// KeySize length of the big-endian keys built by ID.Key
+const KeySize = 8
+
+// Key return id as an 8-byte big-endian key, which sorts bytewise in id (and so creation
+// time) order, for KV stores such as BadgerDB and Bolt that order keys as byte strings
+func (id ID) Key() []byte {
+	return id.AppendKey(make([]byte, 0, KeySize))
+}
+
+// AppendKey append the big-endian key of id to dst, for keys behind a table or tenant prefix
+func (id ID) AppendKey(dst []byte) []byte {
+	return binary.BigEndian.AppendUint64(dst, uint64(id))
+}
+
+// ParseKey return the id of an 8-byte key built by ID.Key
+func ParseKey(key []byte) (ID, error) {
+	if len(key) != KeySize {
+		return 0, fmt.Errorf("key length %d, want %d", len(key), KeySize)
+	}
+	return ID(binary.BigEndian.Uint64(key)), nil
+}
+
+// KeyRange half-open byte key interval [Start, End), iterate it with Seek(Start) while
+// bytes.Compare(key, End) < 0
+type KeyRange struct {
+	Start []byte // 包含
+	End   []byte // 不包含
+}
+
+// Contains report whether key is in the range
+func (r KeyRange) Contains(key []byte) bool {
+	return bytes.Compare(key, r.Start) >= 0 && bytes.Compare(key, r.End) < 0
+}
+
+// WithPrefix return the range of keys built by ID.AppendKey behind prefix
+func (r KeyRange) WithPrefix(prefix []byte) KeyRange {
+	return KeyRange{
+		Start: append(bytes.Clone(prefix), r.Start...),
+		End:   append(bytes.Clone(prefix), r.End...),
+	}
+}
+
+// KeyRangeForWindow return the keys of the ids issued in [from, to), see Layout.Range
+func (l Layout) KeyRangeForWindow(from, to time.Time) KeyRange {
+	r := l.Range(from, to)
+	return KeyRange{Start: r.From.Key(), End: r.To.Key()}
+}
+
+// KeyRangeForWindow return the keys of DefaultWorker's layout issued in [from, to)
+func KeyRangeForWindow(from, to time.Time) KeyRange {
+	return defaultLayout().KeyRangeForWindow(from, to)
+}
+
+// PrefixForTime return the longest byte prefix shared by the keys of every id issued in t's
+// millisecond, for prefix scans. The timestamp rarely ends on a byte boundary, so the prefix
+// also matches neighbouring milliseconds (4 with DefaultLayout); filter with Range or use
+// KeyRangeForWindow for exact bounds
+func (l Layout) PrefixForTime(t time.Time) []byte {
+	// 时间戳以上的位在这一毫秒内不变，取其中的整字节
+	n := (64 - int(l.timestampShift())) / 8
+	return l.FirstID(time.UnixMilli(t.UnixMilli())).Key()[:n:n]
+}
+
+// PrefixForTime return the key prefix of t's millisecond in DefaultWorker's layout
+func PrefixForTime(t time.Time) []byte {
+	return defaultLayout().PrefixForTime(t)
+}
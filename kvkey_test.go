@@ -0,0 +1,70 @@
+package snowflake
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func Test_Key(t *testing.T) {
+	ids := []ID{1, 255, 256, 1 << 40, 1<<63 - 1}
+	for i, id := range ids {
+		key := id.Key()
+		if len(key) != KeySize {
+			t.Fatalf("Key(%d) = %x", id, key)
+		}
+		if got, err := ParseKey(key); err != nil || got != id {
+			t.Errorf("ParseKey(%x) = %d, %v", key, got, err)
+		}
+		if i > 0 && bytes.Compare(ids[i-1].Key(), key) >= 0 {
+			t.Errorf("key of %d does not sort after %d", id, ids[i-1])
+		}
+	}
+	if got := ID(0x0102).AppendKey([]byte("t/")); string(got) != "t/\x00\x00\x00\x00\x00\x00\x01\x02" {
+		t.Errorf("AppendKey = %q", got)
+	}
+	if _, err := ParseKey([]byte{1, 2}); err == nil {
+		t.Error("short key should fail")
+	}
+}
+
+func Test_KeyRangeForWindow(t *testing.T) {
+	l := DefaultLayout
+	from := l.Epoch.Add(time.Hour)
+	to := from.Add(time.Minute)
+	r := l.KeyRangeForWindow(from, to)
+	inside := ID(l.compose(from.Sub(l.Epoch).Milliseconds()+5, 3, 4, 5))
+	if !r.Contains(inside.Key()) {
+		t.Errorf("%x..%x does not contain %x", r.Start, r.End, inside.Key())
+	}
+	if r.Contains(l.FirstID(to).Key()) || r.Contains((l.FirstID(from) - 1).Key()) {
+		t.Errorf("%x..%x contains keys outside the window", r.Start, r.End)
+	}
+
+	p := r.WithPrefix([]byte("orders/"))
+	if !p.Contains(inside.AppendKey([]byte("orders/"))) || p.Contains(inside.AppendKey([]byte("users/"))) {
+		t.Errorf("prefixed range %q..%q", p.Start, p.End)
+	}
+	if !bytes.Equal(r.Start, l.FirstID(from).Key()) {
+		t.Error("WithPrefix modified the range")
+	}
+}
+
+func Test_PrefixForTime(t *testing.T) {
+	l := DefaultLayout
+	at := l.Epoch.Add(time.Hour + 123*time.Millisecond + 456*time.Microsecond)
+	prefix := l.PrefixForTime(at)
+	// 默认布局时间戳以上 42 位，取其中 5 个整字节
+	if len(prefix) != 5 {
+		t.Fatalf("prefix %x, want 5 bytes", prefix)
+	}
+	ms := at.Sub(l.Epoch).Milliseconds()
+	for _, id := range []uint64{l.compose(ms, 0, 0, 0), l.compose(ms, 31, 31, 4095)} {
+		if !bytes.HasPrefix(ID(id).Key(), prefix) {
+			t.Errorf("key %x lacks prefix %x", ID(id).Key(), prefix)
+		}
+	}
+	if bytes.HasPrefix(ID(l.compose(ms+4, 0, 0, 0)).Key(), prefix) {
+		t.Errorf("prefix %x covers more than 4 milliseconds", prefix)
+	}
+}
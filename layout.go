@@ -0,0 +1,194 @@
+package snowflake
+
+import (
+	"fmt"
+	"time"
+)
+
+// Layout bit allocation of an id, from high to low: version, timestamp, datacenter id, worker id, sequence
+type Layout struct {
+	Epoch          time.Time // 时间戳起点
+	TimestampBits  uint8     // 时间戳(毫秒)所占位数
+	DatacenterBits uint8     // 数据标识id所占位数
+	WorkerBits     uint8     // 机器id所占位数
+	SequenceBits   uint8     // 序列所占位数
+
+	// VersionBits 时间戳之上保留的方案版本位数，Version 为本布局的版本号。更换 epoch 等
+	// 布局演进时新旧布局使用不同版本，id 仍可区分，见 Schemes
+	VersionBits uint8
+	Version     uint8
+
+	// Unsigned 不保留最高的符号位，全部 64 位可用，例如 42 位时间戳 + 10 位机器 + 12 位序列。
+	// 生成的 id 只能通过 NextUint64 获取；最高位为 1 的 id 在 Java long、有符号数据库列、
+	// JSON number 等只支持有符号 64 位整数的环境中会变成负数或丢失精度，跨语言使用前需确认
+	Unsigned bool
+
+	// TombstoneBit 保留序列字段的最低位作为墓碑标记，可用序列减半。签发的 id 该位为 0，
+	// Tombstone 将其置 1 得到紧随原 id 排序的删除标记，见 IsTombstone
+	TombstoneBit bool
+}
+
+// DefaultLayout 41 位时间戳 + 5 位数据标识 + 5 位机器 + 12 位序列，起始时间 2019-01-01
+var DefaultLayout = Layout{
+	Epoch:          time.UnixMilli(twepoch),
+	TimestampBits:  41,
+	DatacenterBits: datacenterIDBits,
+	WorkerBits:     workerIDBits,
+	SequenceBits:   sequenceBits,
+}
+
+// LiteLayout 48 位 unix 毫秒时间戳 + 16 位随机序列，即常见的 "48ms + 16 random" 方案，
+// 无需分配机器 id；与 ULID 的前 64 位结构相同，按时间排序，可用 Decompose 解析，
+// 序列字段即熵。最高位在 6429 年后才为 1，见 NewLiteGenerator
+var LiteLayout = Layout{
+	Epoch:         time.UnixMilli(0),
+	TimestampBits: 48,
+	SequenceBits:  16,
+	Unsigned:      true,
+}
+
+// Validate check the layout fits into 63 bits, or 64 bits for unsigned layouts
+func (l Layout) Validate() error {
+	if l.Epoch.IsZero() {
+		return fmt.Errorf("layout epoch is required")
+	}
+	if l.TimestampBits == 0 {
+		return fmt.Errorf("layout timestamp bits must be greater than 0")
+	}
+	if l.TimestampBits > 63 {
+		// 距 epoch 的毫秒数以 int64 表示
+		return fmt.Errorf("layout timestamp bits must be at most 63")
+	}
+	limit := 63
+	if l.Unsigned {
+		limit = 64
+	}
+	if l.VersionBits > 8 || uint16(l.Version)>>l.VersionBits != 0 {
+		return fmt.Errorf("layout version %d does not fit into %d version bits", l.Version, l.VersionBits)
+	}
+	if l.TombstoneBit && l.SequenceBits == 0 {
+		return fmt.Errorf("layout tombstone bit requires sequence bits")
+	}
+	if total := l.bits(); total > limit {
+		return fmt.Errorf("layout uses %d bits, at most %d allowed", total, limit)
+	}
+	return nil
+}
+
+// MaySetSignBit report whether ids of the layout can have the top bit set, making them
+// negative as Java long or signed BIGINT; only unsigned layouts using all 64 bits can
+func (l Layout) MaySetSignBit() bool {
+	return l.bits() == 64
+}
+
+// bits 总位数
+func (l Layout) bits() int {
+	return int(l.VersionBits) + int(l.TimestampBits) + int(l.DatacenterBits) + int(l.WorkerBits) + int(l.SequenceBits)
+}
+
+// timestampShift 时间戳字段的起始位
+func (l Layout) timestampShift() uint8 {
+	return l.SequenceBits + l.WorkerBits + l.DatacenterBits
+}
+
+// versionShift 版本字段的起始位
+func (l Layout) versionShift() uint8 {
+	return l.timestampShift() + l.TimestampBits
+}
+
+func (l Layout) maxVersion() int64 {
+	return -1 ^ (-1 << l.VersionBits)
+}
+
+func (l Layout) maxWorkerID() int64 {
+	return -1 ^ (-1 << l.WorkerBits)
+}
+
+func (l Layout) maxDatacenterID() int64 {
+	return -1 ^ (-1 << l.DatacenterBits)
+}
+
+// tombstoneBits 序列字段中墓碑位的位数，0 或 1
+func (l Layout) tombstoneBits() uint8 {
+	if l.TombstoneBit {
+		return 1
+	}
+	return 0
+}
+
+// sequenceBits 可用于序列的位数，不含墓碑位
+func (l Layout) sequenceBits() uint8 {
+	return l.SequenceBits - l.tombstoneBits()
+}
+
+// sequenceMask 最大的序列值，不含墓碑位
+func (l Layout) sequenceMask() int64 {
+	return -1 ^ (-1 << l.sequenceBits())
+}
+
+func (l Layout) maxElapsed() int64 {
+	return int64(uint64(1)<<l.TimestampBits - 1)
+}
+
+// compose 按布局拼接各部分，elapsed 为距 Epoch 的毫秒数；版本用加法，
+// 使 FirstID 中越界的 elapsed 进位到下一个版本
+func (l Layout) compose(elapsed, datacenterID, workerID, sequence int64) uint64 {
+	return uint64(l.Version)<<l.versionShift() + uint64(elapsed)<<l.timestampShift() |
+		uint64(datacenterID)<<(l.SequenceBits+l.WorkerBits) |
+		uint64(workerID)<<l.SequenceBits |
+		uint64(sequence)<<l.tombstoneBits()
+}
+
+// decompose compose 的逆运算
+func (l Layout) decompose(id uint64) (elapsed, datacenterID, workerID, sequence int64) {
+	sequence = int64(id>>l.tombstoneBits()) & l.sequenceMask()
+	workerID = int64(id>>l.SequenceBits) & l.maxWorkerID()
+	datacenterID = int64(id>>(l.SequenceBits+l.WorkerBits)) & l.maxDatacenterID()
+	elapsed = l.elapsed(id)
+	return
+}
+
+// elapsed 读取 id 的时间戳字段，即距 epoch 的毫秒数
+func (l Layout) elapsed(id uint64) int64 {
+	return int64(id>>l.timestampShift()) & l.maxElapsed()
+}
+
+// version 读取 id 的版本位
+func (l Layout) version(id uint64) uint8 {
+	if l.VersionBits == 0 {
+		return 0
+	}
+	return uint8(id >> l.versionShift() & uint64(l.maxVersion()))
+}
+
+// Parts decomposed fields of an id
+type Parts struct {
+	Time         time.Time
+	DatacenterID int64
+	WorkerID     int64
+	Sequence     int64
+	Version      uint8  // 版本位，布局没有版本位时为 0
+	Tombstone    bool   // 墓碑标记，见 Layout.Tombstone
+	Region       string // DatacenterID 在 Regions 中登记的名称，仅由包级 Decompose 填充
+}
+
+// Decompose split id into its fields according to the layout
+func (l Layout) Decompose(id ID) Parts {
+	elapsed, datacenterID, workerID, sequence := l.decompose(uint64(id))
+	return Parts{
+		Time:         time.UnixMilli(l.Epoch.UnixMilli() + elapsed),
+		DatacenterID: datacenterID,
+		WorkerID:     workerID,
+		Sequence:     sequence,
+		Version:      l.version(uint64(id)),
+		Tombstone:    l.IsTombstone(id),
+	}
+}
+
+// Decompose split id into its fields according to the layout of DefaultWorker,
+// naming the region from Regions
+func Decompose(id ID) Parts {
+	p := defaultLayout().Decompose(id)
+	p.Region, _ = Regions.Name(p.DatacenterID)
+	return p
+}
@@ -0,0 +1,83 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_DefaultLayout(t *testing.T) {
+	l := DefaultLayout
+	if err := l.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if l.maxWorkerID() != maxWorkerID || l.maxDatacenterID() != maxDatacenterID || l.sequenceMask() != sequenceMask {
+		t.Errorf("DefaultLayout does not match default constants: %+v", l)
+	}
+	if got := l.compose(1, 1, 1, 1); got != 1<<timestampLeftShift|1<<datacenterIDShift|1<<workerIDShift|1 {
+		t.Errorf("compose = %b", got)
+	}
+}
+
+func Test_LayoutValidate(t *testing.T) {
+	epoch := time.UnixMilli(twepoch)
+	tests := []struct {
+		layout Layout
+		ok     bool
+	}{
+		{Layout{Epoch: epoch, TimestampBits: 42, WorkerBits: 9, SequenceBits: 12}, true},
+		{Layout{Epoch: epoch, TimestampBits: 42, WorkerBits: 10, SequenceBits: 12}, false},
+		{Layout{Epoch: epoch, TimestampBits: 42, WorkerBits: 10, SequenceBits: 12, Unsigned: true}, true},
+		{Layout{Epoch: epoch, TimestampBits: 43, WorkerBits: 10, SequenceBits: 12, Unsigned: true}, false},
+		{Layout{Epoch: epoch, WorkerBits: 10, SequenceBits: 12}, false},
+		{Layout{TimestampBits: 41, WorkerBits: 10, SequenceBits: 12}, false},
+		{Layout{Epoch: epoch, TimestampBits: 63, Unsigned: true}, true},
+		{Layout{Epoch: epoch, TimestampBits: 64, Unsigned: true}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.layout.Validate(); (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v) = %v, want ok %v", tt.layout, err, tt.ok)
+		}
+	}
+}
+
+func Test_NextUint64(t *testing.T) {
+	l := Layout{Epoch: time.UnixMilli(twepoch), TimestampBits: 42, WorkerBits: 10, SequenceBits: 12, Unsigned: true}
+	w, err := newWorker(3, 0, WithLayout(l))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Next(); err == nil {
+		t.Error("Next on unsigned layout expected error")
+	}
+	var last uint64
+	for i := 0; i < 10000; i++ {
+		id, err := w.NextUint64()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= last {
+			t.Fatalf("id %d not greater than previous %d", id, last)
+		}
+		if worker := id >> 12 & (1<<10 - 1); worker != 3 {
+			t.Fatalf("worker bits = %d, want 3", worker)
+		}
+		last = id
+	}
+
+	if _, err := newWorker(0, 1, WithLayout(l)); err == nil {
+		t.Error("datacenter id outside layout expected error")
+	}
+}
+
+func Test_NextTimestampOverflow(t *testing.T) {
+	l := Layout{Epoch: time.UnixMilli(twepoch), TimestampBits: 20, SequenceBits: 12}
+	w, err := newWorker(0, 0, WithLayout(l))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Next(); err == nil {
+		t.Error("expected timestamp overflow error")
+	}
+}
@@ -0,0 +1,108 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrLeaseLost returned once the worker can no longer prove it owns its worker id
+var ErrLeaseLost = errors.New("snowflake: worker id lease lost")
+
+// LeaseChecker confirm this process still holds the lease on its worker/datacenter id,
+// implemented on top of the external allocator that handed the id out
+type LeaseChecker interface {
+	CheckLease(ctx context.Context) error
+}
+
+// LeaseCheckerFunc adapt a function to LeaseChecker
+type LeaseCheckerFunc func(ctx context.Context) error
+
+// CheckLease call f
+func (f LeaseCheckerFunc) CheckLease(ctx context.Context) error {
+	return f(ctx)
+}
+
+// WithLeaseCheck fence the worker with its allocator lease: c is checked on creation and
+// then every interval in the background, and ids are only issued within ttl of the last
+// successful check (real elapsed time, whatever clock the worker reads ids from), so a worker whose id was reassigned (or that is partitioned from the
+// allocator) stops with ErrLeaseLost instead of issuing conflicting ids. ttl must be
+// shorter than the allocator's lease expiry; interval and ttl must be positive.
+func WithLeaseCheck(c LeaseChecker, interval, ttl time.Duration) Option {
+	return func(w *worker) {
+		w.lease = &lease{checker: c, interval: interval, ttl: ttl, base: time.Now(), stop: make(chan struct{})}
+	}
+}
+
+type lease struct {
+	checker    LeaseChecker
+	interval   time.Duration
+	ttl        time.Duration
+	base       time.Time    // 单调时钟的起点，租约期限与 worker 的时钟（WithClock 等）无关
+	validUntil atomic.Int64 // base 之后的纳秒数，超过后拒绝签发
+	stop       chan struct{}
+}
+
+// check 执行一次租约校验，成功则从校验开始时刻起续期 ttl
+func (l *lease) check() error {
+	start := time.Since(l.base)
+	ctx, cancel := context.WithTimeout(context.Background(), l.interval)
+	defer cancel()
+	if err := l.checker.CheckLease(ctx); err != nil {
+		return err
+	}
+	l.validUntil.Store(int64(start + l.ttl))
+	return nil
+}
+
+// valid 租约是否仍在有效期内
+func (l *lease) valid() bool {
+	return int64(time.Since(l.base)) <= l.validUntil.Load()
+}
+
+// validate 检查校验间隔与有效期，非正值会让后台 ticker panic
+func (l *lease) validate() error {
+	if l.interval <= 0 || l.ttl <= 0 {
+		return fmt.Errorf("lease check interval %v and ttl %v must be positive", l.interval, l.ttl)
+	}
+	return nil
+}
+
+// startLease 同步校验一次后启动后台续期
+func (w *worker) startLease() error {
+	if err := w.lease.check(); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(w.lease.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.lease.stop:
+				return
+			case <-ticker.C:
+				if err := w.lease.check(); err != nil {
+					w.notify(func() {
+						w.log(LevelError, "snowflake: lease check failed", "error", err)
+						w.emit(EventLeaseLost, 0)
+					})
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (w *worker) stopLease() {
+	if w.lease != nil {
+		select {
+		case <-w.lease.stop:
+		default:
+			close(w.lease.stop)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_LeaseCheck(t *testing.T) {
+	var owned atomic.Bool
+	owned.Store(true)
+	checker := LeaseCheckerFunc(func(ctx context.Context) error {
+		if !owned.Load() {
+			return errors.New("lease reassigned")
+		}
+		return nil
+	})
+	lost := make(chan Event, 10)
+	g, err := NewGenerator(1, 1, WithLeaseCheck(checker, 10*time.Millisecond, 30*time.Millisecond),
+		WithEventHandler(func(e Event) { lost <- e }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	if _, err := g.Next(); err != nil {
+		t.Fatal(err)
+	}
+
+	owned.Store(false)
+	select {
+	case e := <-lost:
+		if e.Type != EventLeaseLost {
+			t.Errorf("event = %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no lease lost event")
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, err := g.Next()
+		if err == ErrLeaseLost {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Next kept issuing after the lease was lost: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	owned.Store(true)
+	deadline = time.Now().Add(time.Second)
+	for {
+		if _, err := g.Next(); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Next did not recover after the lease was renewed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	other, err := NewGenerator(2, 1, WithLeaseCheck(checker, time.Second, time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Close()
+	owned.Store(false)
+	if _, err := NewGenerator(3, 1, WithLeaseCheck(checker, time.Second, time.Second)); err == nil {
+		t.Error("expected creation to fail without a lease")
+	}
+}
+
+func Test_LeaseCheckCustomClock(t *testing.T) {
+	// 租约期限按真实经过的时间计算，与 worker 的时钟无关
+	checker := LeaseCheckerFunc(func(ctx context.Context) error { return nil })
+	past := func() time.Time { return DefaultLayout.Epoch.Add(time.Hour) }
+	g, err := NewGenerator(1, 1, WithClock(past), WithLeaseCheck(checker, time.Hour, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	if _, err := g.Next(); err != nil {
+		t.Errorf("Next with a clock behind wall time = %v", err)
+	}
+	future := func() time.Time { return time.Now().Add(24 * time.Hour) }
+	g, err = NewGenerator(2, 1, WithClock(future), WithLeaseCheck(checker, time.Hour, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	if _, err := g.Next(); err != nil {
+		t.Errorf("Next with a clock ahead of wall time = %v", err)
+	}
+}
+
+func Test_LeaseCheckInvalid(t *testing.T) {
+	checker := LeaseCheckerFunc(func(ctx context.Context) error { return nil })
+	for _, c := range []struct{ interval, ttl time.Duration }{{0, time.Second}, {-time.Second, time.Second}, {time.Second, 0}, {time.Second, -time.Second}} {
+		if g, err := NewGenerator(1, 1, WithLeaseCheck(checker, c.interval, c.ttl)); err == nil {
+			g.Close()
+			t.Errorf("interval %v, ttl %v accepted", c.interval, c.ttl)
+		}
+	}
+}
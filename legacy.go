@@ -0,0 +1,91 @@
+package snowflake
+
+import (
+	"fmt"
+	"time"
+)
+
+// LegacyMapper deterministically maps legacy auto-increment ids plus their creation time
+// into snowflakes issued from a reserved range of worker ids, to ease cutovers from
+// auto-increment keys. Live workers must not use the reserved worker ids.
+//
+// Map is stateless and cannot detect collisions: two legacy ids congruent modulo Modulus
+// and created in the same millisecond get the same snowflake, without an error. Only use it
+// when fewer than Modulus consecutive legacy ids share a millisecond (reserve more workers
+// otherwise), or check the mapped ids for duplicates before writing them.
+type LegacyMapper struct {
+	layout        Layout
+	epoch         int64
+	datacenterID  int64
+	firstWorkerID int64
+	modulus       int64
+}
+
+// LegacyRef reverse lookup key of a migrated id: the legacy id satisfies
+// legacyID % Modulus == Residue and was created at CreatedAt (millisecond precision)
+type LegacyRef struct {
+	Residue   int64
+	Modulus   int64
+	CreatedAt time.Time
+}
+
+// NewLegacyMapper reserve worker ids [firstWorkerID, firstWorkerID+workers) of datacenterID
+// for migrated ids
+func NewLegacyMapper(layout Layout, datacenterID, firstWorkerID, workers uint8) (*LegacyMapper, error) {
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+	if int64(datacenterID) > layout.maxDatacenterID() {
+		return nil, fmt.Errorf("datacenter Id can't be greater than %d or less than 0", layout.maxDatacenterID())
+	}
+	if workers == 0 || int64(firstWorkerID)+int64(workers)-1 > layout.maxWorkerID() {
+		return nil, fmt.Errorf("reserved worker ids must be within 0..%d", layout.maxWorkerID())
+	}
+	return &LegacyMapper{
+		layout:        layout,
+		epoch:         layout.Epoch.UnixMilli(),
+		datacenterID:  int64(datacenterID),
+		firstWorkerID: int64(firstWorkerID),
+		modulus:       int64(workers) * (layout.sequenceMask() + 1),
+	}, nil
+}
+
+// Modulus two legacy ids created in the same millisecond collide when they are congruent modulo Modulus
+func (m *LegacyMapper) Modulus() int64 {
+	return m.modulus
+}
+
+// Map return the snowflake of legacy id created at createdAt; ids congruent modulo Modulus
+// created in the same millisecond collide, see LegacyMapper
+func (m *LegacyMapper) Map(legacyID int64, createdAt time.Time) (ID, error) {
+	if legacyID < 0 {
+		return 0, fmt.Errorf("legacy id %d is negative", legacyID)
+	}
+	elapsed := createdAt.UnixMilli() - m.epoch
+	if elapsed < 0 || elapsed > m.layout.maxElapsed() {
+		return 0, fmt.Errorf("created at %v is outside the layout time range", createdAt)
+	}
+	// 每个 worker 容纳的序列数不含墓碑位
+	slot := legacyID % m.modulus
+	sequences := m.layout.sequenceMask() + 1
+	worker := m.firstWorkerID + slot/sequences
+	id := m.layout.compose(elapsed, m.datacenterID, worker, slot%sequences)
+	if int64(id) <= 0 {
+		return 0, &InvariantError{ID: int64(id), Reason: "migrated id is not positive"}
+	}
+	return ID(id), nil
+}
+
+// Unmap return the reverse lookup key of id, ok is false if id was not produced by Map
+func (m *LegacyMapper) Unmap(id ID) (ref LegacyRef, ok bool) {
+	elapsed, datacenter, worker, sequence := m.layout.decompose(uint64(id))
+	slot := (worker - m.firstWorkerID) * (m.layout.sequenceMask() + 1)
+	if id <= 0 || datacenter != m.datacenterID || worker < m.firstWorkerID || slot >= m.modulus {
+		return LegacyRef{}, false
+	}
+	return LegacyRef{
+		Residue:   slot + sequence,
+		Modulus:   m.modulus,
+		CreatedAt: time.UnixMilli(m.epoch + elapsed),
+	}, true
+}
@@ -0,0 +1,104 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_LegacyMapper(t *testing.T) {
+	m, err := NewLegacyMapper(DefaultLayout, 31, 30, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Modulus() != 2*(sequenceMask+1) {
+		t.Fatalf("Modulus = %d", m.Modulus())
+	}
+	createdAt := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	seen := make(map[ID]int64)
+	for legacy := int64(1); legacy <= 10000; legacy++ {
+		id, err := m.Map(legacy, createdAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again, _ := m.Map(legacy, createdAt); again != id {
+			t.Fatalf("Map(%d) is not deterministic", legacy)
+		}
+		if legacy < m.Modulus() {
+			if prev, ok := seen[id]; ok {
+				t.Fatalf("legacy ids %d and %d collide", prev, legacy)
+			}
+			seen[id] = legacy
+		}
+		ref, ok := m.Unmap(id)
+		if !ok || ref.Residue != legacy%m.Modulus() || !ref.CreatedAt.Equal(createdAt) {
+			t.Fatalf("Unmap(%d) = %+v, %v", id, ref, ok)
+		}
+	}
+
+	live, err := Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.Unmap(ID(live)); ok {
+		t.Error("live id should not be recognised as migrated")
+	}
+
+	earlier, _ := m.Map(1, createdAt.Add(-time.Second))
+	later, _ := m.Map(1, createdAt.Add(time.Second))
+	if earlier >= later {
+		t.Error("migrated ids should sort by creation time")
+	}
+
+	// 同一毫秒内模 Modulus 同余的旧 id 冲突，不同毫秒则不冲突
+	a, _ := m.Map(5, createdAt)
+	b, _ := m.Map(5+m.Modulus(), createdAt)
+	c, _ := m.Map(5+m.Modulus(), createdAt.Add(time.Millisecond))
+	if a != b || a == c {
+		t.Errorf("congruent ids: same millisecond %d, %d; next millisecond %d", a, b, c)
+	}
+
+	if _, err := NewLegacyMapper(DefaultLayout, 31, 31, 2); err == nil {
+		t.Error("expected error for worker range outside layout")
+	}
+	if _, err := m.Map(-1, createdAt); err == nil {
+		t.Error("expected error for negative legacy id")
+	}
+}
+
+func Test_LegacyMapperTombstone(t *testing.T) {
+	layout := DefaultLayout
+	layout.TombstoneBit = true
+	m, err := NewLegacyMapper(layout, 1, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 墓碑位不参与序列，每个 worker 容纳的旧 id 减半
+	if m.Modulus() != 2*(sequenceMask+1)/2 {
+		t.Fatalf("Modulus = %d", m.Modulus())
+	}
+	createdAt := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	seen := make(map[ID]int64)
+	for legacy := int64(0); legacy < m.Modulus(); legacy++ {
+		id, err := m.Map(legacy, createdAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prev, ok := seen[id]; ok {
+			t.Fatalf("legacy ids %d and %d collide", prev, legacy)
+		}
+		seen[id] = legacy
+		if layout.IsTombstone(id) {
+			t.Fatalf("Map(%d) = %d is a tombstone", legacy, id)
+		}
+		if ref, ok := m.Unmap(id); !ok || ref.Residue != legacy {
+			t.Fatalf("Unmap(%d) = %+v, %v", id, ref, ok)
+		}
+	}
+	// 保留范围之外的 worker 不被识别
+	outside := ID(layout.compose(1, 1, 2, 0))
+	if _, ok := m.Unmap(outside); ok {
+		t.Error("id of worker 2 recognised as migrated")
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+// WithRandomSequence start each millisecond's sequence at a random value instead of 0, so
+// uncoordinated processes sharing the machine bits rarely issue the same id; ids of one
+// process stay unique and ordered, on average half of the sequence space is usable per
+// millisecond
+func WithRandomSequence() Option {
+	return func(w *worker) {
+		w.randomSequence = true
+	}
+}
+
+// NewLiteGenerator return a coordination-free generator of LiteLayout ids: 48-bit unix
+// millisecond timestamp and 16 bits of entropy, drawn per millisecond from the system's
+// secure source (or WithRandomSeed) and incremented within it. No worker or datacenter ids
+// are needed; the package's clock handling still applies, ids never go back in time and
+// options such as WithClock, WithClockSmear or WithStateStore work as for NewGenerator.
+// Never returns negative ids before the year 6429, so Next can be used.
+// Two processes collide only when they issue ids in the same millisecond and their sequence
+// ranges overlap; for high rates across many processes prefer coordinated worker ids.
+func NewLiteGenerator(opts ...Option) (Generator, error) {
+	return NewGenerator(0, 0, append([]Option{WithLayout(LiteLayout), WithSignMode(SignNonNegative), WithRandomSequence()}, opts...)...)
+}
+
+// startSequence 新毫秒的起始序列，调用方需持有 w.mutex
+func (w *worker) startSequence() int64 {
+	if !w.randomSequence {
+		return 0
+	}
+	return w.randomMachineID(w.layout.sequenceMask())
+}
@@ -0,0 +1,84 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_LiteGenerator(t *testing.T) {
+	g, err := NewLiteGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	before := time.Now().UnixMilli()
+	ids, err := g.NextN(100000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := time.Now().UnixMilli()
+	for i, id := range ids {
+		if i > 0 && id <= ids[i-1] {
+			t.Fatalf("ids not increasing: %d after %d", id, ids[i-1])
+		}
+		if ms := id >> 16; ms < before || ms > after {
+			t.Fatalf("id %d timestamp %d outside [%d, %d]", id, ms, before, after)
+		}
+	}
+	if p := LiteLayout.Decompose(ID(ids[0])); p.Time.UnixMilli() != ids[0]>>16 || p.Sequence != ids[0]&0xffff {
+		t.Errorf("Decompose = %+v, want unix millis %d", p, ids[0]>>16)
+	}
+	if r := LiteLayout.Report(); r.Invalid != nil || r.Exhausted.Year() != 10889 || r.NegativeFrom.Year() != 6429 {
+		t.Errorf("Report = %+v", r)
+	}
+}
+
+func Test_RandomSequence(t *testing.T) {
+	ms := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return ms }
+	// 同一时刻、同一种子的两个 worker 结果相同，不同种子的起点不同
+	first := func(seed uint64) int64 {
+		w, err := newWorker(0, 0, WithLayout(LiteLayout), WithSignMode(SignNonNegative), WithRandomSequence(), WithRandomSeed(seed), WithClock(clock))
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := w.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id & 0xffff
+	}
+	if first(1) != first(1) {
+		t.Error("same seed, different sequence")
+	}
+	starts := map[int64]bool{}
+	for seed := range uint64(8) {
+		starts[first(seed)] = true
+	}
+	if len(starts) < 4 {
+		t.Errorf("random starts %v", starts)
+	}
+
+	// 序列用尽后等待下一毫秒，本毫秒内不回绕到已用过的值
+	w, err := newWorker(0, 0, WithLayout(LiteLayout), WithSignMode(SignNonNegative), WithRandomSequence(), WithRandomSeed(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[int64]bool{}
+	for range 200000 {
+		id, err := w.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[id] {
+			t.Fatalf("duplicate id %d", id)
+		}
+		seen[id] = true
+	}
+
+	if _, err := NewShardedWorker(0, 0, WithRandomSequence()); err == nil {
+		t.Error("sharded worker accepted WithRandomSequence")
+	}
+}
@@ -0,0 +1,148 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Level severity of a log record
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	}
+	return "error"
+}
+
+// Logger receives the library's warnings with alternating key/value pairs, see SlogLogger,
+// ZapLogger and LogrusLogger for adapters
+type Logger interface {
+	Log(level Level, msg string, keyvals ...any)
+}
+
+// WithLogger log anomalies (the events of WithEventHandler) and background failures such
+// as lease checks to l; the library logs nothing by default
+func WithLogger(l Logger) Option {
+	return func(w *worker) {
+		w.logger = l
+	}
+}
+
+// eventLevels 各事件的日志级别
+var eventLevels = map[EventType]Level{
+	EventClockBackwards:    LevelWarn,
+	EventSequenceExhausted: LevelDebug,
+	EventLeaseLost:         LevelError,
+	EventClockSmeared:      LevelInfo,
+	EventClockSkew:         LevelWarn,
+}
+
+// log 附带 worker 与 datacenter id 输出一条日志
+func (w *worker) log(level Level, msg string, keyvals ...any) {
+	if w.logger == nil {
+		return
+	}
+	w.logger.Log(level, msg, append([]any{"worker_id", w.workerID, "datacenter_id", w.datacenterID}, keyvals...)...)
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// SlogLogger return Logger writing to l
+func SlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l}
+}
+
+func (s slogLogger) Log(level Level, msg string, keyvals ...any) {
+	levels := [...]slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError}
+	s.l.Log(context.Background(), levels[min(max(level, LevelDebug), LevelError)], msg, keyvals...)
+}
+
+// ZapSugaredLogger methods of *zap.SugaredLogger used by ZapLogger
+type ZapSugaredLogger interface {
+	Debugw(msg string, keysAndValues ...any)
+	Infow(msg string, keysAndValues ...any)
+	Warnw(msg string, keysAndValues ...any)
+	Errorw(msg string, keysAndValues ...any)
+}
+
+type zapLogger struct {
+	l ZapSugaredLogger
+}
+
+// ZapLogger return Logger writing to a zap sugared logger, e.g. zap.L().Sugar()
+func ZapLogger(l ZapSugaredLogger) Logger {
+	return zapLogger{l}
+}
+
+func (z zapLogger) Log(level Level, msg string, keyvals ...any) {
+	switch level {
+	case LevelDebug:
+		z.l.Debugw(msg, keyvals...)
+	case LevelInfo:
+		z.l.Infow(msg, keyvals...)
+	case LevelWarn:
+		z.l.Warnw(msg, keyvals...)
+	default:
+		z.l.Errorw(msg, keyvals...)
+	}
+}
+
+// PrintfLogger leveled printf methods of logrus.FieldLogger and similar loggers
+type PrintfLogger interface {
+	Debugf(format string, args ...any)
+	Infof(format string, args ...any)
+	Warnf(format string, args ...any)
+	Errorf(format string, args ...any)
+}
+
+type printfLogger struct {
+	l PrintfLogger
+}
+
+// LogrusLogger return Logger writing to a logrus logger or entry (or any PrintfLogger),
+// with the key/value pairs appended to the message as key=value
+func LogrusLogger(l PrintfLogger) Logger {
+	return printfLogger{l}
+}
+
+func (p printfLogger) Log(level Level, msg string, keyvals ...any) {
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i < len(keyvals); i += 2 {
+		if i+1 < len(keyvals) {
+			fmt.Fprintf(&b, " %v=%v", keyvals[i], keyvals[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", keyvals[i])
+		}
+	}
+	s := b.String()
+	switch level {
+	case LevelDebug:
+		p.l.Debugf("%s", s)
+	case LevelInfo:
+		p.l.Infof("%s", s)
+	case LevelWarn:
+		p.l.Warnf("%s", s)
+	default:
+		p.l.Errorf("%s", s)
+	}
+}
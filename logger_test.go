@@ -0,0 +1,139 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type recordLogger struct {
+	lines []string
+}
+
+func (r *recordLogger) Log(level Level, msg string, keyvals ...any) {
+	r.lines = append(r.lines, fmt.Sprint(level, " ", msg, keyvals))
+}
+
+func Test_WithLogger(t *testing.T) {
+	rec := &recordLogger{}
+	w, err := newWorker(1, 2, WithLogger(rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.lastTimestamp = currentMillis() + 50
+	if _, err := w.Next(); err == nil {
+		t.Fatal("expected clock backwards error")
+	}
+	if len(rec.lines) != 1 || !strings.HasPrefix(rec.lines[0], "warn snowflake: clock_backwards[worker_id 1 datacenter_id 2 millis") {
+		t.Errorf("logged %q", rec.lines)
+	}
+}
+
+type zapRecorder struct {
+	calls   []string
+	keyvals []any
+}
+
+func (z *zapRecorder) record(level, msg string, kv []any) {
+	z.calls = append(z.calls, level+" "+msg)
+	z.keyvals = kv
+}
+
+func (z *zapRecorder) Debugw(msg string, kv ...any) { z.record("debug", msg, kv) }
+func (z *zapRecorder) Infow(msg string, kv ...any)  { z.record("info", msg, kv) }
+func (z *zapRecorder) Warnw(msg string, kv ...any)  { z.record("warn", msg, kv) }
+func (z *zapRecorder) Errorw(msg string, kv ...any) { z.record("error", msg, kv) }
+
+type printfRecorder struct{ calls []string }
+
+func (p *printfRecorder) Debugf(f string, a ...any) {
+	p.calls = append(p.calls, "debug "+fmt.Sprintf(f, a...))
+}
+func (p *printfRecorder) Infof(f string, a ...any) {
+	p.calls = append(p.calls, "info "+fmt.Sprintf(f, a...))
+}
+func (p *printfRecorder) Warnf(f string, a ...any) {
+	p.calls = append(p.calls, "warn "+fmt.Sprintf(f, a...))
+}
+func (p *printfRecorder) Errorf(f string, a ...any) {
+	p.calls = append(p.calls, "error "+fmt.Sprintf(f, a...))
+}
+
+func Test_LoggerAdapters(t *testing.T) {
+	var buf bytes.Buffer
+	SlogLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))).Log(LevelWarn, "m", "k", 1)
+	if s := buf.String(); !strings.Contains(s, "level=WARN") || !strings.Contains(s, "msg=m k=1") {
+		t.Errorf("slog output %q", s)
+	}
+
+	z := &zapRecorder{}
+	ZapLogger(z).Log(LevelError, "m", "k", 1)
+	if len(z.calls) != 1 || z.calls[0] != "error m" || len(z.keyvals) != 2 || z.keyvals[0] != "k" {
+		t.Errorf("zap calls %q %v", z.calls, z.keyvals)
+	}
+
+	p := &printfRecorder{}
+	LogrusLogger(p).Log(LevelInfo, "m", "k", 1, "dangling")
+	if len(p.calls) != 1 || p.calls[0] != "info m k=1 dangling" {
+		t.Errorf("printf calls %q", p.calls)
+	}
+}
+
+func Test_LeaseFailureLogged(t *testing.T) {
+	var mu sync.Mutex
+	var msgs []string
+	logger := loggerFunc(func(level Level, msg string, keyvals ...any) {
+		mu.Lock()
+		defer mu.Unlock()
+		msgs = append(msgs, msg)
+	})
+	var fail atomic.Bool
+	checker := LeaseCheckerFunc(func(ctx context.Context) error {
+		if fail.Load() {
+			return errors.New("etcd unreachable")
+		}
+		return nil
+	})
+	w, err := newWorker(1, 1, WithLogger(logger), WithLeaseCheck(checker, 5*time.Millisecond, time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	fail.Store(true)
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		mu.Lock()
+		logged := slices.Contains(msgs, "snowflake: lease check failed")
+		mu.Unlock()
+		if logged {
+			return
+		}
+	}
+	t.Error("lease failure not logged")
+}
+
+type loggerFunc func(level Level, msg string, keyvals ...any)
+
+func (f loggerFunc) Log(level Level, msg string, keyvals ...any) {
+	f(level, msg, keyvals...)
+}
+
+func Test_LayoutWarningsLogged(t *testing.T) {
+	rec := &recordLogger{}
+	short := Layout{Epoch: time.Now().Add(-time.Hour), TimestampBits: 30, SequenceBits: 12}
+	if _, err := newWorker(0, 0, WithLayout(short), WithLogger(rec)); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.lines) != 1 || !strings.HasPrefix(rec.lines[0], "warn snowflake: layout: timestamp bits run out on ") {
+		t.Errorf("logged %q", rec.lines)
+	}
+}
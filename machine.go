@@ -0,0 +1,113 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// WithMachineID use one machine id field covering the datacenter and worker bits
+// (0-1023 with the default layout) instead of the 5+5 split, for deployments without
+// a datacenter concept; the worker and datacenter ids given to NewWorker must be 0.
+// Decomposed ids report the machine id as WorkerID.
+func WithMachineID(id uint16) Option {
+	return func(w *worker) {
+		w.machineID = int64(id)
+		w.machine = true
+	}
+}
+
+// applyMachineID 合并数据标识位和机器位，在所有选项之后调用
+func (w *worker) applyMachineID() error {
+	if w.workerID != 0 || w.datacenterID != 0 {
+		return fmt.Errorf("worker and datacenter ids must be 0 with WithMachineID")
+	}
+	w.layout.WorkerBits += w.layout.DatacenterBits
+	w.layout.DatacenterBits = 0
+	if w.randomMachine {
+		w.machineID = w.randomMachineID(w.layout.maxWorkerID())
+	}
+	w.workerID = w.machineID
+	if w.workerID > w.layout.maxWorkerID() {
+		return fmt.Errorf("machine Id can't be greater than %d", w.layout.maxWorkerID())
+	}
+	return nil
+}
+
+// WithRandomMachine fill the machine bits (datacenter and worker bits, as in WithMachineID)
+// with a random value per process instead of a coordinated id, for short-lived jobs where
+// coordination is impossible. When a millisecond's sequence is used up the worker picks new
+// random machine bits (up to 16 per millisecond) instead of waiting, so ids of one millisecond
+// are not ordered.
+// Two processes collide only if they pick the same machine bits in the same millisecond,
+// see RandomCollisionProbability: a process using up its sequence holds up to 16 machine
+// values in one millisecond, raising the probability as if it were 16 processes.
+// The worker and datacenter ids given to NewWorker must be 0.
+func WithRandomMachine() Option {
+	return func(w *worker) {
+		w.machine = true
+		w.randomMachine = true
+	}
+}
+
+// randomMachineID 返回 [0, max] 内的随机值，设置了 WithRandomSeed 时取自确定的随机序列
+func (w *worker) randomMachineID(max int64) int64 {
+	if w.rand != nil {
+		return w.rand.Int64N(max + 1)
+	}
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("snowflake: random machine id: %v", err))
+	}
+	return int64(binary.LittleEndian.Uint64(b[:]) % uint64(max+1))
+}
+
+// canReroll timestamp 这一毫秒是否还能更换机器位，调用方需持有 w.mutex
+func (w *worker) canReroll(timestamp int64) bool {
+	used := 1 // 当前机器位
+	if w.rerollMs == timestamp {
+		used += len(w.rerolled)
+	}
+	return int64(used) <= w.layout.maxWorkerID() && used < rerollLimit
+}
+
+// rerollMachine 为 timestamp 这一毫秒换一组本毫秒未用过的随机机器位，
+// 无可用值时返回 false，调用方需持有 w.mutex
+func (w *worker) rerollMachine(timestamp int64) bool {
+	if !w.canReroll(timestamp) {
+		return false
+	}
+	if w.rerollMs != timestamp {
+		w.rerollMs, w.rerolled = timestamp, w.rerolled[:0]
+	}
+	w.rerolled = append(w.rerolled, w.workerID)
+	for {
+		id := w.randomMachineID(w.layout.maxWorkerID())
+		if !slices.Contains(w.rerolled, id) {
+			w.workerID = id
+			return true
+		}
+	}
+}
+
+// rerollLimit 每毫秒最多使用的机器位组数，用完后等待下一毫秒
+const rerollLimit = 16
+
+// RandomCollisionProbability return the probability that at least two of processes random
+// machine workers of the layout (see WithRandomMachine) run with the same machine bits at
+// the same time, counting one machine value per process. It bounds the chance of a duplicate
+// id only while no process uses up a millisecond's sequence: a saturated process rerolls and
+// holds up to 16 values per millisecond, pass 16 times the processes for that bound
+func RandomCollisionProbability(processes int, l Layout) float64 {
+	if processes < 2 {
+		return 0
+	}
+	space := math.Ldexp(1, int(l.DatacenterBits)+int(l.WorkerBits))
+	n := float64(processes)
+	// 生日问题的近似: 1 - exp(-n(n-1)/2N)
+	return -math.Expm1(-n * (n - 1) / (2 * space))
+}
@@ -0,0 +1,100 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import "testing"
+
+func Test_WithMachineID(t *testing.T) {
+	w, err := newWorker(0, 0, WithMachineID(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := w.layout.Decompose(ID(id))
+	if p.WorkerID != 1000 || p.DatacenterID != 0 || w.layout.WorkerBits != 10 || w.layout.DatacenterBits != 0 {
+		t.Errorf("parts = %+v, layout = %+v", p, w.layout)
+	}
+	// 与 5+5 布局的 id 位置相同：datacenter<<5 | worker
+	if p := DefaultLayout.Decompose(ID(id)); p.DatacenterID != 1000>>5 || p.WorkerID != 1000&31 {
+		t.Errorf("5+5 view of machine id = %+v", p)
+	}
+
+	if _, err := newWorker(0, 0, WithMachineID(1024)); err == nil {
+		t.Error("expected error for machine id beyond 10 bits")
+	}
+	if _, err := newWorker(1, 0, WithMachineID(5)); err == nil {
+		t.Error("expected error when worker id is also set")
+	}
+
+	w, err = workerFromEnv(func(k string) string { return map[string]string{EnvMachineID: "513"}[k] })
+	if err != nil || w.workerID != 513 {
+		t.Errorf("machine id from env = %+v, %v", w, err)
+	}
+	if _, err := workerFromEnv(func(k string) string { return map[string]string{EnvMachineID: "70000"}[k] }); err == nil {
+		t.Error("expected error for invalid machine id in env")
+	}
+}
+
+func Test_WithRandomMachine(t *testing.T) {
+	w, err := newWorker(0, 0, WithRandomMachine())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 冻结时钟，序列耗尽后应换机器位而不是等待
+	frozen := currentMillis() + 1000
+	w.now = func() int64 { return frozen }
+	w.customClock = true
+	seen := map[int64]bool{}
+	machines := map[int64]bool{}
+	for i := 0; i < 3*(sequenceMask+1); i++ {
+		id, err := w.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[id] {
+			t.Fatalf("duplicate id %d", id)
+		}
+		seen[id] = true
+		p := w.layout.Decompose(ID(id))
+		if p.Time.UnixMilli() != frozen {
+			t.Fatalf("id time %v, want frozen %d", p.Time, frozen)
+		}
+		machines[p.WorkerID] = true
+	}
+	if len(machines) != 3 {
+		t.Errorf("used %d machine values, want 3", len(machines))
+	}
+	if _, ok := w.TryNext(); !ok {
+		t.Error("TryNext should not report exhaustion in random machine mode")
+	}
+	// 每毫秒最多换 rerollLimit 次，之后与普通模式一样等待
+	for i := 0; i < (rerollLimit-3)*(sequenceMask+1)-1; i++ {
+		id, err := w.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[id] {
+			t.Fatalf("duplicate id %d after %d", id, i)
+		}
+		seen[id] = true
+	}
+	if _, ok := w.TryNext(); ok {
+		t.Error("TryNext should report exhaustion after rerollLimit machine values")
+	}
+}
+
+func Test_RandomCollisionProbability(t *testing.T) {
+	if p := RandomCollisionProbability(1, DefaultLayout); p != 0 {
+		t.Errorf("one process = %v", p)
+	}
+	// 1024 个机器值，38 个进程约 50%
+	if p := RandomCollisionProbability(38, DefaultLayout); p < 0.45 || p > 0.55 {
+		t.Errorf("38 processes = %v", p)
+	}
+	if p := RandomCollisionProbability(2, DefaultLayout); p < 0.00097 || p > 0.00098 {
+		t.Errorf("2 processes = %v", p)
+	}
+}
@@ -0,0 +1,51 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// RequestIDHeader header carrying request ids
+const RequestIDHeader = "X-Request-Id"
+
+// Middleware return net/http middleware giving each request a snowflake request id from w,
+// set on both the request and response RequestIDHeader and on the request context (see
+// IDFromContext); ids already present on incoming requests are kept, and only reach the
+// context when they parse as ids. If w fails the request is served without an id.
+// The func(http.Handler) http.Handler shape plugs directly into chi (r.Use) and
+// any other router built on net/http; gin and echo adapters are in snowflakegin and
+// snowflakeecho, and snowflakefiber applies the same rules through RequestID.
+func Middleware(w Worker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rid := r.Header.Get(RequestIDHeader)
+			if rid == "" {
+				if rid = RequestID(w, ""); rid != "" {
+					r.Header.Set(RequestIDHeader, rid)
+				}
+			}
+			if rid != "" {
+				rw.Header().Set(RequestIDHeader, rid)
+			}
+			if id, err := Parse(rid); err == nil {
+				r = r.WithContext(ContextWithID(r.Context(), id))
+			}
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// RequestID return the request id Middleware uses for an incoming RequestIDHeader value:
+// rid itself when set, otherwise a new id from w, "" if w fails. For routers not built on
+// net/http
+func RequestID(w Worker, rid string) string {
+	if rid != "" {
+		return rid
+	}
+	if id, err := w.Next(); err == nil {
+		return strconv.FormatInt(id, 10)
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_Middleware(t *testing.T) {
+	var seen string
+	var fromCtx ID
+	var inCtx bool
+	h := Middleware(NewWorker(0, 0))(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get(RequestIDHeader)
+		fromCtx, inCtx = IDFromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	id, err := Parse(seen)
+	if err != nil || !IsValid(int64(id)) {
+		t.Fatalf("request id %q is not a snowflake id: %v", seen, err)
+	}
+	if got := rec.Header().Get(RequestIDHeader); got != seen {
+		t.Errorf("response id = %q, request id = %q", got, seen)
+	}
+	if !inCtx || fromCtx != id {
+		t.Errorf("context id = %d, %v, want %d", fromCtx, inCtx, id)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "upstream")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if seen != "upstream" || rec.Header().Get(RequestIDHeader) != "upstream" {
+		t.Errorf("incoming request id should be kept, got %q", seen)
+	}
+	if inCtx {
+		t.Error("non-id request id reached the context")
+	}
+}
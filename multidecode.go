@@ -0,0 +1,74 @@
+package snowflake
+
+import (
+	"fmt"
+	"time"
+)
+
+// multiDecodeSkew 未设置 To 时，容忍时间戳领先当前时间的时长
+const multiDecodeSkew = time.Minute
+
+// Candidate a layout tried by MultiDecoder, with the window its ids were issued in
+type Candidate struct {
+	Layout Layout
+	From   time.Time // zero for the layout's epoch
+	To     time.Time // zero for now plus a minute of clock skew
+}
+
+// MultiDecoder decode ids of layouts that coexist in storage without version bits (see
+// Schemes for layouts that have them), e.g. during a migration to a new epoch. For each id it
+// tries the candidates in order and picks the first one that decodes the id to a time within
+// the candidate's window. List the newest scheme first with its window starting at the
+// cutover: an old id then decodes to a time beyond now under the new epoch and falls through,
+// as long as the old epoch is further in the past than the new one is. Keep windows as tight
+// as known, e.g. From at the first id ever issued with the layout.
+type MultiDecoder struct {
+	candidates []Candidate
+}
+
+// NewMultiDecoder return a decoder trying the candidates in order
+func NewMultiDecoder(candidates ...Candidate) (*MultiDecoder, error) {
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("multi decoder needs at least one candidate")
+	}
+	for i, c := range candidates {
+		if err := c.Layout.Validate(); err != nil {
+			return nil, fmt.Errorf("candidate %d: %v", i, err)
+		}
+		if !c.To.IsZero() && c.To.Before(c.From) {
+			return nil, fmt.Errorf("candidate %d: window ends before it starts", i)
+		}
+	}
+	return &MultiDecoder{candidates: candidates}, nil
+}
+
+// Layout return the layout of the first candidate decoding id into its window
+func (d *MultiDecoder) Layout(id ID) (Layout, bool) {
+	now := time.Now()
+	for _, c := range d.candidates {
+		if !c.Layout.isValid(uint64(id)) {
+			continue
+		}
+		t := c.Layout.Decompose(id).Time
+		from, to := c.From, c.To
+		if from.IsZero() {
+			from = c.Layout.Epoch
+		}
+		if to.IsZero() {
+			to = now.Add(multiDecodeSkew)
+		}
+		if !t.Before(from) && !t.After(to) {
+			return c.Layout, true
+		}
+	}
+	return Layout{}, false
+}
+
+// Decompose split id with the layout selected by Layout
+func (d *MultiDecoder) Decompose(id ID) (Parts, error) {
+	l, ok := d.Layout(id)
+	if !ok {
+		return Parts{}, fmt.Errorf("id %d decodes to a plausible time under no candidate layout", id)
+	}
+	return l.Decompose(id), nil
+}
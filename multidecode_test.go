@@ -0,0 +1,51 @@
+package snowflake
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_MultiDecoder(t *testing.T) {
+	old := DefaultLayout
+	old.Epoch = time.Date(2010, 11, 4, 0, 0, 0, 0, time.UTC)
+	cutover := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cur := DefaultLayout
+	cur.Epoch = cutover
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	d, err := NewMultiDecoder(
+		Candidate{Layout: cur, To: now},
+		Candidate{Layout: old, From: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC), To: cutover},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issue := func(l Layout, at time.Time, worker int64) ID {
+		return ID(l.compose(at.UnixMilli()-l.Epoch.UnixMilli(), 1, worker, 7))
+	}
+	for _, c := range []struct {
+		l  Layout
+		at time.Time
+	}{
+		{old, time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{old, cutover.Add(-time.Hour)},
+		{cur, cutover.Add(time.Hour)},
+		{cur, now.Add(-time.Hour)},
+	} {
+		id := issue(c.l, c.at, 9)
+		p, err := d.Decompose(id)
+		if err != nil {
+			t.Errorf("%v: %v", c.at, err)
+			continue
+		}
+		if !p.Time.Equal(c.at) || p.WorkerID != 9 || p.Sequence != 7 {
+			t.Errorf("%v decoded as %+v", c.at, p)
+		}
+	}
+	// 新布局下晚于 now，旧布局下早于服务上线，两者都不可信
+	if _, err := d.Decompose(issue(cur, now.Add(time.Hour), 1)); err == nil {
+		t.Error("id from the future decoded")
+	}
+	if _, err := NewMultiDecoder(Candidate{Layout: cur, From: now, To: cutover}); err == nil {
+		t.Error("inverted window accepted")
+	}
+}
@@ -0,0 +1,69 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import "sync"
+
+// Multiplexer mint ids on behalf of many logical workers from one process, e.g. in an
+// id issuing proxy; each worker/datacenter pair keeps its own sequence state
+type Multiplexer struct {
+	opts    []Option
+	mu      sync.Mutex
+	workers map[uint16]*worker
+	closed  bool
+}
+
+// NewMultiplexer return a multiplexer whose workers are created on first use with opts
+func NewMultiplexer(opts ...Option) *Multiplexer {
+	return &Multiplexer{opts: opts, workers: map[uint16]*worker{}}
+}
+
+// NextFor return new id issued by workerID in datacenterID
+func (m *Multiplexer) NextFor(workerID, datacenterID uint8) (int64, error) {
+	w, err := m.worker(workerID, datacenterID)
+	if err != nil {
+		return 0, err
+	}
+	return w.Next()
+}
+
+// worker 返回该组合的 worker，不存在时创建
+func (m *Multiplexer) worker(workerID, datacenterID uint8) (*worker, error) {
+	key := uint16(datacenterID)<<8 | uint16(workerID)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, ErrClosed
+	}
+	if w, ok := m.workers[key]; ok {
+		return w, nil
+	}
+	w, err := newWorker(workerID, datacenterID, m.opts...)
+	if err != nil {
+		return nil, err
+	}
+	m.workers[key] = w
+	return w, nil
+}
+
+// Stats return the statistics of every worker created so far, summed
+func (m *Multiplexer) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var s Stats
+	for _, w := range m.workers {
+		s.add(w.Stats())
+	}
+	return s
+}
+
+// Close close all workers, later calls return ErrClosed
+func (m *Multiplexer) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	for _, w := range m.workers {
+		w.Close()
+	}
+	return nil
+}
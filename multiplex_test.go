@@ -0,0 +1,50 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"sync"
+	"testing"
+)
+
+func Test_Multiplexer(t *testing.T) {
+	m := NewMultiplexer()
+	var mu sync.Mutex
+	seen := map[int64]bool{}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				wk, dc := uint8(i%4), uint8(g%2)
+				id, err := m.NextFor(wk, dc)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				p := DefaultLayout.Decompose(ID(id))
+				if p.WorkerID != int64(wk) || p.DatacenterID != int64(dc) {
+					t.Errorf("id %d decomposes to %+v, want worker %d datacenter %d", id, p, wk, dc)
+				}
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("duplicate id %d", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+		}(g)
+	}
+	wg.Wait()
+	if s := m.Stats(); s.Issued != 8000 {
+		t.Errorf("Stats().Issued = %d", s.Issued)
+	}
+	if _, err := m.NextFor(maxWorkerID+1, 0); err == nil {
+		t.Error("expected error for invalid worker id")
+	}
+	m.Close()
+	if _, err := m.NextFor(0, 0); err != ErrClosed {
+		t.Errorf("NextFor after Close: got %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package snowflake
+
+import (
+	"fmt"
+	"time"
+)
+
+// OrderViolationKind ordering property an id broke, see OrderChecker
+type OrderViolationKind int
+
+const (
+	// NodeRegression id not above the previous id of the same datacenter and worker in its source
+	NodeRegression OrderViolationKind = iota
+	// SkewExceeded id timestamped more than the tolerated skew before the newest id seen earlier in its source
+	SkewExceeded
+	// InvalidID id not valid for the checker's layout, not checked further
+	InvalidID
+)
+
+func (k OrderViolationKind) String() string {
+	switch k {
+	case NodeRegression:
+		return "node regression"
+	case SkewExceeded:
+		return "skew exceeded"
+	case InvalidID:
+		return "invalid id"
+	}
+	return fmt.Sprintf("OrderViolationKind(%d)", int(k))
+}
+
+// OrderViolation an id observed out of order, with the earlier id it conflicts with
+type OrderViolation struct {
+	Kind         OrderViolationKind
+	Source       string
+	Line         int // position of ID among the ids of its source, from 1: the line of one id per line files
+	ID           ID
+	DatacenterID int64
+	WorkerID     int64
+	Previous     ID            // earlier id of the source ID is out of order with, 0 for InvalidID
+	PreviousLine int           // position of Previous in the source
+	Behind       time.Duration // how far ID's timestamp is behind Previous's
+}
+
+// String return the violation with its context on one line
+func (v OrderViolation) String() string {
+	if v.Kind == InvalidID {
+		return fmt.Sprintf("%s:%d: %s %d", v.Source, v.Line, v.Kind, v.ID)
+	}
+	return fmt.Sprintf("%s:%d: %s: %d (datacenter %d worker %d) after %d at line %d, %s behind",
+		v.Source, v.Line, v.Kind, v.ID, v.DatacenterID, v.WorkerID, v.Previous, v.PreviousLine, v.Behind)
+}
+
+// orderMark 某个来源中之前见过的 id
+type orderMark struct {
+	id   ID
+	line int
+	time time.Time
+}
+
+// orderSource 一个来源的检查状态
+type orderSource struct {
+	line   int
+	newest orderMark
+	nodes  map[[2]int64]orderMark
+}
+
+// OrderChecker verify the ordering properties of ids as observed by consumers, e.g. in log
+// files or arriving on a socket: within each source, every datacenter/worker's ids must be
+// strictly increasing, and no id may be timestamped more than the tolerated skew before the
+// newest id seen earlier, so interleaved ids of several workers are roughly time ordered.
+// Not safe for concurrent use.
+type OrderChecker struct {
+	layout  Layout
+	skew    time.Duration
+	sources map[string]*orderSource
+}
+
+// NewOrderChecker return a checker for ids of layout l tolerating skew between workers' clocks
+func NewOrderChecker(l Layout, skew time.Duration) *OrderChecker {
+	return &OrderChecker{layout: l, skew: skew, sources: map[string]*orderSource{}}
+}
+
+// Check record id as the next id observed in source and return the violations it causes
+func (c *OrderChecker) Check(source string, id ID) []OrderViolation {
+	s := c.sources[source]
+	if s == nil {
+		s = &orderSource{nodes: map[[2]int64]orderMark{}}
+		c.sources[source] = s
+	}
+	s.line++
+	if !c.layout.isValid(uint64(id)) {
+		return []OrderViolation{{Kind: InvalidID, Source: source, Line: s.line, ID: id}}
+	}
+	p := c.layout.Decompose(id)
+	cur := orderMark{id: id, line: s.line, time: p.Time}
+	violation := func(kind OrderViolationKind, prev orderMark) OrderViolation {
+		return OrderViolation{Kind: kind, Source: source, Line: s.line, ID: id, DatacenterID: p.DatacenterID, WorkerID: p.WorkerID,
+			Previous: prev.id, PreviousLine: prev.line, Behind: prev.time.Sub(p.Time)}
+	}
+
+	var out []OrderViolation
+	node := [2]int64{p.DatacenterID, p.WorkerID}
+	if prev, ok := s.nodes[node]; ok && uint64(id) <= uint64(prev.id) {
+		out = append(out, violation(NodeRegression, prev))
+	} else {
+		s.nodes[node] = cur
+	}
+	if s.newest.line > 0 && s.newest.time.Sub(p.Time) > c.skew {
+		out = append(out, violation(SkewExceeded, s.newest))
+	}
+	if s.newest.line == 0 || p.Time.After(s.newest.time) {
+		s.newest = cur
+	}
+	return out
+}
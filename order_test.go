@@ -0,0 +1,47 @@
+package snowflake
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_OrderChecker(t *testing.T) {
+	l := DefaultLayout
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	id := func(ms int, worker, sequence int64) ID {
+		return ID(l.compose(base.Add(time.Duration(ms)*time.Millisecond).UnixMilli()-l.Epoch.UnixMilli(), 0, worker, sequence))
+	}
+	c := NewOrderChecker(l, 10*time.Millisecond)
+	check := func(source string, id ID) []OrderViolation {
+		return c.Check(source, id)
+	}
+
+	for _, x := range []ID{id(0, 1, 0), id(0, 2, 0), id(5, 1, 0), id(1, 2, 1), id(20, 2, 0)} {
+		if v := check("a", x); v != nil {
+			t.Fatalf("Check(%d) = %v", x, v)
+		}
+	}
+	// worker 1 回退，同时落后最新 id 20ms
+	v := check("a", id(4, 1, 0))
+	if len(v) != 2 || v[0].Kind != NodeRegression || v[0].Previous != id(5, 1, 0) || v[0].PreviousLine != 3 || v[0].Line != 6 || v[0].Behind != time.Millisecond {
+		t.Fatalf("regression = %+v", v)
+	}
+	if v[1].Kind != SkewExceeded || v[1].Previous != id(20, 2, 0) || v[1].Behind != 16*time.Millisecond {
+		t.Errorf("skew = %+v", v[1])
+	}
+	if s := v[0].String(); !strings.Contains(s, "a:6: node regression") || !strings.Contains(s, "worker 1") || !strings.Contains(s, "line 3") {
+		t.Errorf("String = %q", s)
+	}
+	// 重复 id 也不是递增
+	if v := check("a", id(20, 2, 0)); len(v) != 1 || v[0].Kind != NodeRegression {
+		t.Errorf("duplicate = %v", v)
+	}
+	// 来源相互独立
+	if v := check("b", id(0, 1, 0)); v != nil {
+		t.Errorf("new source = %v", v)
+	}
+	if v := check("b", -1); len(v) != 1 || v[0].Kind != InvalidID || v[0].Line != 2 {
+		t.Errorf("invalid = %v", v)
+	}
+}
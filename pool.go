@@ -0,0 +1,112 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// pool 在连续的 worker id 上运行多个 worker，轮询分发请求
+type pool struct {
+	workers []*worker
+	next    atomic.Uint64
+}
+
+// NewPool return a generator backed by n workers with consecutive worker ids starting at
+// baseWorkerID, multiplying per process throughput beyond one worker's sequence space.
+// Ids stay unique but are only roughly time ordered across the pool: ids of the same
+// millisecond sort by worker id, not by issue order.
+func NewPool(n int, baseWorkerID, datacenterID uint8, opts ...Option) (Generator, error) {
+	if n < 1 || int(baseWorkerID)+n-1 > 255 {
+		return nil, fmt.Errorf("invalid pool size %d from worker id %d", n, baseWorkerID)
+	}
+	p := &pool{}
+	for i := 0; i < n; i++ {
+		w, err := newWorker(baseWorkerID+uint8(i), datacenterID, opts...)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.workers = append(p.workers, w)
+	}
+	return p, nil
+}
+
+// pick 轮询选择 worker
+func (p *pool) pick() *worker {
+	return p.workers[(p.next.Add(1)-1)%uint64(len(p.workers))]
+}
+
+func (p *pool) Next() (int64, error) {
+	return p.pick().Next()
+}
+
+func (p *pool) NextContext(ctx context.Context) (int64, error) {
+	return p.pick().NextContext(ctx)
+}
+
+func (p *pool) NextN(n int) ([]int64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid n %d", n)
+	}
+	ids := make([]int64, n)
+	if err := p.Fill(ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Fill 按序列空间大小切分，每段交给一个 worker
+func (p *pool) Fill(dst []int64) error {
+	chunk := int(p.workers[0].layout.sequenceMask()) + 1
+	for len(dst) > 0 {
+		c := min(len(dst), chunk)
+		if err := p.pick().Fill(dst[:c]); err != nil {
+			return err
+		}
+		dst = dst[c:]
+	}
+	return nil
+}
+
+func (p *pool) NextNContext(ctx context.Context, n int) ([]int64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid n %d", n)
+	}
+	ids := make([]int64, 0, min(n, 1<<16))
+	for len(ids) < n {
+		part, err := p.pick().NextNContext(ctx, min(n-len(ids), nextNChunk))
+		ids = append(ids, part...)
+		if err != nil {
+			return ids, err
+		}
+	}
+	return ids, nil
+}
+
+func (p *pool) Close() error {
+	for _, w := range p.workers {
+		w.Close()
+	}
+	return nil
+}
+
+// Stats return the summed statistics of all workers
+func (p *pool) Stats() Stats {
+	var s Stats
+	for _, w := range p.workers {
+		s.add(w.Stats())
+	}
+	return s
+}
+
+// Saturation return the average saturation of the workers
+func (p *pool) Saturation() float64 {
+	var total float64
+	for _, w := range p.workers {
+		total += w.Saturation()
+	}
+	return total / float64(len(p.workers))
+}
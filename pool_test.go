@@ -0,0 +1,67 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"sync"
+	"testing"
+)
+
+func Test_Pool(t *testing.T) {
+	g, err := NewPool(4, 8, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	var mu sync.Mutex
+	seen := map[int64]bool{}
+	workers := map[int64]int{}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 2000; j++ {
+				id, err := g.Next()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("duplicate id %d", id)
+				}
+				seen[id] = true
+				workers[DefaultLayout.Decompose(ID(id)).WorkerID]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	for wk := int64(8); wk < 12; wk++ {
+		if workers[wk] != 4000 {
+			t.Errorf("worker %d issued %d ids, want 4000", wk, workers[wk])
+		}
+	}
+
+	ids, err := g.NextN(20000)
+	if err != nil || len(ids) != 20000 {
+		t.Fatalf("NextN = %d, %v", len(ids), err)
+	}
+	for _, id := range ids {
+		if seen[id] {
+			t.Fatalf("duplicate id %d", id)
+		}
+		seen[id] = true
+	}
+	if s := g.Stats(); s.Issued != 36000 {
+		t.Errorf("Stats().Issued = %d", s.Issued)
+	}
+
+	if _, err := NewPool(0, 0, 0); err == nil {
+		t.Error("expected error for empty pool")
+	}
+	if _, err := NewPool(4, 30, 0); err == nil {
+		t.Error("expected error for worker ids beyond the layout")
+	}
+}
@@ -0,0 +1,62 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import "context"
+
+// Priority caller priority under sequence saturation, see WithPriorityReserve
+type Priority int
+
+const (
+	// PriorityNormal default priority
+	PriorityNormal Priority = iota
+	// PriorityHigh may use the reserved end of each millisecond's sequence space
+	PriorityHigh
+)
+
+type priorityKey struct{}
+
+// ContextWithPriority return ctx carrying p for NextContext
+func ContextWithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFrom return the priority carried by ctx, PriorityNormal by default
+func PriorityFrom(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	return p
+}
+
+// WithPriorityReserve reserve the last n sequence values of every millisecond for
+// PriorityHigh callers of NextContext: once the rest is used up, normal priority calls
+// (including Next and NextN) wait for the next millisecond while high priority ones continue.
+// n must leave normal callers part of the sequence space: 0 <= n < 2^sequence bits - 1
+func WithPriorityReserve(n int) Option {
+	return func(w *worker) {
+		w.reserve = int64(n)
+	}
+}
+
+// reserved 普通优先级调用是否需等待下一毫秒，调用方需持有 w.mutex
+func (w *worker) reserved(p Priority) bool {
+	if w.reserve == 0 || p >= PriorityHigh {
+		return false
+	}
+	return w.now() == w.lastTimestamp && w.sequence >= w.layout.sequenceMask()-w.reserve
+}
+
+// lockLane 获取 w.mutex，普通优先级在保留区内时释放锁等待下一毫秒
+func (w *worker) lockLane(ctx context.Context, p Priority) {
+	w.mutex.Lock()
+	w.yieldLane(ctx, p)
+}
+
+// yieldLane 普通优先级在保留区内时释放锁等待下一毫秒，调用方需持有 w.mutex
+func (w *worker) yieldLane(ctx context.Context, p Priority) {
+	for w.reserved(p) {
+		last := w.lastTimestamp
+		w.mutex.Unlock()
+		w.tilNextMillis(ctx, last, "reserve")
+		w.mutex.Lock()
+	}
+}
@@ -0,0 +1,83 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_PriorityReserve(t *testing.T) {
+	const reserve = 100
+	w, err := newWorker(1, 1, WithPriorityReserve(reserve))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 冻结时钟，普通优先级用完非保留区后会一直等待
+	var mu sync.Mutex
+	frozen := currentMillis() + 1000
+	w.now = func() int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		return frozen
+	}
+	w.customClock = true
+	for i := int64(0); i < w.layout.sequenceMask()-reserve+1; i++ {
+		if _, err := w.Next(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	done := make(chan int64)
+	go func() {
+		id, _ := w.NextContext(context.Background())
+		done <- id
+	}()
+	select {
+	case <-done:
+		t.Fatal("normal priority call should wait for the next millisecond")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	high := ContextWithPriority(context.Background(), PriorityHigh)
+	for i := 0; i < reserve; i++ {
+		id, err := w.NextContext(high)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := DefaultLayout.Decompose(ID(id)).Time.UnixMilli(); got != frozen {
+			t.Fatalf("high priority id from %d, want frozen %d", got, frozen)
+		}
+	}
+
+	mu.Lock()
+	frozen++
+	mu.Unlock()
+	select {
+	case id := <-done:
+		if got := DefaultLayout.Decompose(ID(id)).Time.UnixMilli(); got != frozen {
+			t.Errorf("waiting call got id from %d, want %d", got, frozen)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("normal priority call did not resume")
+	}
+	if PriorityFrom(context.Background()) != PriorityNormal {
+		t.Error("default priority should be normal")
+	}
+}
+
+func Test_PriorityReserveInvalid(t *testing.T) {
+	for _, n := range []int{-1, sequenceMask, sequenceMask + 1} {
+		if g, err := NewGenerator(1, 1, WithPriorityReserve(n)); err == nil {
+			g.Close()
+			t.Errorf("reserve %d accepted", n)
+		}
+	}
+	g, err := NewGenerator(1, 1, WithPriorityReserve(sequenceMask-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Close()
+}
@@ -0,0 +1,13 @@
+//go:build !tinygo && !snowflake_decode
+
+package snowflake
+
+import _ "unsafe" // go:linkname
+
+// runtime_procPin 将当前 goroutine 绑定到所在的 P 并返回其编号，期间不可阻塞
+//
+//go:linkname runtime_procPin runtime.procPin
+func runtime_procPin() int
+
+//go:linkname runtime_procUnpin runtime.procUnpin
+func runtime_procUnpin()
@@ -0,0 +1,10 @@
+//go:build tinygo && !snowflake_decode
+
+package snowflake
+
+// runtime_procPin TinyGo 没有 P，所有 goroutine 使用同一分片
+func runtime_procPin() int {
+	return 0
+}
+
+func runtime_procUnpin() {}
@@ -0,0 +1,60 @@
+package snowflake
+
+import (
+	"fmt"
+	"strings"
+)
+
+// proquint 字母表: 辅音 4 位，元音 2 位，每个 quint "辅元辅元辅" 表示 16 位
+const (
+	proquintConsonants = "bdfghjklmnprstvz"
+	proquintVowels     = "aiou"
+)
+
+// FormatProquint return pronounceable form of id, e.g. "babad-lusab-dizom-hamul",
+// for reading ids out loud in support workflows
+func FormatProquint(id ID) string {
+	var sb strings.Builder
+	sb.Grow(23)
+	v := uint64(id)
+	for shift := 48; shift >= 0; shift -= 16 {
+		if shift != 48 {
+			sb.WriteByte('-')
+		}
+		q := uint16(v >> shift)
+		sb.WriteByte(proquintConsonants[q>>12&0xf])
+		sb.WriteByte(proquintVowels[q>>10&0x3])
+		sb.WriteByte(proquintConsonants[q>>6&0xf])
+		sb.WriteByte(proquintVowels[q>>4&0x3])
+		sb.WriteByte(proquintConsonants[q&0xf])
+	}
+	return sb.String()
+}
+
+// ParseProquint parse FormatProquint output, case insensitive
+func ParseProquint(s string) (ID, error) {
+	quints := strings.Split(strings.ToLower(s), "-")
+	if len(quints) != 4 {
+		return 0, fmt.Errorf("invalid proquint %q: want 4 groups", s)
+	}
+	var v uint64
+	for _, q := range quints {
+		if len(q) != 5 {
+			return 0, fmt.Errorf("invalid proquint %q: group %q", s, q)
+		}
+		var bits uint64
+		for i := 0; i < 5; i++ {
+			alphabet, width := proquintConsonants, uint(4)
+			if i%2 == 1 {
+				alphabet, width = proquintVowels, 2
+			}
+			n := strings.IndexByte(alphabet, q[i])
+			if n < 0 {
+				return 0, fmt.Errorf("invalid proquint %q: unexpected %q", s, q[i])
+			}
+			bits = bits<<width | uint64(n)
+		}
+		v = v<<16 | bits
+	}
+	return ID(v), nil
+}
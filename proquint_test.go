@@ -0,0 +1,28 @@
+package snowflake
+
+import (
+	"testing"
+	"testing/quick"
+)
+
+func Test_Proquint(t *testing.T) {
+	// 标准示例: 127.0.0.1 = 0x7f000001 -> "lusab-babad"
+	if got := FormatProquint(0x7f000001); got != "babab-babab-lusab-babad" {
+		t.Errorf("FormatProquint(0x7f000001) = %q", got)
+	}
+	roundTrip := func(v int64) bool {
+		got, err := ParseProquint(FormatProquint(ID(v)))
+		return err == nil && got == ID(v)
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+	if got, err := ParseProquint("BABAB-BABAB-LUSAB-BABAD"); err != nil || got != 0x7f000001 {
+		t.Errorf("upper case parse = %d, %v", got, err)
+	}
+	for _, s := range []string{"", "babab-babab-lusab", "babab-babab-lusab-babac", "babab-babab-lusab-babadd"} {
+		if _, err := ParseProquint(s); err == nil {
+			t.Errorf("ParseProquint(%q) expected error", s)
+		}
+	}
+}
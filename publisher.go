@@ -0,0 +1,238 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Publisher sends a message to a message bus subject (topic), implement it on top of
+// a Kafka producer or use NATSPublisher
+type Publisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// EventPublisher forwards events to a Publisher from a background goroutine
+type EventPublisher struct {
+	p       Publisher
+	subject string
+	events  chan Event
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+	done    chan struct{}
+
+	mu     sync.RWMutex // 保护 closed，Handle 与 Close 互斥
+	closed bool
+}
+
+// PublishEvents start forwarding events as JSON to subject of p; use its Handle method with
+// WithEventHandler. Events are buffered and dropped when the buffer is full, so a slow
+// bus never delays id generation.
+func PublishEvents(p Publisher, subject string, buffer int) *EventPublisher {
+	ep := &EventPublisher{p: p, subject: subject, events: make(chan Event, buffer), done: make(chan struct{})}
+	go ep.run()
+	return ep
+}
+
+func (ep *EventPublisher) run() {
+	defer close(ep.done)
+	for e := range ep.events {
+		data, err := json.Marshal(e)
+		if err == nil {
+			err = ep.p.Publish(ep.subject, data)
+		}
+		if err != nil {
+			ep.failed.Add(1)
+		}
+	}
+}
+
+// Handle queue e for publishing, never blocks; events after Close are dropped
+func (ep *EventPublisher) Handle(e Event) {
+	ep.mu.RLock()
+	defer ep.mu.RUnlock()
+	if ep.closed {
+		ep.dropped.Add(1)
+		return
+	}
+	select {
+	case ep.events <- e:
+	default:
+		ep.dropped.Add(1)
+	}
+}
+
+// Dropped return number of events dropped because the buffer was full or the publisher closed
+func (ep *EventPublisher) Dropped() uint64 {
+	return ep.dropped.Load()
+}
+
+// Failed return number of events the publisher failed to send
+func (ep *EventPublisher) Failed() uint64 {
+	return ep.failed.Load()
+}
+
+// Close publish the queued events and stop, later Handle calls count as dropped
+func (ep *EventPublisher) Close() error {
+	ep.mu.Lock()
+	if !ep.closed {
+		ep.closed = true
+		close(ep.events)
+	}
+	ep.mu.Unlock()
+	<-ep.done
+	return nil
+}
+
+// NATSPublisher minimal NATS core publisher speaking the text protocol (CONNECT/PUB),
+// enough for fire-and-forget event publishing without a client dependency. A background
+// reader answers the server's PINGs. A server -ERR is returned by the next Publish, wrapped
+// as an earlier-publish error, and that call still reconnects and sends its own message
+type NATSPublisher struct {
+	addr    string
+	timeout time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn // nil 表示需要重新连接
+	w      *bufio.Writer
+	err    error // 服务器的 -ERR，由下一次 Publish 返回
+	closed bool
+}
+
+// DefaultNATSTimeout timeout used by DialNATS when given 0
+const DefaultNATSTimeout = 5 * time.Second
+
+// DialNATS connect to NATS server at addr (host:port); timeout bounds each (re)connection
+// and each write, so a stalled server fails Publish instead of blocking it and Close.
+// 0 means DefaultNATSTimeout
+func DialNATS(addr string, timeout time.Duration) (*NATSPublisher, error) {
+	if timeout <= 0 {
+		timeout = DefaultNATSTimeout
+	}
+	p := &NATSPublisher{addr: addr, timeout: timeout}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.connect(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// connect 建立连接并启动读协程，调用方需持有 p.mu
+func (p *NATSPublisher) connect() error {
+	conn, err := net.DialTimeout("tcp", p.addr, p.timeout)
+	if err != nil {
+		return err
+	}
+	conn.SetReadDeadline(time.Now().Add(p.timeout))
+	r := bufio.NewReader(conn)
+	info, err := r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(info, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("nats: unexpected greeting %q: %v", info, err)
+	}
+	conn.SetReadDeadline(time.Time{})
+	w := bufio.NewWriter(conn)
+	w.WriteString("CONNECT {\"verbose\":false,\"pedantic\":false,\"name\":\"snowflake\"}\r\n")
+	conn.SetWriteDeadline(time.Now().Add(p.timeout))
+	if err := w.Flush(); err != nil {
+		conn.Close()
+		return err
+	}
+	p.conn, p.w = conn, w
+	go p.read(conn, r)
+	return nil
+}
+
+// drop 关闭当前连接，下次 Publish 重新连接，调用方需持有 p.mu
+func (p *NATSPublisher) drop() {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+	}
+}
+
+// flush 在 timeout 内写出缓冲，调用方需持有 p.mu 且连接存在
+func (p *NATSPublisher) flush() error {
+	p.conn.SetWriteDeadline(time.Now().Add(p.timeout))
+	return p.w.Flush()
+}
+
+// read 应答 PING 并记录 -ERR，连接出错或被替换后退出
+func (p *NATSPublisher) read(conn net.Conn, r *bufio.Reader) {
+	for {
+		line, err := r.ReadString('\n')
+		p.mu.Lock()
+		current := p.conn == conn
+		switch {
+		case !current:
+		case err != nil:
+			p.drop()
+		case strings.HasPrefix(line, "PING"):
+			p.w.WriteString("PONG\r\n")
+			if p.flush() != nil {
+				p.drop()
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			// 服务器发送 -ERR 后通常会关闭连接
+			p.err = fmt.Errorf("nats: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+			p.drop()
+		}
+		p.mu.Unlock()
+		if err != nil || !current {
+			return
+		}
+	}
+}
+
+// Publish send data to subject, reconnecting first when the connection was lost
+func (p *NATSPublisher) Publish(subject string, data []byte) error {
+	if subject == "" || strings.ContainsAny(subject, " \t\r\n") {
+		return fmt.Errorf("nats: invalid subject %q", subject)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return net.ErrClosed
+	}
+	// 上一条消息收到的 -ERR 照常上报, 但本条消息仍然发送
+	var earlier error
+	if p.err != nil {
+		earlier = fmt.Errorf("earlier publish: %w", p.err)
+		p.err = nil
+	}
+	return errors.Join(earlier, p.publish(subject, data))
+}
+
+func (p *NATSPublisher) publish(subject string, data []byte) error {
+	if p.conn == nil {
+		if err := p.connect(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(p.w, "PUB %s %d\r\n", subject, len(data))
+	p.w.Write(data)
+	p.w.WriteString("\r\n")
+	if err := p.flush(); err != nil {
+		p.drop()
+		return err
+	}
+	return nil
+}
+
+// Close close the connection
+func (p *NATSPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	p.drop()
+	return nil
+}
@@ -0,0 +1,176 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrScheduleEnd returned by replay generators once every recorded id was issued
+var ErrScheduleEnd = errors.New("snowflake: replay schedule exhausted")
+
+// Step one issued id of a recorded run
+type Step struct {
+	Millis   int64 // unix milliseconds of the id's timestamp
+	Sequence int64
+	WorkerID int64 // differs between steps only with WithRandomMachine
+}
+
+// Schedule ids issued by a recorded run, JSON encodable for attaching to bug reports
+type Schedule struct {
+	Layout       Layout
+	DatacenterID int64
+	Steps        []Step
+}
+
+// Recorder capture the schedule of the worker it is passed to with WithRecorder
+type Recorder struct {
+	mu       sync.Mutex
+	schedule Schedule
+}
+
+// WithRecorder append every id the worker issues to rec, in issue order, so the run can be
+// reproduced with NewReplay; pair with WithRandomSeed to also make a fresh run deterministic
+func WithRecorder(rec *Recorder) Option {
+	return func(w *worker) {
+		w.recorder = rec
+	}
+}
+
+// WithRandomSeed draw the random machine bits of WithRandomMachine from a generator seeded
+// with seed instead of the system's secure source, so runs with the same seed and clock issue
+// the same ids. Only for tests and reproductions: processes sharing a seed collide.
+func WithRandomSeed(seed uint64) Option {
+	return func(w *worker) {
+		w.rand = rand.New(rand.NewPCG(seed, seed))
+	}
+}
+
+// record 记录一次签发，调用方持有 worker 的锁
+func (r *Recorder) record(millis, sequence, workerID int64) {
+	r.mu.Lock()
+	r.schedule.Steps = append(r.schedule.Steps, Step{Millis: millis, Sequence: sequence, WorkerID: workerID})
+	r.mu.Unlock()
+}
+
+// bind 记录 worker 的最终布局和数据标识，newWorker 完成配置后调用
+func (r *Recorder) bind(l Layout, datacenterID int64) {
+	r.mu.Lock()
+	r.schedule.Layout, r.schedule.DatacenterID = l, datacenterID
+	r.mu.Unlock()
+}
+
+// Schedule return a copy of the schedule recorded so far
+func (r *Recorder) Schedule() Schedule {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.schedule
+	s.Steps = slices.Clone(s.Steps)
+	return s
+}
+
+// replay 按记录的顺序重新签发 id
+type replay struct {
+	mu     sync.Mutex
+	s      Schedule
+	next   int
+	closed bool
+}
+
+// NewReplay return a generator issuing exactly the ids of s, in the recorded order, then
+// ErrScheduleEnd; it reads no clock, so a bug depending on the ids reproduces at any time
+func NewReplay(s Schedule) (Generator, error) {
+	if err := s.Layout.Validate(); err != nil {
+		return nil, err
+	}
+	if s.DatacenterID > s.Layout.maxDatacenterID() {
+		return nil, fmt.Errorf("datacenter Id can't be greater than %d or less than 0", s.Layout.maxDatacenterID())
+	}
+	epoch := s.Layout.Epoch.UnixMilli()
+	for i, step := range s.Steps {
+		if e := step.Millis - epoch; e < 0 || e > s.Layout.maxElapsed() || step.Sequence > s.Layout.sequenceMask() ||
+			step.Sequence < 0 || step.WorkerID > s.Layout.maxWorkerID() || step.WorkerID < 0 {
+			return nil, fmt.Errorf("step %d %+v does not fit the layout", i, step)
+		}
+	}
+	return &replay{s: s}, nil
+}
+
+func (r *replay) Next() (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, ErrClosed
+	}
+	if r.next == len(r.s.Steps) {
+		return 0, ErrScheduleEnd
+	}
+	step := r.s.Steps[r.next]
+	r.next++
+	l := r.s.Layout
+	return int64(l.compose(step.Millis-l.Epoch.UnixMilli(), r.s.DatacenterID, step.WorkerID, step.Sequence)), nil
+}
+
+func (r *replay) NextContext(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.Next()
+}
+
+func (r *replay) NextN(n int) ([]int64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid n %d", n)
+	}
+	ids := make([]int64, n)
+	if err := r.Fill(ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *replay) NextNContext(ctx context.Context, n int) ([]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.NextN(n)
+}
+
+func (r *replay) Fill(dst []int64) error {
+	for i := range dst {
+		id, err := r.Next()
+		if err != nil {
+			return err
+		}
+		dst[i] = id
+	}
+	return nil
+}
+
+func (r *replay) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+func (r *replay) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := Stats{Issued: uint64(r.next)}
+	if r.next > 0 {
+		s.LastIssued = time.UnixMilli(r.s.Steps[r.next-1].Millis)
+	}
+	return s
+}
+
+// Saturation return 0, replays do not wait
+func (r *replay) Saturation() float64 {
+	return 0
+}
@@ -0,0 +1,76 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_RecordReplay(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := DefaultLayout
+	l.SequenceBits = 2
+	run := func(rec *Recorder) []int64 {
+		var reads atomic.Int64
+		clock := func() time.Time { return base.Add(time.Duration(reads.Add(1)/8) * time.Millisecond) }
+		g, err := NewGenerator(0, 0, WithLayout(l), WithClock(clock), WithRandomMachine(), WithRandomSeed(42), WithRecorder(rec))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids, err := g.NextN(200)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ids
+	}
+	rec := &Recorder{}
+	ids := run(rec)
+	// 同一种子和时钟得到相同的 id，包括换过的随机机器位
+	if again := run(&Recorder{}); !slices.Equal(again, ids) {
+		t.Fatal("runs with the same seed differ")
+	}
+
+	b, err := json.Marshal(rec.Schedule())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s Schedule
+	if err := json.Unmarshal(b, &s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Steps) != len(ids) {
+		t.Fatalf("recorded %d steps, want %d", len(s.Steps), len(ids))
+	}
+	machines := map[int64]bool{}
+	for _, step := range s.Steps {
+		machines[step.WorkerID] = true
+	}
+	if len(machines) < 2 {
+		t.Errorf("expected rerolled machine bits, got %v", machines)
+	}
+
+	g, err := NewReplay(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := g.NextN(len(ids))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(replayed, ids) {
+		t.Error("replayed ids differ from the recorded run")
+	}
+	if _, err := g.Next(); !errors.Is(err, ErrScheduleEnd) {
+		t.Errorf("after the schedule: %v", err)
+	}
+
+	s.Steps = append(s.Steps, Step{Millis: base.UnixMilli(), Sequence: 4})
+	if _, err := NewReplay(s); err == nil {
+		t.Error("sequence beyond the layout accepted")
+	}
+}
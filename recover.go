@@ -0,0 +1,120 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// PanicError a panic converted to an error by Recover
+type PanicError struct {
+	Value any    // value passed to panic
+	Stack []byte // stack of the panicking goroutine
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("snowflake: panic: %v", e.Value)
+}
+
+// Unwrap return the panic value if it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Recover wrap g so that panics, e.g. from event handlers, loggers or a custom Worker
+// behind AsGenerator, are returned as *PanicError with the stack instead of unwinding
+// into the caller. A panic may interrupt g in an unknown state, so the wrapper fails:
+// every later call returns the same *PanicError, only Close still reaches g;
+// Stats and Saturation return zero values when they panic
+func Recover(g Generator) Generator {
+	return &recovered{g: g}
+}
+
+type recovered struct {
+	g      Generator
+	failed atomic.Pointer[PanicError] // 首次 panic 后记录，之后不再调用 g
+}
+
+// catch 将 panic 转换为 *PanicError 写入 err，并标记失效
+func (r *recovered) catch(err *error) {
+	if v := recover(); v != nil {
+		pe := &PanicError{Value: v, Stack: debug.Stack()}
+		r.failed.CompareAndSwap(nil, pe)
+		*err = pe
+	}
+}
+
+// check 返回此前 panic 的错误
+func (r *recovered) check() error {
+	if pe := r.failed.Load(); pe != nil {
+		return pe
+	}
+	return nil
+}
+
+func (r *recovered) Next() (id int64, err error) {
+	if err := r.check(); err != nil {
+		return 0, err
+	}
+	defer r.catch(&err)
+	return r.g.Next()
+}
+
+func (r *recovered) NextN(n int) (ids []int64, err error) {
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	defer r.catch(&err)
+	return r.g.NextN(n)
+}
+
+func (r *recovered) Fill(dst []int64) (err error) {
+	if err := r.check(); err != nil {
+		return err
+	}
+	defer r.catch(&err)
+	return Fill(r.g, dst)
+}
+
+func (r *recovered) NextContext(ctx context.Context) (id int64, err error) {
+	if err := r.check(); err != nil {
+		return 0, err
+	}
+	defer r.catch(&err)
+	return r.g.NextContext(ctx)
+}
+
+func (r *recovered) NextNContext(ctx context.Context, n int) (ids []int64, err error) {
+	if err := r.check(); err != nil {
+		return nil, err
+	}
+	defer r.catch(&err)
+	return NextNContext(ctx, r.g, n)
+}
+
+func (r *recovered) Close() (err error) {
+	defer r.catch(&err)
+	return r.g.Close()
+}
+
+func (r *recovered) Stats() (s Stats) {
+	defer func() { recover() }()
+	return r.g.Stats()
+}
+
+func (r *recovered) Saturation() (f float64) {
+	defer func() { recover() }()
+	return Saturation(r.g)
+}
+
+// notify 在后台 goroutine 中持锁调用 f，忽略用户回调的 panic，避免整个进程退出
+func (w *worker) notify(f func()) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	defer func() { recover() }()
+	f()
+}
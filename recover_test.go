@@ -0,0 +1,183 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type panickyWorker struct{}
+
+func (panickyWorker) Next() (int64, error) {
+	panic(errors.New("boom"))
+}
+
+func Test_Recover(t *testing.T) {
+	g := Recover(AsGenerator(panickyWorker{}))
+	_, err := g.Next()
+	var pe *PanicError
+	if !errors.As(err, &pe) || !strings.Contains(string(pe.Stack), "panickyWorker") || pe.Unwrap().Error() != "boom" {
+		t.Fatalf("Next = %v", err)
+	}
+	if _, err := g.NextN(3); !errors.As(err, &pe) {
+		t.Errorf("NextN = %v", err)
+	}
+	if err := Fill(g, make([]int64, 2)); !errors.As(err, &pe) {
+		t.Errorf("Fill = %v", err)
+	}
+
+	// 事件处理函数 panic 后 worker 仍可用，锁已释放
+	var panics atomic.Int32
+	w, err := newWorker(1, 1, WithEventHandler(func(Event) {
+		panics.Add(1)
+		panic("handler")
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rg := Recover(w)
+	w.mutex.Lock()
+	w.lastTimestamp = currentMillis() + 20
+	w.mutex.Unlock()
+	if _, err := rg.Next(); !errors.As(err, &pe) || pe.Value != "handler" {
+		t.Errorf("Next with panicking handler = %v", err)
+	}
+	// panic 后包装失效，后续调用返回同一个错误，锁已释放
+	if _, err := rg.NextContext(context.Background()); err != pe {
+		t.Errorf("NextContext after recovered panic = %v, want %v", err, pe)
+	}
+	time.Sleep(25 * time.Millisecond)
+	if _, err := w.Next(); err != nil {
+		t.Errorf("worker locked after recovered panic: %v", err)
+	}
+	if rg.Stats().Issued != 1 {
+		t.Errorf("Stats = %+v", rg.Stats())
+	}
+	if err := rg.Close(); err != nil {
+		t.Error(err)
+	}
+	if _, err := w.Next(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Close did not reach the worker: %v", err)
+	}
+}
+
+func Test_RecoverSequenceExhausted(t *testing.T) {
+	// 时钟冻结在一毫秒内，序列用尽后由测试推进
+	var now atomic.Int64
+	now.Store(DefaultLayout.Epoch.UnixMilli() + 1000)
+	var panicked atomic.Bool
+	newPanicky := func() *worker {
+		w, err := newWorker(1, 1, WithClock(func() time.Time { return time.UnixMilli(now.Load()) }), WithEventHandler(func(e Event) {
+			if e.Type == EventSequenceExhausted && panicked.CompareAndSwap(false, true) {
+				panic("handler")
+			}
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return w
+	}
+	advance := func(w *worker) {
+		for w.Stats().Issued < 4096 {
+			time.Sleep(time.Millisecond)
+		}
+		now.Add(1)
+	}
+
+	w := newPanicky()
+	go advance(w)
+	seen := map[int64]int{}
+	for i := 1; i <= 4096+100; i++ {
+		id, err := func() (id int64, err error) {
+			defer func() {
+				if v := recover(); v != nil {
+					err = fmt.Errorf("panic: %v", v)
+				}
+			}()
+			return w.Next()
+		}()
+		if err != nil {
+			if i != 4097 {
+				t.Fatalf("call %d: %v", i, err)
+			}
+			continue
+		}
+		if j, ok := seen[id]; ok {
+			t.Fatalf("duplicate id %d at call %d, first at call %d", id, i, j)
+		}
+		seen[id] = i
+	}
+	if !panicked.Load() {
+		t.Fatal("handler did not panic")
+	}
+
+	now.Add(1)
+	panicked.Store(false)
+	rg := Recover(newPanicky())
+	go advance(rg.(*recovered).g.(*worker))
+	for i := 1; i <= 4096; i++ {
+		if _, err := rg.Next(); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	var pe *PanicError
+	if _, err := rg.Next(); !errors.As(err, &pe) {
+		t.Fatalf("Next with panicking handler = %v", err)
+	}
+	if _, err := rg.Next(); err != pe {
+		t.Errorf("Next after recovered panic = %v", err)
+	}
+}
+
+func Test_BackgroundPanicContained(t *testing.T) {
+	var calls atomic.Int32
+	checker := LeaseCheckerFunc(func(ctx context.Context) error {
+		if calls.Add(1) > 1 {
+			return errors.New("lost")
+		}
+		return nil
+	})
+	w, err := newWorker(1, 1, WithLeaseCheck(checker, 5*time.Millisecond, time.Minute), WithEventHandler(func(Event) { panic("handler") }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	time.Sleep(30 * time.Millisecond)
+	if calls.Load() < 3 {
+		t.Errorf("lease checks stopped after a handler panic: %d", calls.Load())
+	}
+}
+
+// 非法的时长作为配置错误返回，不会在后台 goroutine 中 panic
+func Test_NewGeneratorInvalidDurations(t *testing.T) {
+	ok := LeaseCheckerFunc(func(context.Context) error { return nil })
+	src := &fakeSkew{}
+	for _, d := range []time.Duration{0, -time.Second} {
+		for name, opt := range map[string]Option{
+			"lease interval": WithLeaseCheck(ok, d, time.Second),
+			"lease ttl":      WithLeaseCheck(ok, time.Second, d),
+			"skew threshold": WithSkewGuard(src, d, time.Second),
+			"skew interval":  WithSkewGuard(src, time.Second, d),
+		} {
+			if g, err := NewGenerator(1, 1, opt); err == nil {
+				g.Close()
+				t.Errorf("%s %v accepted", name, d)
+			}
+		}
+	}
+	for name, opt := range map[string]Option{
+		"clock smear":  WithClockSmear(-time.Second),
+		"borrow ahead": WithBorrowAhead(-time.Second),
+	} {
+		if g, err := NewGenerator(1, 1, opt); err == nil {
+			g.Close()
+			t.Errorf("negative %s accepted", name)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// redisConn 最小的 RESP2 客户端：单连接，命令由 mutex 串行化，出错后重新连接
+type redisConn struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// redisError Redis 返回的错误回复
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// do 发送命令并读取回复：简单字符串为 string，整数为 int64，批量字符串为 []byte 或 nil，数组为 []any
+func (c *redisConn) do(ctx context.Context, args ...string) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		if err := c.dial(ctx); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := c.roundTrip(ctx, args)
+	var re redisError
+	if err != nil && !errors.As(err, &re) {
+		// 连接状态未知，下次重新连接
+		c.conn.Close()
+		c.conn = nil
+	}
+	return reply, err
+}
+
+func (c *redisConn) dial(ctx context.Context) error {
+	d := net.Dialer{Timeout: c.timeout}
+	conn, err := d.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return err
+	}
+	c.conn, c.r = conn, bufio.NewReader(conn)
+	var setup [][]string
+	if c.password != "" {
+		setup = append(setup, []string{"AUTH", c.password})
+	}
+	if c.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.db)})
+	}
+	for _, args := range setup {
+		if _, err := c.roundTrip(ctx, args); err != nil {
+			conn.Close()
+			c.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *redisConn) roundTrip(ctx context.Context, args []string) (any, error) {
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && (c.timeout <= 0 || d.Before(deadline)) {
+		deadline = d
+	} else if c.timeout <= 0 {
+		deadline = time.Time{}
+	}
+	c.conn.SetDeadline(deadline)
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, a := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(a))+"\r\n"...)
+		buf = append(buf, a...)
+		buf = append(buf, "\r\n"...)
+	}
+	if _, err := c.conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return readRESP(c.r)
+}
+
+func readRESP(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", body)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("redis: malformed array length %q", body)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = readRESP(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
+
+func (c *redisConn) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// RedisStateStore StateStore keeping the mark under a Redis key
+type RedisStateStore struct {
+	c   redisConn
+	key string
+}
+
+// NewRedisStateStore return a store for key on the Redis server at addr (host:port);
+// password and db are optional (empty and 0), timeout bounds each command
+func NewRedisStateStore(addr, password string, db int, key string, timeout time.Duration) *RedisStateStore {
+	return &RedisStateStore{c: redisConn{addr: addr, password: password, db: db, timeout: timeout}, key: key}
+}
+
+// Load GET the mark, 0 when the key does not exist
+func (s *RedisStateStore) Load(ctx context.Context) (int64, error) {
+	reply, err := s.c.do(ctx, "GET", s.key)
+	if err != nil || reply == nil {
+		return 0, err
+	}
+	b, ok := reply.([]byte)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected GET reply %v", reply)
+	}
+	return strconv.ParseInt(string(b), 10, 64)
+}
+
+// redisRaiseScript 仅在新值更大时写入上限
+const redisRaiseScript = `local v = tonumber(redis.call('GET', KEYS[1])) if v == nil or v < tonumber(ARGV[1]) then redis.call('SET', KEYS[1], ARGV[1]) return 1 end return 0`
+
+// Store raise the mark atomically with a script, keeping a higher stored mark
+func (s *RedisStateStore) Store(ctx context.Context, millis int64) error {
+	_, err := s.c.do(ctx, "EVAL", redisRaiseScript, "1", s.key, strconv.FormatInt(millis, 10))
+	return err
+}
+
+// Close close the connection
+func (s *RedisStateStore) Close() error {
+	return s.c.close()
+}
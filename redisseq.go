@@ -0,0 +1,76 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// redisSequenceTTL 每毫秒计数键的过期时间，须大于节点间的时钟偏差，
+// 否则落后的节点会重建已过期的键并重复使用序列
+const redisSequenceTTL = time.Minute
+
+// redisSequence 从 Redis 分配序列，见 WithRedisSequence
+type redisSequence struct {
+	c      redisConn
+	prefix string
+}
+
+// WithRedisSequence allocate the sequence from Redis instead of a local counter: every id
+// INCRs the key prefix:<unix millis> on the server at addr (host:port; password and db are
+// optional, timeout bounds each command), so all nodes sharing the server and prefix draw one
+// sequence per millisecond and the ids of a millisecond are totally ordered across nodes.
+// Give every node the same datacenter and worker ids (e.g. 0), the counter keeps ids unique
+// and differing machine bits would order a millisecond by node first; a layout moving the
+// machine bits into the sequence (e.g. 41 timestamp + 22 sequence bits) raises the shared
+// per-millisecond capacity. Once the counter passes the sequence bits the node waits for the
+// next millisecond. Ids of different milliseconds are ordered by the nodes' clocks, keep them
+// within a minute of each other (keys expire after a minute), e.g. with WithSkewGuard.
+// Costs one round trip per id, two for the first id of a millisecond, taken while holding
+// the worker's lock: a node issues at most one id per round trip, and Next fails when Redis
+// is unreachable.
+func WithRedisSequence(addr, password string, db int, prefix string, timeout time.Duration) Option {
+	return func(w *worker) {
+		w.redisSeq = &redisSequence{c: redisConn{addr: addr, password: password, db: db, timeout: timeout}, prefix: prefix}
+	}
+}
+
+// incr 分配 timestamp 这一毫秒的下一个计数，从 1 开始
+func (s *redisSequence) incr(ctx context.Context, timestamp int64) (int64, error) {
+	key := s.prefix + ":" + strconv.FormatInt(timestamp, 10)
+	reply, err := s.c.do(ctx, "INCR", key)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected INCR reply %v", reply)
+	}
+	if n == 1 {
+		// 首个 id 设置过期；失败时键只是不会过期，不影响唯一性
+		if _, err := s.c.do(ctx, "PEXPIRE", key, strconv.FormatInt(redisSequenceTTL.Milliseconds(), 10)); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// redisNext 从 Redis 取得 timestamp 或之后某一毫秒的序列，用尽时等待下一毫秒，调用方需持有 w.mutex
+func (w *worker) redisNext(ctx context.Context, timestamp int64) (int64, int64, error) {
+	for {
+		n, err := w.redisSeq.incr(ctx, timestamp)
+		if err != nil {
+			return 0, 0, err
+		}
+		if n-1 <= w.layout.sequenceMask() {
+			return timestamp, n - 1, nil
+		}
+		w.counters.rollovers.Add(1)
+		w.emit(EventSequenceExhausted, 0)
+		w.saturation.record(timestamp)
+		timestamp = w.tilNextMillis(ctx, timestamp, "rollover")
+	}
+}
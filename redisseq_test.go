@@ -0,0 +1,73 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_RedisSequence(t *testing.T) {
+	addr := fakeRedis(t)
+	var ms atomic.Int64
+	ms.Store(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC).UnixMilli())
+	clock := func() time.Time { return time.UnixMilli(ms.Load()) }
+	layout := Layout{Epoch: DefaultLayout.Epoch, TimestampBits: 41, SequenceBits: 2}
+	newNode := func() Generator {
+		g, err := NewGenerator(0, 0, WithLayout(layout), WithClock(clock), WithRedisSequence(addr, "", 0, "seq", time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { g.Close() })
+		return g
+	}
+	a, b := newNode(), newNode()
+
+	// 两个节点交替发号，同一毫秒内共享一个序列，整体严格递增
+	var ids []int64
+	for i := range 4 {
+		g := a
+		if i%2 == 1 {
+			g = b
+		}
+		id, err := g.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	for i, id := range ids {
+		if p := layout.Decompose(ID(id)); p.Sequence != int64(i) || p.Time.UnixMilli() != ms.Load() {
+			t.Errorf("id %d = %+v, want sequence %d", i, p, i)
+		}
+	}
+
+	// 序列用尽后等待下一毫秒
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		ms.Add(1)
+	}()
+	id, err := a.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := layout.Decompose(ID(id)); p.Sequence != 0 || id <= ids[3] {
+		t.Errorf("after exhaustion %d = %+v", id, p)
+	}
+	if st := a.Stats(); st.Rollovers != 1 {
+		t.Errorf("Rollovers = %d", st.Rollovers)
+	}
+
+	down, err := NewGenerator(0, 0, WithRedisSequence("127.0.0.1:1", "", 0, "seq", time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer down.Close()
+	if _, err := down.Next(); err == nil {
+		t.Error("Next succeeded without Redis")
+	}
+	if _, err := NewGenerator(0, 0, WithRandomMachine(), WithRedisSequence(addr, "", 0, "seq", time.Second)); err == nil {
+		t.Error("random machine bits accepted with a Redis sequence")
+	}
+}
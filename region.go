@@ -0,0 +1,80 @@
+package snowflake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// RegionRegistry maps region/zone names to datacenter ids, so multi-region deployments
+// assign datacenter bits consistently and decoded ids show "us-east-1" instead of "3"
+type RegionRegistry struct {
+	mu     sync.RWMutex
+	byName map[string]uint8
+	byID   map[uint8]string
+}
+
+// Regions registry used by Decompose and Describe
+var Regions = NewRegionRegistry()
+
+// NewRegionRegistry return empty registry
+func NewRegionRegistry() *RegionRegistry {
+	return &RegionRegistry{byName: make(map[string]uint8), byID: make(map[uint8]string)}
+}
+
+// LoadRegions read a JSON object mapping region names to datacenter ids, e.g.
+// {"us-east-1": 1, "eu-west-1": 2}, into a new registry
+func LoadRegions(r io.Reader) (*RegionRegistry, error) {
+	var m map[string]uint8
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid region config: %v", err)
+	}
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	reg := NewRegionRegistry()
+	for _, name := range names {
+		if err := reg.Register(name, m[name]); err != nil {
+			return nil, err
+		}
+	}
+	return reg, nil
+}
+
+// Register map name to datacenterID, each name and each id may only be registered once
+func (r *RegionRegistry) Register(name string, datacenterID uint8) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id, ok := r.byName[name]; ok && id != datacenterID {
+		return fmt.Errorf("region %q is already mapped to datacenter %d", name, id)
+	}
+	if other, ok := r.byID[datacenterID]; ok && other != name {
+		return fmt.Errorf("datacenter %d is already mapped to region %q", datacenterID, other)
+	}
+	r.byName[name] = datacenterID
+	r.byID[datacenterID] = name
+	return nil
+}
+
+// DatacenterID return the datacenter id of region name
+func (r *RegionRegistry) DatacenterID(name string) (uint8, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	id, ok := r.byName[name]
+	return id, ok
+}
+
+// Name return the region name of datacenterID
+func (r *RegionRegistry) Name(datacenterID int64) (string, bool) {
+	if datacenterID < 0 || datacenterID > 0xff {
+		return "", false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	name, ok := r.byID[uint8(datacenterID)]
+	return name, ok
+}
@@ -0,0 +1,46 @@
+package snowflake
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_LoadRegions(t *testing.T) {
+	r, err := LoadRegions(strings.NewReader(`{"us-east-1": 3, "eu-west-1": 4}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, ok := r.DatacenterID("us-east-1"); !ok || id != 3 {
+		t.Errorf("DatacenterID(us-east-1) = %d, %v", id, ok)
+	}
+	if name, ok := r.Name(4); !ok || name != "eu-west-1" {
+		t.Errorf("Name(4) = %q, %v", name, ok)
+	}
+	if _, ok := r.Name(5); ok {
+		t.Error("Name(5) should be unknown")
+	}
+	if err := r.Register("ap-east-1", 3); err == nil {
+		t.Error("expected error registering a used datacenter id")
+	}
+	if err := r.Register("us-east-1", 5); err == nil {
+		t.Error("expected error remapping a region")
+	}
+	for _, cfg := range []string{`{"a": 1, "b": 1}`, `{"a": 256}`, `[]`} {
+		if _, err := LoadRegions(strings.NewReader(cfg)); err == nil {
+			t.Errorf("LoadRegions(%s) expected error", cfg)
+		}
+	}
+}
+
+func Test_DescribeRegion(t *testing.T) {
+	if err := Regions.Register("test-region-9", 9); err != nil {
+		t.Fatal(err)
+	}
+	id := ID(1<<timestampLeftShift | 9<<datacenterIDShift)
+	if p := Decompose(id); p.Region != "test-region-9" {
+		t.Errorf("Decompose(%d).Region = %q", id, p.Region)
+	}
+	if got := Describe(id); !strings.Contains(got, "datacenter=test-region-9") {
+		t.Errorf("Describe(%d) = %q", id, got)
+	}
+}
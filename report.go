@@ -0,0 +1,103 @@
+package snowflake
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxSafeInteger 2^53-1，JavaScript number 和 JSON 解析器能精确表示的最大整数
+const maxSafeInteger = 1<<53 - 1
+
+// reportHorizon 时间戳在此期限内用尽时给出警告
+const reportHorizon = 10 * 365 * 24 * time.Hour
+
+// LayoutReport capacity and interop analysis of a layout, see Layout.Report
+type LayoutReport struct {
+	Datacenters   int64     // distinct datacenter ids
+	WorkersPerDC  int64     // distinct worker ids per datacenter
+	Nodes         int64     // distinct datacenter/worker pairs
+	IDsPerMilli   int64     // ids per millisecond per node
+	Exhausted     time.Time // first millisecond beyond the timestamp bits
+	JSSafeUntil   time.Time // first millisecond issuing an id above 2^53-1, zero if none does
+	NegativeFrom  time.Time // first millisecond issuing an id with the top bit set, zero if none does
+	MaySetSignBit bool      // a version of the layout could issue negative ids, see Layout.MaySetSignBit
+	Warnings      []string  // problems to fix before deploying
+	Invalid       error     // Layout.Validate result, the other fields are zero when set
+}
+
+// Report analyze the layout: node and per millisecond capacity, when the timestamp runs out,
+// when ids stop being exact in JavaScript and when they turn negative as signed integers,
+// with warnings for layouts that run out within ten years, start in the future or can set
+// the sign bit, so misconfigured layouts are caught in code review
+func (l Layout) Report() LayoutReport {
+	if err := l.Validate(); err != nil {
+		return LayoutReport{Invalid: err, Warnings: []string{err.Error()}}
+	}
+	r := LayoutReport{
+		Datacenters:   l.maxDatacenterID() + 1,
+		WorkersPerDC:  l.maxWorkerID() + 1,
+		Nodes:         (l.maxDatacenterID() + 1) * (l.maxWorkerID() + 1),
+		IDsPerMilli:   l.sequenceMask() + 1,
+		Exhausted:     l.millis(l.maxElapsed() + 1),
+		MaySetSignBit: l.MaySetSignBit(),
+	}
+	// 本版本 id 的下界来自版本位，ms 毫秒内的最大 id 为 base + (ms+1)<<shift - 1
+	base := uint64(l.Version) << l.versionShift()
+	if base > maxSafeInteger {
+		r.JSSafeUntil = l.Epoch
+	} else if ms := int64((maxSafeInteger + 1 - base) >> l.timestampShift()); ms <= l.maxElapsed() {
+		r.JSSafeUntil = l.millis(ms)
+	}
+	if l.bits() == 64 {
+		if l.VersionBits > 0 {
+			if l.Version>>(l.VersionBits-1) != 0 {
+				r.NegativeFrom = l.Epoch
+			}
+		} else {
+			r.NegativeFrom = l.millis(1 << (l.TimestampBits - 1))
+		}
+	}
+
+	now := time.Now()
+	if l.Epoch.After(now) {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("epoch %s is in the future, ids cannot be issued before it", l.Epoch.UTC().Format(time.RFC3339)))
+	}
+	if r.Exhausted.Before(now.Add(reportHorizon)) {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("timestamp bits run out on %s", r.Exhausted.UTC().Format(time.DateOnly)))
+	}
+	if !r.NegativeFrom.IsZero() && r.NegativeFrom.Before(r.Exhausted) {
+		r.Warnings = append(r.Warnings, fmt.Sprintf("ids are negative as Java long or signed BIGINT from %s", r.NegativeFrom.UTC().Format(time.DateOnly)))
+	} else if r.MaySetSignBit {
+		r.Warnings = append(r.Warnings, "later versions of the layout issue negative ids as Java long or signed BIGINT")
+	}
+	return r
+}
+
+// millis 距 epoch ms 毫秒的时刻
+func (l Layout) millis(ms int64) time.Time {
+	return time.UnixMilli(l.Epoch.UnixMilli() + ms)
+}
+
+// String return the report as lines of text
+func (r LayoutReport) String() string {
+	if r.Invalid != nil {
+		return "invalid layout: " + r.Invalid.Error() + "\n"
+	}
+	var b strings.Builder
+	date := func(t time.Time, never string) string {
+		if t.IsZero() {
+			return never
+		}
+		return t.UTC().Format(time.DateOnly)
+	}
+	fmt.Fprintf(&b, "nodes: %d (%d datacenters x %d workers)\n", r.Nodes, r.Datacenters, r.WorkersPerDC)
+	fmt.Fprintf(&b, "ids per node: %d/ms, %d/s\n", r.IDsPerMilli, r.IDsPerMilli*1000)
+	fmt.Fprintf(&b, "timestamp exhausted: %s\n", date(r.Exhausted, "never"))
+	fmt.Fprintf(&b, "exact in JavaScript until: %s\n", date(r.JSSafeUntil, "always"))
+	fmt.Fprintf(&b, "negative as signed 64-bit from: %s\n", date(r.NegativeFrom, "never"))
+	for _, w := range r.Warnings {
+		fmt.Fprintf(&b, "warning: %s\n", w)
+	}
+	return b.String()
+}
@@ -0,0 +1,43 @@
+package snowflake
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_LayoutReport(t *testing.T) {
+	r := DefaultLayout.Report()
+	if r.Nodes != 1024 || r.Datacenters != 32 || r.WorkersPerDC != 32 || r.IDsPerMilli != 4096 {
+		t.Errorf("capacity = %+v", r)
+	}
+	if got := r.Exhausted.UTC().Format(time.DateOnly); got != "2088-09-06" {
+		t.Errorf("Exhausted = %s", got)
+	}
+	// 2^53 / 2^22 毫秒约 24.9 天后 id 超出 JavaScript 的精确范围
+	if got := r.JSSafeUntil.Sub(DefaultLayout.Epoch); got != time.Duration(1<<31)*time.Millisecond {
+		t.Errorf("JSSafeUntil = epoch + %v", got)
+	}
+	if !r.NegativeFrom.IsZero() || r.MaySetSignBit || len(r.Warnings) != 0 {
+		t.Errorf("default layout report = %+v", r)
+	}
+	if s := r.String(); !strings.Contains(s, "nodes: 1024 (32 datacenters x 32 workers)\n") {
+		t.Errorf("String() = %s", s)
+	}
+
+	wide := Layout{Epoch: DefaultLayout.Epoch, TimestampBits: 42, WorkerBits: 10, SequenceBits: 12, Unsigned: true}
+	r = wide.Report()
+	if got := r.NegativeFrom.UTC().Format(time.DateOnly); got != "2088-09-06" || !r.MaySetSignBit || len(r.Warnings) != 1 {
+		t.Errorf("unsigned 64-bit layout: NegativeFrom %s, %+v", got, r)
+	}
+
+	short := Layout{Epoch: time.Now().Add(time.Hour), TimestampBits: 30, SequenceBits: 12}
+	r = short.Report()
+	if len(r.Warnings) != 2 || !r.JSSafeUntil.IsZero() {
+		t.Errorf("short layout in the future: %+v", r)
+	}
+
+	if r := (Layout{}).Report(); r.Invalid == nil || !strings.HasPrefix(r.String(), "invalid layout: ") {
+		t.Errorf("invalid layout report = %+v", r)
+	}
+}
@@ -0,0 +1,152 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrReservationNotFound token is unknown, already confirmed or cancelled, or expired
+var ErrReservationNotFound = errors.New("snowflake: reservation not found")
+
+// Reservation id reserved by Reserver.ReserveID
+type Reservation struct {
+	ID      int64
+	Token   string
+	Expires time.Time
+}
+
+// ReservationStats counters of a Reserver
+type ReservationStats struct {
+	Pending   int
+	Confirmed uint64
+	Cancelled uint64
+	Expired   uint64
+}
+
+// Reserver two-phase id issuance: ReserveID hands out an id that only counts as issued
+// once confirmed, unconfirmed reservations expire after ttl; ids of cancelled or expired
+// reservations are never handed out again
+type Reserver struct {
+	w        Worker
+	ttl      time.Duration
+	onExpire func(Reservation)
+	now      func() time.Time
+
+	mu        sync.Mutex
+	pending   map[string]Reservation
+	nextSweep time.Time
+	stats     ReservationStats
+}
+
+// NewReserver return reserver issuing ids from w, onExpire (optional) is called for
+// every reservation that expired unconfirmed, e.g. to write an audit record
+func NewReserver(w Worker, ttl time.Duration, onExpire func(Reservation)) *Reserver {
+	return &Reserver{w: w, ttl: ttl, onExpire: onExpire, now: time.Now, pending: make(map[string]Reservation)}
+}
+
+// ReserveID reserve a new id
+func (r *Reserver) ReserveID() (Reservation, error) {
+	id, err := r.w.Next()
+	if err != nil {
+		return Reservation{}, err
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return Reservation{}, err
+	}
+	now := r.now()
+	res := Reservation{ID: id, Token: hex.EncodeToString(b[:]), Expires: now.Add(r.ttl)}
+	r.mu.Lock()
+	expired := r.sweep(now)
+	r.pending[res.Token] = res
+	r.mu.Unlock()
+	r.notify(expired)
+	return res, nil
+}
+
+// Confirm mark the reservation issued and return its id
+func (r *Reserver) Confirm(token string) (int64, error) {
+	res, err := r.take(token)
+	if err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	r.stats.Confirmed++
+	r.mu.Unlock()
+	return res.ID, nil
+}
+
+// Cancel abandon the reservation
+func (r *Reserver) Cancel(token string) error {
+	if _, err := r.take(token); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.stats.Cancelled++
+	r.mu.Unlock()
+	return nil
+}
+
+// Stats return reservation counters
+func (r *Reserver) Stats() ReservationStats {
+	r.mu.Lock()
+	expired := r.sweep(r.now())
+	s := r.stats
+	s.Pending = len(r.pending)
+	r.mu.Unlock()
+	r.notify(expired)
+	return s
+}
+
+func (r *Reserver) take(token string) (Reservation, error) {
+	now := r.now()
+	r.mu.Lock()
+	expired := r.sweep(now)
+	res, ok := r.pending[token]
+	if ok && !now.Before(res.Expires) {
+		delete(r.pending, token)
+		r.stats.Expired++
+		expired = append(expired, res)
+		ok = false
+	}
+	if ok {
+		delete(r.pending, token)
+	}
+	r.mu.Unlock()
+	r.notify(expired)
+	if !ok {
+		return Reservation{}, ErrReservationNotFound
+	}
+	return res, nil
+}
+
+// sweep 清理过期预留，最多每 ttl/2 扫描一次，需持有 r.mu
+func (r *Reserver) sweep(now time.Time) []Reservation {
+	if now.Before(r.nextSweep) {
+		return nil
+	}
+	r.nextSweep = now.Add(r.ttl / 2)
+	var expired []Reservation
+	for token, res := range r.pending {
+		if !now.Before(res.Expires) {
+			delete(r.pending, token)
+			r.stats.Expired++
+			expired = append(expired, res)
+		}
+	}
+	return expired
+}
+
+func (r *Reserver) notify(expired []Reservation) {
+	if r.onExpire == nil {
+		return
+	}
+	for _, res := range expired {
+		r.onExpire(res)
+	}
+}
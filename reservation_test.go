@@ -0,0 +1,49 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Reserver(t *testing.T) {
+	now := time.Now()
+	var expired []Reservation
+	r := NewReserver(NewWorker(0, 0), time.Minute, func(res Reservation) { expired = append(expired, res) })
+	r.now = func() time.Time { return now }
+
+	a, err := r.ReserveID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := r.ReserveID()
+	c, _ := r.ReserveID()
+	if id, err := r.Confirm(a.Token); err != nil || id != a.ID {
+		t.Errorf("Confirm = %d, %v", id, err)
+	}
+	if _, err := r.Confirm(a.Token); err != ErrReservationNotFound {
+		t.Errorf("second Confirm: got %v", err)
+	}
+	if err := r.Cancel(b.Token); err != nil {
+		t.Error(err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := r.Confirm(c.Token); err != ErrReservationNotFound {
+		t.Errorf("Confirm of expired reservation: got %v", err)
+	}
+	if len(expired) != 1 || expired[0].ID != c.ID {
+		t.Errorf("expired = %+v", expired)
+	}
+	s := r.Stats()
+	if s.Pending != 0 || s.Confirmed != 1 || s.Cancelled != 1 || s.Expired != 1 {
+		t.Errorf("Stats = %+v", s)
+	}
+
+	d, _ := r.ReserveID()
+	now = now.Add(2 * time.Minute)
+	if s := r.Stats(); s.Pending != 0 || s.Expired != 2 || expired[1].ID != d.ID {
+		t.Errorf("Stats after sweep = %+v", s)
+	}
+}
@@ -0,0 +1,68 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+// 饱和度按 saturationBuckets 个 saturationBucket 毫秒的桶统计最近一秒
+const (
+	saturationBucket  = 100
+	saturationBuckets = 10
+	saturationWindow  = saturationBucket * saturationBuckets
+)
+
+// saturation 最近一秒内序列耗尽的毫秒数，调用方需持有 w.mutex
+type saturation struct {
+	last    int64 // 最近记录的毫秒，同一毫秒只计一次
+	buckets [saturationBuckets]struct {
+		start int64 // 桶编号，即 毫秒时间戳 / saturationBucket
+		count int64
+	}
+}
+
+// record 记录 ms 这一毫秒序列耗尽
+func (s *saturation) record(ms int64) {
+	if ms == s.last {
+		return
+	}
+	s.last = ms
+	n := ms / saturationBucket
+	b := &s.buckets[n%saturationBuckets]
+	if b.start != n {
+		b.start, b.count = n, 0
+	}
+	b.count++
+}
+
+// fraction 返回截至 now 的最近一秒内序列耗尽的毫秒占比
+func (s *saturation) fraction(now int64) float64 {
+	n := now / saturationBucket
+	var total int64
+	for _, b := range s.buckets {
+		if b.start > n-saturationBuckets && b.start <= n {
+			total += b.count
+		}
+	}
+	// 当前桶只过去了一部分
+	window := saturationWindow - saturationBucket + now%saturationBucket + 1
+	return min(float64(total)/float64(window), 1)
+}
+
+// SaturationReporter implemented by generators created by NewGenerator, NewPool and NewShardedWorker
+type SaturationReporter interface {
+	Saturation() float64
+}
+
+// Saturation return the saturation of w, 0 when w does not implement SaturationReporter
+func Saturation(w Worker) float64 {
+	if r, ok := w.(SaturationReporter); ok {
+		return r.Saturation()
+	}
+	return 0
+}
+
+// Saturation return the fraction of milliseconds in the last second in which the
+// sequence was used up, 1 means the worker runs at its per millisecond capacity
+func (w *worker) Saturation() float64 {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.saturation.fraction(w.timestamp())
+}
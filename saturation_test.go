@@ -0,0 +1,53 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import "testing"
+
+func Test_saturation(t *testing.T) {
+	var s saturation
+	base := int64(1_000_000)
+	for ms := base; ms < base+1000; ms += 4 {
+		s.record(ms)
+		s.record(ms)
+	}
+	if got := s.fraction(base + 999); got != 0.25 {
+		t.Errorf("fraction = %v, want 0.25", got)
+	}
+	// 一秒以后旧记录不再计入
+	if got := s.fraction(base + 2500); got != 0 {
+		t.Errorf("fraction after a second = %v, want 0", got)
+	}
+}
+
+func Test_Saturation(t *testing.T) {
+	w, err := newWorker(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 冻结的时钟从桶的起点开始，每毫秒用尽序列后再推进
+	start := (currentMillis()/saturationBucket + 10) * saturationBucket
+	frozen := start
+	w.now = func() int64 { return frozen }
+	w.customClock = true
+	if got := w.Saturation(); got != 0 {
+		t.Errorf("idle Saturation = %v", got)
+	}
+	for ; frozen < start+10; frozen++ {
+		for {
+			if _, ok := w.TryNext(); !ok {
+				break
+			}
+		}
+	}
+	// 10 个毫秒用尽，当前桶只过去了 11 毫秒
+	if got, want := w.Saturation(), 10.0/911; got != want {
+		t.Errorf("Saturation under load = %v, want %v", got, want)
+	}
+	if got := Saturation(AsGenerator(&countingWorker{})); got != 0 {
+		t.Errorf("adapter Saturation = %v", got)
+	}
+	if got := Saturation(w); got != 10.0/911 {
+		t.Errorf("Saturation(w) = %v", got)
+	}
+}
@@ -0,0 +1,122 @@
+package snowflake
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 列元数据的键，用于 Arrow 字段元数据、Parquet key_value_metadata 和 Avro 自定义属性
+const (
+	MetaEpoch          = "snowflake.epoch_ms"
+	MetaTimestampBits  = "snowflake.timestamp_bits"
+	MetaDatacenterBits = "snowflake.datacenter_bits"
+	MetaWorkerBits     = "snowflake.worker_bits"
+	MetaSequenceBits   = "snowflake.sequence_bits"
+	MetaUnsigned       = "snowflake.unsigned"
+	MetaVersionBits    = "snowflake.version_bits" // 仅在布局有版本位时写入
+	MetaVersion        = "snowflake.version"
+	MetaTombstoneBit   = "snowflake.tombstone_bit" // 仅在布局保留墓碑位时写入
+	MetaFingerprint    = "snowflake.fingerprint"
+)
+
+// AvroLogicalType logical type set on Avro long fields holding ids,
+// readers that do not know it fall back to long
+const AvroLogicalType = "snowflake-id"
+
+// Fingerprint return a short stable hash of the layout, equal layouts have equal fingerprints
+func (l Layout) Fingerprint() string {
+	s := fmt.Sprintf("epoch=%d;timestamp=%d;datacenter=%d;worker=%d;sequence=%d;unsigned=%t",
+		l.Epoch.UnixMilli(), l.TimestampBits, l.DatacenterBits, l.WorkerBits, l.SequenceBits, l.Unsigned)
+	if l.VersionBits > 0 {
+		// 无版本位的布局保持原有指纹
+		s += fmt.Sprintf(";version=%d/%d", l.Version, l.VersionBits)
+	}
+	if l.TombstoneBit {
+		s += ";tombstone"
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Metadata return the layout as key/value metadata for Arrow fields or Parquet files,
+// so pipelines keep the decode information next to the raw int64
+func (l Layout) Metadata() map[string]string {
+	meta := map[string]string{
+		MetaEpoch:          strconv.FormatInt(l.Epoch.UnixMilli(), 10),
+		MetaTimestampBits:  strconv.Itoa(int(l.TimestampBits)),
+		MetaDatacenterBits: strconv.Itoa(int(l.DatacenterBits)),
+		MetaWorkerBits:     strconv.Itoa(int(l.WorkerBits)),
+		MetaSequenceBits:   strconv.Itoa(int(l.SequenceBits)),
+		MetaUnsigned:       strconv.FormatBool(l.Unsigned),
+		MetaFingerprint:    l.Fingerprint(),
+	}
+	if l.VersionBits > 0 {
+		meta[MetaVersionBits] = strconv.Itoa(int(l.VersionBits))
+		meta[MetaVersion] = strconv.Itoa(int(l.Version))
+	}
+	if l.TombstoneBit {
+		meta[MetaTombstoneBit] = "true"
+	}
+	return meta
+}
+
+// LayoutFromMetadata parse a layout written by Metadata, checking its fingerprint
+func LayoutFromMetadata(meta map[string]string) (Layout, error) {
+	var l Layout
+	epoch, err := strconv.ParseInt(meta[MetaEpoch], 10, 64)
+	if err != nil {
+		return l, fmt.Errorf("invalid %s %q", MetaEpoch, meta[MetaEpoch])
+	}
+	l.Epoch = time.UnixMilli(epoch)
+	for _, f := range []struct {
+		key string
+		dst *uint8
+	}{{MetaTimestampBits, &l.TimestampBits}, {MetaDatacenterBits, &l.DatacenterBits}, {MetaWorkerBits, &l.WorkerBits}, {MetaSequenceBits, &l.SequenceBits}} {
+		v, err := strconv.ParseUint(meta[f.key], 10, 8)
+		if err != nil {
+			return l, fmt.Errorf("invalid %s %q", f.key, meta[f.key])
+		}
+		*f.dst = uint8(v)
+	}
+	if s, ok := meta[MetaUnsigned]; ok {
+		if l.Unsigned, err = strconv.ParseBool(s); err != nil {
+			return l, fmt.Errorf("invalid %s %q", MetaUnsigned, s)
+		}
+	}
+	if s, ok := meta[MetaTombstoneBit]; ok {
+		if l.TombstoneBit, err = strconv.ParseBool(s); err != nil {
+			return l, fmt.Errorf("invalid %s %q", MetaTombstoneBit, s)
+		}
+	}
+	for _, f := range []struct {
+		key string
+		dst *uint8
+	}{{MetaVersionBits, &l.VersionBits}, {MetaVersion, &l.Version}} {
+		if s, ok := meta[f.key]; ok {
+			v, err := strconv.ParseUint(s, 10, 8)
+			if err != nil {
+				return l, fmt.Errorf("invalid %s %q", f.key, s)
+			}
+			*f.dst = uint8(v)
+		}
+	}
+	if fp, ok := meta[MetaFingerprint]; ok && fp != l.Fingerprint() {
+		return l, fmt.Errorf("layout fingerprint %s does not match %s", fp, l.Fingerprint())
+	}
+	return l, l.Validate()
+}
+
+// AvroField return an Avro record field named name holding ids of the layout, as a long
+// annotated with AvroLogicalType and the Metadata attributes
+func (l Layout) AvroField(name string) json.RawMessage {
+	typ := map[string]any{"type": "long", "logicalType": AvroLogicalType}
+	for k, v := range l.Metadata() {
+		typ[k] = v
+	}
+	b, _ := json.Marshal(map[string]any{"name": name, "type": typ})
+	return b
+}
@@ -0,0 +1,51 @@
+package snowflake
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func Test_LayoutMetadata(t *testing.T) {
+	l := Layout{Epoch: time.UnixMilli(1288834974657), TimestampBits: 42, WorkerBits: 10, SequenceBits: 12, Unsigned: true}
+	got, err := LayoutFromMetadata(l.Metadata())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Epoch.Equal(l.Epoch) || got.Fingerprint() != l.Fingerprint() {
+		t.Errorf("round trip = %+v, want %+v", got, l)
+	}
+	if l.Fingerprint() == DefaultLayout.Fingerprint() || len(l.Fingerprint()) != 16 {
+		t.Errorf("fingerprints %s, %s", l.Fingerprint(), DefaultLayout.Fingerprint())
+	}
+
+	meta := l.Metadata()
+	meta[MetaWorkerBits] = "9"
+	if _, err := LayoutFromMetadata(meta); err == nil {
+		t.Error("expected fingerprint mismatch")
+	}
+	delete(meta, MetaFingerprint)
+	if got, err := LayoutFromMetadata(meta); err != nil || got.WorkerBits != 9 {
+		t.Errorf("without fingerprint = %+v, %v", got, err)
+	}
+	if _, err := LayoutFromMetadata(map[string]string{}); err == nil {
+		t.Error("expected error for empty metadata")
+	}
+}
+
+func Test_AvroField(t *testing.T) {
+	var field struct {
+		Name string            `json:"name"`
+		Type map[string]string `json:"type"`
+	}
+	if err := json.Unmarshal(DefaultLayout.AvroField("order_id"), &field); err != nil {
+		t.Fatal(err)
+	}
+	if field.Name != "order_id" || field.Type["type"] != "long" || field.Type["logicalType"] != AvroLogicalType {
+		t.Errorf("field = %+v", field)
+	}
+	l, err := LayoutFromMetadata(field.Type)
+	if err != nil || l.Fingerprint() != DefaultLayout.Fingerprint() {
+		t.Errorf("layout from Avro attributes = %+v, %v", l, err)
+	}
+}
@@ -0,0 +1,42 @@
+#!/bin/sh
+# 基准测试回归门禁: 对比基线提交与当前工作区的 benchstat 结果
+#
+#   scripts/bench.sh [base-ref]
+#
+# base-ref 默认 origin/main。任一 sec/op 显著变慢超过 THRESHOLD(默认 10)% 时退出码为 1。
+# 需要 benchstat: go install golang.org/x/perf/cmd/benchstat@latest
+set -eu
+
+BASE=${1:-origin/main}
+COUNT=${COUNT:-10}
+THRESHOLD=${THRESHOLD:-10}
+BENCH=${BENCH:-.}
+ROOT=$(git rev-parse --show-toplevel)
+OUT=$ROOT/bench_output.txt
+TMP=$(mktemp -d)
+trap 'git -C "$ROOT" worktree remove --force "$TMP/base" >/dev/null 2>&1 || true; rm -rf "$TMP"' EXIT
+
+run() {
+	(cd "$1" && go test -run '^$' -bench "$BENCH" -count "$COUNT" ./...)
+}
+
+git -C "$ROOT" worktree add --detach "$TMP/base" "$BASE" >/dev/null
+run "$TMP/base" >"$TMP/old.txt"
+run "$ROOT" >"$TMP/new.txt"
+
+benchstat "$TMP/old.txt" "$TMP/new.txt" | tee "$OUT"
+
+# benchstat 只为显著差异打印百分比，不显著时为 "~"
+awk -v limit="$THRESHOLD" '
+	/sec\/op/ { timing = 1; next }
+	/^ *$/ || /B\/op|allocs\/op/ { timing = 0 }
+	timing {
+		for (i = 1; i <= NF; i++) {
+			if ($i ~ /^\+[0-9.]+%$/) {
+				v = substr($i, 2, length($i) - 2) + 0
+				if (v > limit) { print "regression: " $0; bad = 1 }
+			}
+		}
+	}
+	END { exit bad }
+' "$OUT"
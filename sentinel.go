@@ -0,0 +1,123 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// sentinel 用两代布隆过滤器滚动记录最近签发的 id
+type sentinel struct {
+	w           Worker
+	onDuplicate func(id int64)
+
+	mu       sync.Mutex
+	current  bloom
+	previous bloom
+	capacity int
+	count    int
+}
+
+// NewDuplicateSentinel wrap w with a last line of defense against repeated ids: the most
+// recent capacity to 2*capacity ids are kept in a rolling bloom filter, and onDuplicate
+// (nil means panic) is called when Next returns an id that is probably among them.
+// A bloom filter has false positives: each Next raises a false alarm with probability
+// falsePositiveRate, so size it for the id rate (memory is about 2*capacity*1.44*log2(1/rate) bits)
+// and prefer an alerting onDuplicate over a panic unless the rate is tiny.
+func NewDuplicateSentinel(w Worker, capacity int, falsePositiveRate float64, onDuplicate func(id int64)) Worker {
+	if onDuplicate == nil {
+		onDuplicate = func(id int64) {
+			panic(fmt.Sprintf("snowflake: duplicate id %d", id))
+		}
+	}
+	if capacity < 1 {
+		capacity = 1
+	}
+	s := &sentinel{w: w, onDuplicate: onDuplicate, capacity: capacity}
+	s.current = newBloom(capacity, falsePositiveRate)
+	s.previous = newBloom(capacity, falsePositiveRate)
+	return s
+}
+
+func (s *sentinel) Next() (int64, error) {
+	id, err := s.w.Next()
+	if err != nil {
+		return id, err
+	}
+	s.mu.Lock()
+	dup := s.current.has(uint64(id)) || s.previous.has(uint64(id))
+	if s.count == s.capacity {
+		s.previous, s.current = s.current, s.previous
+		s.current.reset()
+		s.count = 0
+	}
+	s.current.add(uint64(id))
+	s.count++
+	s.mu.Unlock()
+	if dup {
+		s.onDuplicate(id)
+	}
+	return id, nil
+}
+
+type bloom struct {
+	bits []uint64
+	m    uint64
+	k    int
+}
+
+func newBloom(n int, p float64) bloom {
+	if p <= 0 || p >= 1 {
+		p = 1e-9
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := int(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return bloom{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// hashes 增强双重哈希: 两个 splitmix64 结果组合出 k 个位置；步长逐次递增，
+// 避免 h2 是 m 的倍数(或与 m 有大公因数)时 k 个位置重合导致误报率远高于预期
+func (b *bloom) hashes(id uint64) (uint64, uint64) {
+	h1 := splitmix64(id)
+	return h1, splitmix64(h1) | 1
+}
+
+func (b *bloom) add(id uint64) {
+	h1, h2 := b.hashes(id)
+	for i := 0; i < b.k; i++ {
+		pos := h1 % b.m
+		b.bits[pos/64] |= 1 << (pos % 64)
+		h1, h2 = h1+h2, h2+uint64(i)
+	}
+}
+
+func (b *bloom) has(id uint64) bool {
+	h1, h2 := b.hashes(id)
+	for i := 0; i < b.k; i++ {
+		pos := h1 % b.m
+		if b.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+		h1, h2 = h1+h2, h2+uint64(i)
+	}
+	return true
+}
+
+func (b *bloom) reset() {
+	clear(b.bits)
+}
+
+func splitmix64(x uint64) uint64 {
+	x += 0x9E3779B97F4A7C15
+	x = (x ^ x>>30) * 0xBF58476D1CE4E5B9
+	x = (x ^ x>>27) * 0x94D049BB133111EB
+	return x ^ x>>31
+}
@@ -0,0 +1,46 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import "testing"
+
+type replayWorker struct {
+	ids []int64
+	i   int
+}
+
+func (r *replayWorker) Next() (int64, error) {
+	id := r.ids[r.i%len(r.ids)]
+	r.i++
+	return id, nil
+}
+
+func Test_DuplicateSentinel(t *testing.T) {
+	var dups []int64
+	s := NewDuplicateSentinel(NewWorker(0, 0), 1000, 1e-9, func(id int64) { dups = append(dups, id) })
+	for i := 0; i < 10000; i++ {
+		if _, err := s.Next(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(dups) != 0 {
+		t.Fatalf("false alarms %v", dups)
+	}
+
+	s = NewDuplicateSentinel(&replayWorker{ids: []int64{1, 2, 3}}, 1000, 1e-9, func(id int64) { dups = append(dups, id) })
+	for i := 0; i < 4; i++ {
+		s.Next()
+	}
+	if len(dups) != 1 || dups[0] != 1 {
+		t.Errorf("dups = %v, want [1]", dups)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("default handler should panic")
+		}
+	}()
+	s = NewDuplicateSentinel(&replayWorker{ids: []int64{7}}, 10, 1e-9, nil)
+	s.Next()
+	s.Next()
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+// WithCoalescing serve concurrent GET /id requests arriving within window of the first one
+// from a single batch of up to max ids (0 means DefaultMaxBatch, capped at the server batch
+// cap), trading up to window of added latency for one generator call per batch instead of one
+// per request
+func WithCoalescing(window time.Duration, max int) Option {
+	if max <= 0 {
+		max = DefaultMaxBatch
+	}
+	return func(s *Server) {
+		s.coalesce = &coalescer{window: window, max: max}
+	}
+}
+
+// coalescer 合并窗口内的单个 id 请求
+type coalescer struct {
+	g       snowflake.Generator
+	journal *Journal // 非空时整批记录一次
+	window  time.Duration
+	max     int
+
+	mu      sync.Mutex
+	pending []chan coalesced
+	gen     uint64 // 当前窗口的编号，避免过期的定时器提前结束下一个窗口
+	timer   *time.Timer
+}
+
+type coalesced struct {
+	id  int64
+	err error
+}
+
+func (c *coalescer) next(ctx context.Context) (int64, error) {
+	ch := make(chan coalesced, 1)
+	c.mu.Lock()
+	c.pending = append(c.pending, ch)
+	switch {
+	case len(c.pending) >= c.max:
+		// 在锁内取走整批，之后到达的请求进入下一批，批量不超过 max
+		if c.timer != nil {
+			c.timer.Stop()
+		}
+		go c.issue(c.detach())
+	case len(c.pending) == 1:
+		gen := c.gen
+		c.timer = time.AfterFunc(c.window, func() { c.flush(gen) })
+	}
+	c.mu.Unlock()
+	select {
+	case r := <-ch:
+		return r.id, r.err
+	case <-ctx.Done():
+		// 已分配给本请求的 id 在批量返回时丢弃
+		return 0, ctx.Err()
+	}
+}
+
+// flush 窗口到期时签发第 gen 个窗口的请求，该窗口已被取走时不做任何事
+func (c *coalescer) flush(gen uint64) {
+	c.mu.Lock()
+	if gen != c.gen || len(c.pending) == 0 {
+		c.mu.Unlock()
+		return
+	}
+	batch := c.detach()
+	c.mu.Unlock()
+	c.issue(batch)
+}
+
+// detach 取走当前窗口的请求并开始下一个窗口，调用方需持有 c.mu
+func (c *coalescer) detach() []chan coalesced {
+	batch := c.pending
+	c.pending = nil
+	c.gen++
+	return batch
+}
+
+// issue 为一批请求签发 id
+func (c *coalescer) issue(batch []chan coalesced) {
+	ids := make([]int64, len(batch))
+	err := snowflake.Fill(c.g, ids)
+	if err == nil && c.journal != nil {
+		err = c.journal.record(ids...)
+	}
+	for i, ch := range batch {
+		ch <- coalesced{id: ids[i], err: err}
+	}
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+type countingGenerator struct {
+	snowflake.Generator
+	mu      sync.Mutex
+	calls   int
+	largest int
+}
+
+func (c *countingGenerator) Fill(dst []int64) error {
+	c.mu.Lock()
+	c.calls++
+	c.largest = max(c.largest, len(dst))
+	c.mu.Unlock()
+	return snowflake.Fill(c.Generator, dst)
+}
+
+func Test_Coalescing(t *testing.T) {
+	g, err := snowflake.NewGenerator(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cg := &countingGenerator{Generator: g}
+	s := New(cg, WithCoalescing(20*time.Millisecond, 50))
+
+	const requests = 200
+	ids := make(chan int64, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := get(s, "/id", "")
+			if rec.Code != http.StatusOK {
+				t.Errorf("GET /id = %d %s", rec.Code, rec.Body)
+				return
+			}
+			id, _ := strconv.ParseInt(rec.Body.String(), 10, 64)
+			ids <- id
+		}()
+	}
+	wg.Wait()
+	close(ids)
+	seen := map[int64]bool{}
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("duplicate id %d", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != requests {
+		t.Fatalf("%d ids for %d requests", len(seen), requests)
+	}
+	if cg.calls >= requests/2 {
+		t.Errorf("%d generator calls for %d requests, want batches", cg.calls, requests)
+	}
+	if cg.largest > 50 {
+		t.Errorf("batch of %d ids, want at most 50", cg.largest)
+	}
+
+	start := time.Now()
+	if rec := get(s, "/id", ""); rec.Code != http.StatusOK {
+		t.Fatalf("GET /id = %d", rec.Code)
+	}
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Errorf("lone request returned after %v, before the window closed", d)
+	}
+}
+
+func Test_CoalescingDefaultMax(t *testing.T) {
+	if s := newTestServer(t, WithCoalescing(time.Millisecond, 0)); s.coalesce.max != DefaultMaxBatch {
+		t.Errorf("max %d, want DefaultMaxBatch", s.coalesce.max)
+	}
+	// 合并的批量不超过服务器的批量上限，与选项顺序无关
+	if s := newTestServer(t, WithCoalescing(time.Millisecond, 0), WithMaxBatch(10)); s.coalesce.max != 10 {
+		t.Errorf("max %d, want the server batch cap 10", s.coalesce.max)
+	}
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+	"net/http"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+// DefaultDashboardEvents default number of recent events a Dashboard keeps
+const DefaultDashboardEvents = 50
+
+//go:embed dashboard.html
+var dashboardHTML string
+
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"percent": func(f float64) string { return strconv.FormatFloat(f*100, 'f', 1, 64) + "%" },
+	"when": func(t time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+		return t.UTC().Format("2006-01-02T15:04:05.000Z")
+	},
+}).Parse(dashboardHTML))
+
+// Dashboard self-contained HTML status page served at /debug/snowflake by WithDashboard:
+// worker identity, live stats, the layout report and recent clock and sequence events.
+// Pass its Handle method to snowflake.WithEventHandler to collect the events:
+//
+//	d := server.NewDashboard(workerID, datacenterID, layout)
+//	g, err := snowflake.NewGenerator(workerID, datacenterID, snowflake.WithLayout(layout), snowflake.WithEventHandler(d.Handle))
+//	srv := server.New(g, server.WithDashboard(d))
+type Dashboard struct {
+	workerID     int64
+	datacenterID int64
+	layout       snowflake.Layout
+	limit        int
+
+	mu     sync.Mutex
+	events []snowflake.Event // 环形缓冲，next 为下一个写入位置
+	next   int
+	total  uint64
+}
+
+// NewDashboard return a dashboard for the generator with the given ids and layout,
+// keeping the latest DefaultDashboardEvents events
+func NewDashboard(workerID, datacenterID int64, l snowflake.Layout) *Dashboard {
+	return &Dashboard{workerID: workerID, datacenterID: datacenterID, layout: l, limit: DefaultDashboardEvents}
+}
+
+// Handle record e, for snowflake.WithEventHandler
+func (d *Dashboard) Handle(e snowflake.Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.total++
+	if len(d.events) < d.limit {
+		d.events = append(d.events, e)
+		return
+	}
+	d.events[d.next] = e
+	d.next = (d.next + 1) % d.limit
+}
+
+// recent 最近的事件，新的在前
+func (d *Dashboard) recent() ([]snowflake.Event, uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	events := append(slices.Clone(d.events[d.next:]), d.events[:d.next]...)
+	slices.Reverse(events)
+	return events, d.total
+}
+
+// WithDashboard serve d at GET /debug/snowflake; the page refreshes itself every 5 seconds
+func WithDashboard(d *Dashboard) Option {
+	return func(s *Server) {
+		s.dashboard = d
+	}
+}
+
+// dashboardPage 模板数据
+type dashboardPage struct {
+	WorkerID     int64
+	DatacenterID int64
+	Host         string
+	PID          int
+	GoVersion    string
+	Now          time.Time
+	Stats        snowflake.Stats
+	Saturation   float64
+	Layout       snowflake.Layout
+	Report       snowflake.LayoutReport
+	Events       []snowflake.Event
+	TotalEvents  uint64
+}
+
+func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	d := s.dashboard
+	host, _ := os.Hostname()
+	page := dashboardPage{
+		WorkerID:     d.workerID,
+		DatacenterID: d.datacenterID,
+		Host:         host,
+		PID:          os.Getpid(),
+		GoVersion:    runtime.Version(),
+		Now:          time.Now(),
+		Stats:        s.g.Stats(),
+		Saturation:   snowflake.Saturation(s.g),
+		Layout:       d.layout,
+		Report:       d.layout.Report(),
+	}
+	page.Events, page.TotalEvents = d.recent()
+	var buf bytes.Buffer
+	if err := dashboardTemplate.Execute(&buf, page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(buf.Bytes())
+}
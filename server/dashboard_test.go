@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+func Test_Dashboard(t *testing.T) {
+	if rec := get(newTestServer(t), "/debug/snowflake", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /debug/snowflake without WithDashboard = %d", rec.Code)
+	}
+
+	d := NewDashboard(1, 2, snowflake.DefaultLayout)
+	d.limit = 3
+	g, err := snowflake.NewGenerator(1, 2, snowflake.WithEventHandler(d.Handle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(g, WithDashboard(d))
+	if _, err := g.NextN(10); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 5 {
+		d.Handle(snowflake.Event{Type: snowflake.EventClockBackwards, Time: time.UnixMilli(int64(i)), DatacenterID: 2, WorkerID: 1, Millis: int64(100 + i)})
+	}
+	events, total := d.recent()
+	if total != 5 || len(events) != 3 || events[0].Millis != 104 || events[2].Millis != 102 {
+		t.Errorf("recent = %+v, %d", events, total)
+	}
+
+	rec := get(s, "/debug/snowflake", "")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("GET /debug/snowflake = %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"snowflake worker 2/1",
+		`<tr><th>issued</th><td class="n">10</td></tr>`,
+		"timestamp 41, datacenter 5, worker 5, sequence 12",
+		"latest 3 of 5",
+		"<td>clock_backwards</td><td>2/1</td><td class=\"n\">104</td>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page lacks %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, ">100<") {
+		t.Error("page shows an event beyond the limit")
+	}
+}
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrBehindJournal returned instead of ids at or before the last id recorded by a previous
+// run, e.g. when the clock is behind after a reboot
+var ErrBehindJournal = errors.New("server: ids not after the journal, clock is behind the previous run")
+
+// DefaultSegmentSize default size after which the journal starts a new segment file
+const DefaultSegmentSize = 64 << 20
+
+// SyncPolicy when journal records are flushed to disk
+type SyncPolicy int
+
+const (
+	// SyncEachBatch fsync every record before its ids are returned (default), survives power loss
+	SyncEachBatch SyncPolicy = iota
+	// SyncOS leave flushing to the operating system, survives process crashes but a machine
+	// crash may lose the last records and allow their ids to be reissued
+	SyncOS
+)
+
+// segmentExt 段文件后缀，文件名为递增的段序号
+const segmentExt = ".journal"
+
+// Journal append-only log of issued id ranges, one "first last" line per batch, split into
+// segment files in a directory. Reopening the directory recovers the last issued id and the
+// server refuses ids at or before it. Only the newest segment is needed for recovery, older
+// segments may be archived or removed.
+type Journal struct {
+	dir         string
+	segmentSize int64
+	sync        SyncPolicy
+
+	mu    sync.Mutex
+	floor int64 // 上次运行记录的最大 id
+	max   int64
+	seq   int
+	file  *os.File
+	size  int64
+}
+
+// OpenJournal open the journal in dir, creating it if needed; segmentSize <= 0 means
+// DefaultSegmentSize
+func OpenJournal(dir string, segmentSize int64, sync SyncPolicy) (*Journal, error) {
+	if segmentSize <= 0 {
+		segmentSize = DefaultSegmentSize
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	j := &Journal{dir: dir, segmentSize: segmentSize, sync: sync}
+	seqs, err := j.segments()
+	if err != nil {
+		return nil, err
+	}
+	// 从最新的段向前找到第一条完整记录，每个段以当时的最大 id 开头
+	for i := len(seqs) - 1; i >= 0; i-- {
+		high, ok, err := j.recover(seqs[i])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			j.floor, j.max = high, high
+			break
+		}
+	}
+	if len(seqs) > 0 {
+		j.seq = seqs[len(seqs)-1]
+	}
+	// 总是从新段开始，不在可能残缺的行之后追加
+	if err := j.rotate(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Last return the largest id recorded, including previous runs
+func (j *Journal) Last() int64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.max
+}
+
+// Close close the current segment
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// record 校验并记录一批 id，返回后 id 才能交给调用方
+func (j *Journal) record(ids ...int64) error {
+	first, last := slices.Min(ids), slices.Max(ids)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if first <= j.floor {
+		return ErrBehindJournal
+	}
+	if j.file == nil {
+		return errors.New("server: journal closed")
+	}
+	if j.size >= j.segmentSize {
+		if err := j.rotate(); err != nil {
+			return err
+		}
+	}
+	if err := j.append(first, last); err != nil {
+		return err
+	}
+	j.max = max(j.max, last)
+	return nil
+}
+
+func (j *Journal) append(first, last int64) error {
+	line := strconv.AppendInt(nil, first, 10)
+	line = append(line, ' ')
+	line = strconv.AppendInt(line, last, 10)
+	line = append(line, '\n')
+	n, err := j.file.Write(line)
+	j.size += int64(n)
+	if err != nil {
+		return err
+	}
+	if j.sync == SyncEachBatch {
+		return j.file.Sync()
+	}
+	return nil
+}
+
+// rotate 关闭当前段，新段以当前最大 id 开头并立即落盘
+func (j *Journal) rotate() error {
+	if j.file != nil {
+		if err := j.file.Close(); err != nil {
+			return err
+		}
+		j.file = nil
+	}
+	j.seq++
+	f, err := os.OpenFile(j.segmentPath(j.seq), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	j.file, j.size = f, 0
+	if err := j.append(j.max, j.max); err != nil {
+		return err
+	}
+	if j.sync == SyncOS {
+		// 段头总是落盘，保证新段可用于恢复
+		if err := f.Sync(); err != nil {
+			return err
+		}
+	}
+	if d, err := os.Open(j.dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+func (j *Journal) segmentPath(seq int) string {
+	return filepath.Join(j.dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
+
+// segments 目录中的段序号，升序
+func (j *Journal) segments() ([]int, error) {
+	entries, err := os.ReadDir(j.dir)
+	if err != nil {
+		return nil, err
+	}
+	var seqs []int
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), segmentExt)
+		if !ok {
+			continue
+		}
+		if seq, err := strconv.Atoi(name); err == nil {
+			seqs = append(seqs, seq)
+		}
+	}
+	slices.Sort(seqs)
+	return seqs, nil
+}
+
+// recover 读取段中完整记录的最大 id，忽略崩溃时未写完的最后一行
+func (j *Journal) recover(seq int) (high int64, ok bool, err error) {
+	f, err := os.Open(j.segmentPath(seq))
+	if err != nil {
+		return 0, false, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			// 没有换行的残缺行对应的 id 从未返回
+			return high, ok, nil
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return 0, false, fmt.Errorf("server: corrupt journal %s: %q", f.Name(), line)
+		}
+		last, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("server: corrupt journal %s: %q", f.Name(), line)
+		}
+		high, ok = max(high, last), true
+	}
+}
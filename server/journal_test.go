@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/perlyna/snowflake"
+)
+
+func Test_Journal(t *testing.T) {
+	dir := t.TempDir()
+	j, err := OpenJournal(dir, 64, SyncEachBatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := snowflake.NewGenerator(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(g, WithJournal(j))
+	var last int64
+	for i := 0; i < 10; i++ {
+		rec := get(s, "/id", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /id = %d %s", rec.Code, rec.Body)
+		}
+		last, _ = strconv.ParseInt(rec.Body.String(), 10, 64)
+	}
+	if rec := get(s, "/ids?n=3", ""); rec.Code != http.StatusOK {
+		t.Fatalf("GET /ids = %d %s", rec.Code, rec.Body)
+	}
+	if j.Last() <= last {
+		t.Errorf("Last() = %d, want after %d", j.Last(), last)
+	}
+	high := j.Last()
+	j.Close()
+	if seqs, _ := j.segments(); len(seqs) < 2 {
+		t.Errorf("segments = %v, want rotation at 64 bytes", seqs)
+	}
+
+	// 崩溃时写了一半的记录
+	seqs, _ := j.segments()
+	f, err := os.OpenFile(j.segmentPath(seqs[len(seqs)-1]), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("99999999999999")
+	f.Close()
+
+	j2, err := OpenJournal(dir, 0, SyncOS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j2.Close()
+	if j2.Last() != high {
+		t.Fatalf("recovered %d, want %d", j2.Last(), high)
+	}
+	if err := j2.record(high); err != ErrBehindJournal {
+		t.Errorf("record(last) = %v, want ErrBehindJournal", err)
+	}
+	if err := j2.record(high+1, high+5); err != nil {
+		t.Error(err)
+	}
+	if j2.Last() != high+5 {
+		t.Errorf("Last() = %d", j2.Last())
+	}
+
+	// 时钟落后于上次运行：拒绝而不是重复发号
+	old := snowflake.DefaultLayout
+	old.Epoch = old.Epoch.AddDate(1, 0, 0)
+	behind, err := snowflake.NewGenerator(1, 1, snowflake.WithLayout(old))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := get(New(behind, WithJournal(j2)), "/id", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("behind journal = %d, want 503", rec.Code)
+	}
+}
+
+func Test_JournalCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "00000000000000000001"+segmentExt), []byte("bogus\n"), 0o644)
+	if _, err := OpenJournal(dir, 0, SyncEachBatch); err == nil {
+		t.Error("expected error for corrupt journal")
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+// ErrServerClosed returned by the Serve methods of the TCP servers after Close
+var ErrServerClosed = errors.New("server: closed")
+
+// tcpServer TCP 协议服务共用的监听与连接管理
+type tcpServer struct {
+	mu    sync.Mutex
+	ln    net.Listener
+	conns map[net.Conn]struct{}
+	done  bool
+}
+
+// serve 接受连接并为每个连接启动 handle，直到 close
+func (s *tcpServer) serve(l net.Listener, handle func(net.Conn)) error {
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
+		return ErrServerClosed
+	}
+	s.ln = l
+	if s.conns == nil {
+		s.conns = make(map[net.Conn]struct{})
+	}
+	s.mu.Unlock()
+	for {
+		conn, err := l.Accept()
+		s.mu.Lock()
+		if s.done {
+			s.mu.Unlock()
+			if conn != nil {
+				conn.Close()
+			}
+			return ErrServerClosed
+		}
+		if err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		go func() {
+			defer func() {
+				conn.Close()
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+			}()
+			handle(conn)
+		}()
+	}
+}
+
+// close 停止监听并关闭所有连接
+func (s *tcpServer) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done = true
+	var err error
+	if s.ln != nil {
+		err = s.ln.Close()
+	}
+	for c := range s.conns {
+		c.Close()
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/perlyna/snowflake"
+)
+
+// MemcacheKey key returning a fresh id from the memcached shim, every hit issues a new id
+const MemcacheKey = "id"
+
+// memcacheMaxLine 请求行上限，与 memcached 的 key 长度限制同量级
+const memcacheMaxLine = 4096
+
+// MemcacheServer listener speaking the memcached text protocol, so any memcached client can
+// fetch ids without a dedicated library:
+//
+//	get id          VALUE id 0 <len>\r\n<id>\r\nEND\r\n
+//	get id id id    three distinct ids
+//	gets id         same, with a cas value of 0
+//
+// Other keys are misses; version and quit are supported, any other command gets ERROR.
+type MemcacheServer struct {
+	g snowflake.Generator
+	tcpServer
+}
+
+// NewMemcacheServer return memcached shim issuing ids from g
+func NewMemcacheServer(g snowflake.Generator) *MemcacheServer {
+	return &MemcacheServer{g: g}
+}
+
+// Serve accept connections on l until Close, then return ErrServerClosed
+func (s *MemcacheServer) Serve(l net.Listener) error {
+	return s.serve(l, s.serveConn)
+}
+
+// Close stop accepting and close open connections
+func (s *MemcacheServer) Close() error {
+	return s.close()
+}
+
+func (s *MemcacheServer) serveConn(conn net.Conn) {
+	r := bufio.NewReaderSize(conn, memcacheMaxLine)
+	w := bufio.NewWriter(conn)
+	for {
+		line, err := r.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			w.WriteString("CLIENT_ERROR line too long\r\n")
+			w.Flush()
+			return
+		}
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(string(line))
+		if len(fields) == 0 {
+			w.WriteString("ERROR\r\n")
+		} else {
+			switch fields[0] {
+			case "get", "gets":
+				s.get(w, fields[0] == "gets", fields[1:])
+			case "version":
+				w.WriteString("VERSION snowflake\r\n")
+			case "quit":
+				w.Flush()
+				return
+			default:
+				w.WriteString("ERROR\r\n")
+			}
+		}
+		// 流水线请求在缓冲区读空后一起写出
+		if r.Buffered() == 0 && w.Flush() != nil {
+			return
+		}
+	}
+}
+
+func (s *MemcacheServer) get(w *bufio.Writer, cas bool, keys []string) {
+	if len(keys) == 0 {
+		w.WriteString("ERROR\r\n")
+		return
+	}
+	var buf []byte
+	for _, key := range keys {
+		if key != MemcacheKey {
+			continue
+		}
+		id, err := s.g.Next()
+		if err != nil {
+			w.WriteString("SERVER_ERROR " + strings.ReplaceAll(err.Error(), "\r\n", " ") + "\r\n")
+			return
+		}
+		value := strconv.AppendInt(nil, id, 10)
+		buf = append(buf[:0], "VALUE "+key+" 0 "...)
+		buf = strconv.AppendInt(buf, int64(len(value)), 10)
+		if cas {
+			buf = append(buf, " 0"...)
+		}
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, value...)
+		buf = append(buf, "\r\n"...)
+		w.Write(buf)
+	}
+	w.WriteString("END\r\n")
+}
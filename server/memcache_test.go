@@ -0,0 +1,81 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+func Test_MemcacheServer(t *testing.T) {
+	g, err := snowflake.NewGenerator(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewMemcacheServer(g)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ln) }()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	readLine := func() string {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSuffix(line, "\r\n")
+	}
+
+	conn.Write([]byte("get id other id\r\ngets id\r\nversion\r\nbogus\r\n"))
+	seen := map[int64]bool{}
+	for _, cas := range []bool{false, false, true} {
+		header := strings.Fields(readLine())
+		want := 4
+		if cas {
+			want = 5
+		}
+		if len(header) != want || header[0] != "VALUE" || header[1] != "id" || header[2] != "0" {
+			t.Fatalf("header = %q", header)
+		}
+		value := readLine()
+		if n, _ := strconv.Atoi(header[3]); n != len(value) {
+			t.Errorf("length %s, value %q", header[3], value)
+		}
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || !snowflake.IsValid(id) || seen[id] {
+			t.Errorf("value %q is not a fresh id", value)
+		}
+		seen[id] = true
+		if cas {
+			continue
+		}
+		if len(seen) == 2 {
+			if got := readLine(); got != "END" {
+				t.Errorf("got %q, want END", got)
+			}
+		}
+	}
+	for _, want := range []string{"END", "VERSION snowflake", "ERROR"} {
+		if got := readLine(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+
+	s.Close()
+	if err := <-done; err != ErrServerClosed {
+		t.Errorf("Serve = %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+// DefaultOTLPInterval default push interval of OTLPExporter
+const DefaultOTLPInterval = 10 * time.Second
+
+// OTLPExporter push generator metrics to an OpenTelemetry collector (or a Datadog agent with
+// OTLP ingestion) using OTLP/HTTP with JSON encoding, for deployments without scraping.
+// Exported metrics: snowflake.ids.issued, snowflake.waits, snowflake.wait.duration,
+// snowflake.smear.steps, snowflake.smear.frozen (cumulative sums) and snowflake.saturation (gauge).
+type OTLPExporter struct {
+	Endpoint   string            // e.g. http://localhost:4318/v1/metrics
+	Headers    map[string]string // extra request headers, e.g. authentication
+	Attributes map[string]string // resource attributes, service.name defaults to "snowflake"
+	Interval   time.Duration     // push interval of Run, 0 means DefaultOTLPInterval
+	Client     *http.Client      // nil means http.DefaultClient
+	OnError    func(error)       // called with failed pushes of Run, nil ignores them
+
+	once  sync.Once
+	start time.Time // 累计指标的起始时间
+}
+
+// Run push metrics of g every Interval until ctx is done, then push once more with a
+// short timeout and return ctx.Err()
+func (e *OTLPExporter) Run(ctx context.Context, g snowflake.Generator) error {
+	interval := e.Interval
+	if interval <= 0 {
+		interval = DefaultOTLPInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			final, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			e.report(e.Export(final, g))
+			cancel()
+			return ctx.Err()
+		case <-ticker.C:
+			e.report(e.Export(ctx, g))
+		}
+	}
+}
+
+func (e *OTLPExporter) report(err error) {
+	if err != nil && e.OnError != nil {
+		e.OnError(err)
+	}
+}
+
+// Export push the current metrics of g once
+func (e *OTLPExporter) Export(ctx context.Context, g snowflake.Generator) error {
+	e.once.Do(func() { e.start = time.Now() })
+	body, err := json.Marshal(e.payload(g.Stats(), snowflake.Saturation(g), time.Now()))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(res.Body, 1<<10))
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("otlp: %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// 以下为 OTLP 的 protobuf JSON 映射，64 位整数编码为字符串
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+type otlpPoint struct {
+	StartTimeUnixNano string   `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string   `json:"timeUnixNano"`
+	AsInt             string   `json:"asInt,omitempty"`
+	AsDouble          *float64 `json:"asDouble,omitempty"`
+}
+
+type otlpSum struct {
+	AggregationTemporality int         `json:"aggregationTemporality"` // 2 = CUMULATIVE
+	IsMonotonic            bool        `json:"isMonotonic"`
+	DataPoints             []otlpPoint `json:"dataPoints"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpPoint `json:"dataPoints"`
+}
+
+type otlpMetric struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Unit        string     `json:"unit,omitempty"`
+	Sum         *otlpSum   `json:"sum,omitempty"`
+	Gauge       *otlpGauge `json:"gauge,omitempty"`
+}
+
+type otlpScopeMetrics struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	} `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+func (e *OTLPExporter) payload(st snowflake.Stats, saturation float64, now time.Time) otlpRequest {
+	start, ts := nanos(e.start), nanos(now)
+	sum := func(name, unit, desc string, v uint64) otlpMetric {
+		p := otlpPoint{StartTimeUnixNano: start, TimeUnixNano: ts, AsInt: strconv.FormatUint(v, 10)}
+		return otlpMetric{Name: name, Unit: unit, Description: desc, Sum: &otlpSum{AggregationTemporality: 2, IsMonotonic: true, DataPoints: []otlpPoint{p}}}
+	}
+	waited, depth := st.Waited.Seconds(), st.BorrowDepth.Seconds()
+	metrics := []otlpMetric{
+		sum("snowflake.ids.issued", "{id}", "ids returned", st.Issued),
+		sum("snowflake.waits", "{wait}", "times the sequence was exhausted", st.Waits),
+		{Name: "snowflake.wait.duration", Unit: "s", Description: "time spent waiting for the next millisecond",
+			Sum: &otlpSum{AggregationTemporality: 2, IsMonotonic: true, DataPoints: []otlpPoint{{StartTimeUnixNano: start, TimeUnixNano: ts, AsDouble: &waited}}}},
+		sum("snowflake.smear.steps", "{step}", "tolerated backwards clock steps", st.Steps),
+		sum("snowflake.smear.frozen", "{id}", "ids issued from a frozen timestamp", st.Frozen),
+		sum("snowflake.borrow.borrowed", "ms", "milliseconds borrowed ahead of the clock", st.Borrowed),
+		{Name: "snowflake.borrow.depth", Unit: "s", Description: "how far the latest id's timestamp is ahead of the clock",
+			Gauge: &otlpGauge{DataPoints: []otlpPoint{{TimeUnixNano: ts, AsDouble: &depth}}}},
+		{Name: "snowflake.saturation", Unit: "1", Description: "fraction of recent milliseconds with an exhausted sequence",
+			Gauge: &otlpGauge{DataPoints: []otlpPoint{{TimeUnixNano: ts, AsDouble: &saturation}}}},
+	}
+
+	attrs := map[string]string{"service.name": "snowflake"}
+	for k, v := range e.Attributes {
+		attrs[k] = v
+	}
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var rm otlpResourceMetrics
+	for _, k := range keys {
+		a := otlpAttribute{Key: k}
+		a.Value.StringValue = attrs[k]
+		rm.Resource.Attributes = append(rm.Resource.Attributes, a)
+	}
+	sm := otlpScopeMetrics{Metrics: metrics}
+	sm.Scope.Name = "github.com/perlyna/snowflake/server"
+	rm.ScopeMetrics = []otlpScopeMetrics{sm}
+	return otlpRequest{ResourceMetrics: []otlpResourceMetrics{rm}}
+}
+
+func nanos(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+func Test_OTLPExporter(t *testing.T) {
+	var mu sync.Mutex
+	var got []otlpRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" || r.Header.Get("Content-Type") != "application/json" || r.Header.Get("DD-API-KEY") != "k" {
+			http.Error(rw, "bad request", http.StatusBadRequest)
+			return
+		}
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		got = append(got, req)
+		mu.Unlock()
+		rw.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	g, err := snowflake.NewGenerator(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.NextN(10)
+	e := &OTLPExporter{
+		Endpoint:   srv.URL + "/v1/metrics",
+		Headers:    map[string]string{"DD-API-KEY": "k"},
+		Attributes: map[string]string{"host.name": "node-1"},
+		Interval:   10 * time.Millisecond,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := e.Run(ctx, g); err != context.DeadlineExceeded {
+		t.Errorf("Run = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) < 2 {
+		t.Fatalf("pushes = %d", len(got))
+	}
+	rm := got[len(got)-1].ResourceMetrics[0]
+	attrs := map[string]string{}
+	for _, a := range rm.Resource.Attributes {
+		attrs[a.Key] = a.Value.StringValue
+	}
+	if attrs["service.name"] != "snowflake" || attrs["host.name"] != "node-1" {
+		t.Errorf("attributes = %v", attrs)
+	}
+	metrics := map[string]otlpMetric{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+	if m := metrics["snowflake.ids.issued"]; m.Sum == nil || !m.Sum.IsMonotonic || m.Sum.DataPoints[0].AsInt != "10" {
+		t.Errorf("issued = %+v", m)
+	}
+	if m := metrics["snowflake.saturation"]; m.Gauge == nil || m.Gauge.DataPoints[0].AsDouble == nil {
+		t.Errorf("saturation = %+v", m)
+	}
+	if m := metrics["snowflake.borrow.depth"]; m.Gauge == nil || m.Gauge.DataPoints[0].AsDouble == nil {
+		t.Errorf("borrow depth = %+v", m)
+	}
+
+	bad := &OTLPExporter{Endpoint: srv.URL + "/v1/metrics"}
+	if err := bad.Export(context.Background(), g); err == nil {
+		t.Error("expected error for rejected push")
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// APIKeyHeader header identifying the caller for quotas, requests without it share the "" key
+const APIKeyHeader = "X-Api-Key"
+
+// Quota limits of one caller
+type Quota struct {
+	PerMinute int // ids per minute, also the burst size; 0 means unlimited
+	MaxBatch  int // ids per batch request; 0 means the server cap
+}
+
+// WithQuota enforce perKey[key] for the callers whose API key has an entry, and def for all
+// other callers together: unknown keys share one bucket, so rotating keys does not raise the
+// quota. Callers over their rate get 429 with Retry-After (seconds) and should back off at
+// least that long; batches above MaxBatch or PerMinute can never be served and get 400.
+// A MaxBatch above PerMinute is clamped to PerMinute
+func WithQuota(def Quota, perKey map[string]Quota) Option {
+	def = def.clamp()
+	clamped := make(map[string]Quota, len(perKey))
+	for key, q := range perKey {
+		clamped[key] = q.clamp()
+	}
+	perKey = clamped
+	return func(s *Server) {
+		s.quotas = &quotas{def: def, perKey: perKey, buckets: make(map[string]*bucket), now: time.Now}
+	}
+}
+
+// clamp 单批上限不超过每分钟配额，否则该批永远拿不到足够的令牌
+func (q Quota) clamp() Quota {
+	if q.PerMinute > 0 && (q.MaxBatch <= 0 || q.MaxBatch > q.PerMinute) {
+		q.MaxBatch = q.PerMinute
+	}
+	return q
+}
+
+// idleSweep 清理已补满的令牌桶的间隔，补满的桶与不存在时等价
+const idleSweep = time.Minute
+
+type quotas struct {
+	def    Quota
+	perKey map[string]Quota
+	now    func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket // 只含 perKey 中的 key
+	shared  *bucket            // 未配置的 key 共用，def 的桶
+	swept   time.Time
+}
+
+// bucket 令牌桶，容量与每分钟补充量均为 PerMinute
+type bucket struct {
+	quota  Quota
+	tokens float64
+	last   time.Time
+}
+
+func (q *quotas) quota(key string) Quota {
+	if quota, ok := q.perKey[key]; ok {
+		return quota
+	}
+	return q.def
+}
+
+// maxBatch 调用方单批的上限：MaxBatch、PerMinute 与服务器上限 limit 中最小的
+func (q *quotas) maxBatch(key string, limit int) int {
+	quota := q.quota(key)
+	if quota.MaxBatch > 0 {
+		limit = min(limit, quota.MaxBatch)
+	}
+	if quota.PerMinute > 0 {
+		limit = min(limit, quota.PerMinute)
+	}
+	return limit
+}
+
+// take 消耗 n 个令牌，不足时返回建议的重试秒数；n 不超过 maxBatch
+func (q *quotas) take(key string, n int) (retryAfter int, ok bool) {
+	quota, known := q.perKey[key]
+	if !known {
+		quota = q.def
+	}
+	if quota.PerMinute <= 0 {
+		return 0, true
+	}
+	now := q.now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.sweep(now)
+	b := q.shared
+	if known {
+		b = q.buckets[key]
+	}
+	if b == nil {
+		b = &bucket{quota: quota, tokens: float64(quota.PerMinute), last: now}
+		if known {
+			q.buckets[key] = b
+		} else {
+			q.shared = b
+		}
+	}
+	b.refill(now)
+	if b.tokens < float64(n) {
+		wait := time.Duration((float64(n) - b.tokens) / b.rate())
+		return int(math.Ceil(wait.Seconds())), false
+	}
+	b.tokens -= float64(n)
+	return 0, true
+}
+
+// rate 每纳秒补充的令牌数
+func (b *bucket) rate() float64 {
+	return float64(b.quota.PerMinute) / float64(time.Minute)
+}
+
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(float64(b.quota.PerMinute), b.tokens+float64(now.Sub(b.last))*b.rate())
+	b.last = now
+}
+
+// sweep 删除已补满的桶，调用方需持有 q.mu
+func (q *quotas) sweep(now time.Time) {
+	if now.Sub(q.swept) < idleSweep {
+		return
+	}
+	q.swept = now
+	for key, b := range q.buckets {
+		if b.refill(now); b.tokens >= float64(b.quota.PerMinute) {
+			delete(q.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Package server HTTP id service on top of a snowflake.Generator
+package server
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+// OpenAPI OpenAPI 3.1 definition of the HTTP service, for generating clients in other languages
+//
+//go:embed openapi.json
+var OpenAPI []byte
+
+// DefaultMaxBatch default cap of ids per batch request
+const DefaultMaxBatch = 10000
+
+// DefaultMaxStream default cap of ids per streamed batch request
+const DefaultMaxStream = 10000000
+
+// Server HTTP id service:
+//
+//	GET /id            one id as plain text
+//	GET /ids?n=N       N ids as JSON {"ids":["..."]}, strings to keep precision in JavaScript
+//	POST /ids?n=N      N ids streamed as they are issued, see handleStream
+//	GET /time          local clock as decimal unix nanoseconds, for MeasureSkew
+//	GET /readyz        readiness, see WithSkewReadiness
+//	GET /openapi.json  OpenAPI 3.1 definition of the above, see OpenAPI
+//	GET /debug/snowflake  HTML status page, see WithDashboard
+type Server struct {
+	g         snowflake.Generator
+	mux       *http.ServeMux
+	maxBatch  int
+	maxStream int
+	quotas    *quotas
+	journal   *Journal
+	coalesce  *coalescer
+
+	readyPeers Peers
+	readySkew  time.Duration
+	dashboard  *Dashboard
+	smooth     *smoother
+}
+
+// Option server option
+type Option func(*Server)
+
+// WithMaxBatch cap ids per batch request, default DefaultMaxBatch
+func WithMaxBatch(n int) Option {
+	return func(s *Server) {
+		s.maxBatch = n
+	}
+}
+
+// WithJournal record every issued batch in j before returning it, and refuse ids at or
+// before the last id of a previous run with 503
+func WithJournal(j *Journal) Option {
+	return func(s *Server) {
+		s.journal = j
+	}
+}
+
+// WithMaxStream cap ids per streamed batch request (POST /ids), default DefaultMaxStream
+func WithMaxStream(n int) Option {
+	return func(s *Server) {
+		s.maxStream = n
+	}
+}
+
+// New return server issuing ids from g
+func New(g snowflake.Generator, opts ...Option) *Server {
+	s := &Server{g: g, mux: http.NewServeMux(), maxBatch: DefaultMaxBatch, maxStream: DefaultMaxStream}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.coalesce != nil {
+		s.coalesce.g, s.coalesce.journal = g, s.journal
+		s.coalesce.max = min(s.coalesce.max, s.maxBatch)
+	}
+	s.mux.HandleFunc("/id", getOnly(s.handleID))
+	s.mux.HandleFunc("/ids", getOrPost(s.handleIDs, s.handleStream))
+	s.mux.HandleFunc("/time", getOnly(s.handleTime))
+	s.mux.HandleFunc("/readyz", getOnly(s.handleReady))
+	if s.dashboard != nil {
+		s.mux.HandleFunc("/debug/snowflake", getOnly(s.handleDashboard))
+	}
+	s.mux.HandleFunc("/openapi.json", getOnly(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(OpenAPI)
+	}))
+	return s
+}
+
+// ServeHTTP implement http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// getOnly 只接受 GET(及 HEAD)请求
+func getOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// getOrPost GET(及 HEAD)交给 get，POST 交给 post
+func getOrPost(get, post http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			get(w, r)
+		case http.MethodPost:
+			post(w, r)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func (s *Server) handleID(w http.ResponseWriter, r *http.Request) {
+	if !s.admit(w, r, 1, s.maxBatch) {
+		return
+	}
+	var id int64
+	var err error
+	if s.coalesce != nil {
+		id, err = s.coalesce.next(r.Context())
+	} else if id, err = s.g.NextContext(r.Context()); err == nil && s.journal != nil {
+		err = s.journal.record(id)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(strconv.AppendInt(nil, id, 10))
+}
+
+func (s *Server) handleIDs(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.Atoi(r.URL.Query().Get("n"))
+	if err != nil || n <= 0 {
+		http.Error(w, "n must be a positive integer", http.StatusBadRequest)
+		return
+	}
+	if !s.admit(w, r, n, s.maxBatch) {
+		return
+	}
+	ids := make([]int64, n)
+	err = s.fill(r.Context(), ids)
+	if err == nil && s.journal != nil {
+		err = s.journal.record(ids...)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	resp := struct {
+		IDs []string `json:"ids"`
+	}{IDs: make([]string, len(ids))}
+	for i, id := range ids {
+		resp.IDs[i] = strconv.FormatInt(id, 10)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// admit 检查批量上限 maxBatch 与调用方配额，不通过时已写入响应
+func (s *Server) admit(w http.ResponseWriter, r *http.Request, n, maxBatch int) bool {
+	if s.quotas != nil {
+		key := r.Header.Get(APIKeyHeader)
+		maxBatch = s.quotas.maxBatch(key, maxBatch)
+		if n <= maxBatch {
+			if retry, ok := s.quotas.take(key, n); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(retry))
+				http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+				return false
+			}
+		}
+	}
+	if n > maxBatch {
+		http.Error(w, "batch too large, max "+strconv.Itoa(maxBatch), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
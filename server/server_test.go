@@ -0,0 +1,117 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+func newTestServer(t *testing.T, opts ...Option) *Server {
+	g, err := snowflake.NewGenerator(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(g, opts...)
+}
+
+func get(s http.Handler, url, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", url, nil)
+	if key != "" {
+		req.Header.Set(APIKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func Test_Server(t *testing.T) {
+	s := newTestServer(t, WithMaxBatch(100))
+	rec := get(s, "/id", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /id = %d %s", rec.Code, rec.Body)
+	}
+	if id, err := strconv.ParseInt(rec.Body.String(), 10, 64); err != nil || !snowflake.IsValid(id) {
+		t.Errorf("GET /id body %q is not an id", rec.Body)
+	}
+
+	rec = get(s, "/ids?n=5", "")
+	var resp struct {
+		IDs []string `json:"ids"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || len(resp.IDs) != 5 {
+		t.Fatalf("GET /ids = %d %s", rec.Code, rec.Body)
+	}
+
+	for _, url := range []string{"/ids", "/ids?n=0", "/ids?n=101"} {
+		if rec := get(s, url, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s = %d, want 400", url, rec.Code)
+		}
+	}
+}
+
+func Test_Quota(t *testing.T) {
+	s := newTestServer(t, WithQuota(Quota{PerMinute: 10, MaxBatch: 5}, map[string]Quota{"batch": {PerMinute: 1000}, "small": {PerMinute: 20}}))
+	now := time.Now()
+	s.quotas.now = func() time.Time { return now }
+
+	if rec := get(s, "/ids?n=6", "a"); rec.Code != http.StatusBadRequest {
+		t.Errorf("batch over cap = %d, want 400", rec.Code)
+	}
+	if rec := get(s, "/ids?n=5", "a"); rec.Code != http.StatusOK {
+		t.Fatalf("first batch = %d %s", rec.Code, rec.Body)
+	}
+	if rec := get(s, "/ids?n=5", "a"); rec.Code != http.StatusOK {
+		t.Fatalf("second batch = %d %s", rec.Code, rec.Body)
+	}
+	rec := get(s, "/id", "a")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "6" {
+		t.Errorf("over quota = %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	// 未配置的 key 共用默认配额，轮换 key 不能绕过
+	if rec := get(s, "/id", "b"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("unknown key = %d, want 429 from the shared bucket", rec.Code)
+	}
+	if rec := get(s, "/ids?n=500", "batch"); rec.Code != http.StatusOK {
+		t.Errorf("per key quota = %d %s", rec.Code, rec.Body)
+	}
+	// 超过每分钟配额的批量永远无法满足
+	if rec := get(s, "/ids?n=21", "small"); rec.Code != http.StatusBadRequest {
+		t.Errorf("batch over PerMinute = %d, want 400", rec.Code)
+	}
+	if rec := get(s, "/ids?n=20", "small"); rec.Code != http.StatusOK {
+		t.Errorf("batch of PerMinute = %d %s", rec.Code, rec.Body)
+	}
+	if len(s.quotas.buckets) != 2 {
+		t.Errorf("%d buckets, want one per configured key", len(s.quotas.buckets))
+	}
+
+	now = now.Add(6 * time.Second)
+	if rec := get(s, "/id", "a"); rec.Code != http.StatusOK {
+		t.Errorf("after refill = %d", rec.Code)
+	}
+
+	// 空闲后补满的桶被清理
+	now = now.Add(2 * time.Minute)
+	get(s, "/id", "c")
+	if len(s.quotas.buckets) != 0 {
+		t.Errorf("%d idle buckets kept", len(s.quotas.buckets))
+	}
+}
+
+func Test_QuotaClamp(t *testing.T) {
+	s := newTestServer(t, WithQuota(Quota{PerMinute: 10}, map[string]Quota{"a": {PerMinute: 5, MaxBatch: 10}}))
+	if q := s.quotas.quota("a"); q.MaxBatch != 5 {
+		t.Errorf("MaxBatch %d, want clamped to PerMinute 5", q.MaxBatch)
+	}
+	if rec := get(s, "/ids?n=6", "a"); rec.Code != http.StatusBadRequest {
+		t.Errorf("batch over clamped cap = %d, want 400", rec.Code)
+	}
+	if rec := get(s, "/ids?n=5", "a"); rec.Code != http.StatusOK {
+		t.Errorf("batch of clamped cap = %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PeerOffset clock offset of one peer measured by MeasureSkew
+type PeerOffset struct {
+	Peer   string
+	Offset time.Duration // peer clock minus local clock, accurate to within RTT/2
+	RTT    time.Duration
+	Err    error // set when the peer did not answer, Offset and RTT are then 0
+}
+
+// MeasureSkew query GET /time on every peer id server (base URLs such as
+// http://ids-2.internal:8080) concurrently and return each peer's clock offset, estimated
+// NTP-style from the midpoint of the round trip; the error is set only when no peer answered
+func MeasureSkew(ctx context.Context, peers []string) ([]PeerOffset, error) {
+	offsets := make([]PeerOffset, len(peers))
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			offsets[i] = measurePeer(ctx, peer)
+		}()
+	}
+	wg.Wait()
+	for _, o := range offsets {
+		if o.Err == nil {
+			return offsets, nil
+		}
+	}
+	if len(offsets) == 0 {
+		return offsets, fmt.Errorf("no peers")
+	}
+	return offsets, fmt.Errorf("no peer answered: %v", offsets[0].Err)
+}
+
+func measurePeer(ctx context.Context, peer string) PeerOffset {
+	o := PeerOffset{Peer: peer}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(peer, "/")+"/time", nil)
+	if err != nil {
+		o.Err = err
+		return o
+	}
+	start := time.Now()
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		o.Err = err
+		return o
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 64))
+	end := time.Now()
+	if err != nil {
+		o.Err = err
+		return o
+	}
+	if res.StatusCode != http.StatusOK {
+		o.Err = fmt.Errorf("%s: GET /time = %d", peer, res.StatusCode)
+		return o
+	}
+	nanos, err := strconv.ParseInt(strings.TrimSpace(string(body)), 10, 64)
+	if err != nil {
+		o.Err = fmt.Errorf("%s: GET /time: %v", peer, err)
+		return o
+	}
+	o.RTT = end.Sub(start)
+	o.Offset = time.Unix(0, nanos).Sub(start.Add(o.RTT / 2))
+	return o
+}
+
+// Peers base URLs of peer id servers, a snowflake.SkewSource measuring them with MeasureSkew:
+// snowflake.WithSkewGuard(server.Peers{"http://ids-2:8080", "http://ids-3:8080"}, 100*time.Millisecond, 10*time.Second)
+type Peers []string
+
+// PeerOffsets return the offsets of the peers that answered
+func (p Peers) PeerOffsets(ctx context.Context) ([]time.Duration, error) {
+	offsets, err := MeasureSkew(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	var ds []time.Duration
+	for _, o := range offsets {
+		if o.Err == nil {
+			ds = append(ds, o.Offset)
+		}
+	}
+	return ds, nil
+}
+
+// WithSkewReadiness make GET /readyz measure the peers on every probe and answer 503 while
+// the median offset exceeds threshold, so a drifted node is taken out of rotation before
+// it issues ids out of order with the fleet; peers that do not answer are ignored
+func WithSkewReadiness(peers Peers, threshold time.Duration) Option {
+	return func(s *Server) {
+		s.readyPeers, s.readySkew = peers, threshold
+	}
+}
+
+// handleTime 以十进制 unix 纳秒返回本机时间，供 MeasureSkew 测量
+func (s *Server) handleTime(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(strconv.AppendInt(nil, time.Now().UnixNano(), 10))
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if len(s.readyPeers) > 0 {
+		offsets, err := s.readyPeers.PeerOffsets(r.Context())
+		if err == nil && len(offsets) > 0 {
+			if median := medianOffset(offsets); median > s.readySkew || median < -s.readySkew {
+				http.Error(w, fmt.Sprintf("clock skew %s exceeds %s", median, s.readySkew), http.StatusServiceUnavailable)
+				return
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("ok"))
+}
+
+func medianOffset(ds []time.Duration) time.Duration {
+	s := slices.Clone(ds)
+	slices.Sort(s)
+	if len(s)%2 == 1 {
+		return s[len(s)/2]
+	}
+	return (s[len(s)/2-1] + s[len(s)/2]) / 2
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+// fakePeer 时钟偏移 offset 的对等节点
+func fakePeer(t *testing.T, offset time.Duration) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/time" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(strconv.FormatInt(time.Now().Add(offset).UnixNano(), 10)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func Test_MeasureSkew(t *testing.T) {
+	ctx := context.Background()
+	real := httptest.NewServer(newTestServer(t))
+	defer real.Close()
+	ahead := fakePeer(t, time.Hour)
+	offsets, err := MeasureSkew(ctx, []string{real.URL, ahead, "http://127.0.0.1:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := offsets[0]; o.Err != nil || o.Offset.Abs() > o.RTT+10*time.Millisecond {
+		t.Errorf("same clock = %+v", o)
+	}
+	if o := offsets[1]; o.Err != nil || (o.Offset-time.Hour).Abs() > o.RTT+10*time.Millisecond {
+		t.Errorf("hour ahead = %+v", o)
+	}
+	if offsets[2].Err == nil {
+		t.Error("unreachable peer answered")
+	}
+	if _, err := MeasureSkew(ctx, []string{"http://127.0.0.1:1"}); err == nil {
+		t.Error("MeasureSkew without answers succeeded")
+	}
+
+	// Peers 作为 SkewSource，全部对等节点领先一小时时拒绝创建
+	_, err = snowflake.NewGenerator(1, 1, snowflake.WithSkewGuard(Peers{ahead, ahead}, time.Second, time.Second))
+	if !errors.Is(err, snowflake.ErrClockSkew) {
+		t.Errorf("skew guard = %v", err)
+	}
+}
+
+func Test_SkewReadiness(t *testing.T) {
+	if rec := get(newTestServer(t), "/readyz", ""); rec.Code != http.StatusOK {
+		t.Errorf("GET /readyz without peers = %d", rec.Code)
+	}
+	inSync := Peers{fakePeer(t, 0), fakePeer(t, time.Hour), fakePeer(t, 0)}
+	if rec := get(newTestServer(t, WithSkewReadiness(inSync, time.Second)), "/readyz", ""); rec.Code != http.StatusOK {
+		t.Errorf("GET /readyz with median in sync = %d %s", rec.Code, rec.Body)
+	}
+	drifted := Peers{fakePeer(t, time.Hour), fakePeer(t, time.Hour), fakePeer(t, 0)}
+	rec := get(newTestServer(t, WithSkewReadiness(drifted, time.Second)), "/readyz", "")
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "clock skew") {
+		t.Errorf("GET /readyz drifted = %d %s", rec.Code, rec.Body)
+	}
+}
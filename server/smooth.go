@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+// DefaultSmoothingChunk default chunk size of WithBatchSmoothing
+const DefaultSmoothingChunk = 256
+
+// WithBatchSmoothing fulfil batch requests (GET and POST /ids) in chunks of at most chunk
+// ids (0 means DefaultSmoothingChunk), releasing the generator between chunks so single id
+// requests queued behind a large batch wait for one chunk instead of the whole batch.
+// With rate > 0, chunks also draw from a token bucket shared by all batches, refilled at
+// rate ids per second with a burst of one chunk, leaving the rest of the generator's
+// capacity to single ids; a batch waits for tokens and gives up when its request ends,
+// returning the tokens of the chunk it was waiting for
+func WithBatchSmoothing(chunk, rate int) Option {
+	return func(s *Server) {
+		if chunk <= 0 {
+			chunk = DefaultSmoothingChunk
+		}
+		s.smooth = &smoother{chunk: chunk, rate: float64(rate) / float64(time.Second), now: time.Now}
+	}
+}
+
+// smoother 批量请求的分块与令牌桶，令牌可透支，透支量即后续请求的等待时长
+type smoother struct {
+	chunk int
+	rate  float64 // 每纳秒补充的令牌数，0 表示不限速
+	now   func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// reserve 预订 n 个令牌，返回需要等待的时长
+func (sm *smoother) reserve(n int) time.Duration {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	now := sm.now()
+	if sm.last.IsZero() {
+		sm.tokens = float64(sm.chunk)
+	} else {
+		sm.tokens = min(float64(sm.chunk), sm.tokens+float64(now.Sub(sm.last))*sm.rate)
+	}
+	sm.last = now
+	sm.tokens -= float64(n)
+	if sm.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-sm.tokens / sm.rate)
+}
+
+// cancel 退还未使用的 n 个令牌，减少后续预订的等待
+func (sm *smoother) cancel(n int) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.tokens = min(float64(sm.chunk), sm.tokens+float64(n))
+}
+
+// wait 等待 n 个令牌，ctx 结束时退还预订并返回其错误
+func (sm *smoother) wait(ctx context.Context, n int) error {
+	if sm.rate <= 0 {
+		return ctx.Err()
+	}
+	d := sm.reserve(n)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		sm.cancel(n)
+		return ctx.Err()
+	}
+}
+
+// fill 填充批量请求的 ids，启用平滑时逐块等待令牌并在块之间释放生成器
+func (s *Server) fill(ctx context.Context, ids []int64) error {
+	if s.smooth == nil {
+		return snowflake.Fill(s.g, ids)
+	}
+	for len(ids) > 0 {
+		chunk := ids[:min(len(ids), s.smooth.chunk)]
+		if err := s.smooth.wait(ctx, len(chunk)); err != nil {
+			return err
+		}
+		if err := snowflake.Fill(s.g, chunk); err != nil {
+			return err
+		}
+		ids = ids[len(chunk):]
+	}
+	return nil
+}
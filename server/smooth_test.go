@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_smootherReserve(t *testing.T) {
+	now := time.Unix(0, 0)
+	sm := &smoother{chunk: 100, rate: 1000 / float64(time.Second), now: func() time.Time { return now }}
+	if d := sm.reserve(100); d != 0 {
+		t.Errorf("first chunk waits %s", d)
+	}
+	if d := sm.reserve(100); d != 100*time.Millisecond {
+		t.Errorf("second chunk waits %s, want 100ms", d)
+	}
+	now = now.Add(time.Second)
+	// 补充量不超过一块
+	if d := sm.reserve(100); d != 0 {
+		t.Errorf("after refill waits %s", d)
+	}
+	if d := sm.reserve(50); d != 50*time.Millisecond {
+		t.Errorf("half chunk waits %s, want 50ms", d)
+	}
+}
+
+func Test_smootherCancel(t *testing.T) {
+	now := time.Unix(0, 0)
+	sm := &smoother{chunk: 100, rate: 1000 / float64(time.Second), now: func() time.Time { return now }}
+	sm.reserve(100)
+	// 等待令牌时请求结束，预订的令牌退还
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := sm.wait(ctx, 100); err != context.DeadlineExceeded {
+		t.Fatalf("wait = %v", err)
+	}
+	if d := sm.reserve(100); d != 100*time.Millisecond {
+		t.Errorf("chunk after a canceled wait waits %s, want 100ms", d)
+	}
+}
+
+func Test_BatchSmoothing(t *testing.T) {
+	s := newTestServer(t, WithBatchSmoothing(100, 20000))
+	start := time.Now()
+	var single time.Duration
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		time.Sleep(20 * time.Millisecond)
+		begin := time.Now()
+		if rec := get(s, "/id", ""); rec.Code != http.StatusOK {
+			t.Errorf("GET /id during batch = %d", rec.Code)
+		}
+		single = time.Since(begin)
+	}()
+	rec := get(s, "/ids?n=2000", "")
+	elapsed := time.Since(start)
+	wg.Wait()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /ids = %d %s", rec.Code, rec.Body)
+	}
+	var resp struct{ IDs []string }
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || len(resp.IDs) != 2000 {
+		t.Fatalf("GET /ids: %d ids, %v", len(resp.IDs), err)
+	}
+	// 首块立即发放，其余 1900 个以 20000/s 发放
+	if elapsed < 80*time.Millisecond {
+		t.Errorf("2000 ids at 20000/s took %s", elapsed)
+	}
+	if single > 50*time.Millisecond {
+		t.Errorf("single id waited %s behind the batch", single)
+	}
+
+	// 请求结束后不再等待令牌
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.fill(ctx, make([]int64, 2000)); err != context.DeadlineExceeded {
+		t.Errorf("fill after deadline = %v", err)
+	}
+
+	// 不限速时只分块
+	s = newTestServer(t, WithBatchSmoothing(0, 0))
+	if s.smooth.chunk != DefaultSmoothingChunk {
+		t.Errorf("default chunk = %d", s.smooth.chunk)
+	}
+	if rec := get(s, "/ids?n=1000", ""); rec.Code != http.StatusOK {
+		t.Errorf("GET /ids unlimited = %d", rec.Code)
+	}
+}
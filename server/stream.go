@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrorTrailer trailer set when a streamed batch ends early
+const ErrorTrailer = "X-Snowflake-Error"
+
+// streamChunk 每次生成并写出的 id 数
+const streamChunk = 1024
+
+// handleStream POST /ids?n=N：边生成边写出，客户端无需等待整批完成。
+// 默认输出 ND-JSON，每行一个 JSON 字符串；Accept 为 text/plain 时每行一个十进制 id。
+// 中途出错时 ND-JSON 以 {"error":"..."} 行结束，两种格式都设置 ErrorTrailer
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.Atoi(r.URL.Query().Get("n"))
+	if err != nil || n <= 0 {
+		http.Error(w, "n must be a positive integer", http.StatusBadRequest)
+		return
+	}
+	if !s.admit(w, r, n, s.maxStream) {
+		return
+	}
+	ndjson := !strings.HasPrefix(r.Header.Get("Accept"), "text/plain")
+	if ndjson {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.Header().Set("Trailer", ErrorTrailer)
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	ids := make([]int64, min(n, streamChunk))
+	buf := make([]byte, 0, len(ids)*22)
+	for n > 0 {
+		if err = r.Context().Err(); err != nil {
+			// 客户端已断开
+			return
+		}
+		chunk := ids[:min(n, len(ids))]
+		if err = s.fill(r.Context(), chunk); err == nil && s.journal != nil {
+			err = s.journal.record(chunk...)
+		}
+		if err != nil {
+			break
+		}
+		buf = buf[:0]
+		for _, id := range chunk {
+			if ndjson {
+				buf = append(buf, '"')
+				buf = strconv.AppendInt(buf, id, 10)
+				buf = append(buf, '"', '\n')
+			} else {
+				buf = strconv.AppendInt(buf, id, 10)
+				buf = append(buf, '\n')
+			}
+		}
+		if _, err := w.Write(buf); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		n -= len(chunk)
+	}
+	if err != nil {
+		if ndjson {
+			msg, _ := json.Marshal(err.Error())
+			w.Write([]byte(`{"error":` + string(msg) + "}\n"))
+		}
+		w.Header().Set(ErrorTrailer, err.Error())
+	}
+}
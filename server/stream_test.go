@@ -0,0 +1,71 @@
+package server
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/perlyna/snowflake"
+)
+
+func post(s http.Handler, url, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", url, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func Test_Stream(t *testing.T) {
+	s := newTestServer(t, WithMaxStream(5000))
+	for _, accept := range []string{"", "text/plain"} {
+		rec := post(s, "/ids?n=2500", accept)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("POST /ids = %d %s", rec.Code, rec.Body)
+		}
+		var last int64
+		lines := 0
+		sc := bufio.NewScanner(rec.Body)
+		for sc.Scan() {
+			line := sc.Text()
+			if accept == "" {
+				if !strings.HasPrefix(line, `"`) || !strings.HasSuffix(line, `"`) {
+					t.Fatalf("ND-JSON line %q", line)
+				}
+				line = strings.Trim(line, `"`)
+			}
+			id, err := strconv.ParseInt(line, 10, 64)
+			if err != nil || id <= last || !snowflake.IsValid(id) {
+				t.Fatalf("line %d %q", lines, line)
+			}
+			last = id
+			lines++
+		}
+		if lines != 2500 {
+			t.Errorf("Accept %q: %d lines", accept, lines)
+		}
+		if rec.Result().Trailer.Get(ErrorTrailer) != "" {
+			t.Errorf("unexpected error trailer %q", rec.Result().Trailer.Get(ErrorTrailer))
+		}
+	}
+	if rec := post(s, "/ids?n=5001", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("stream over cap = %d", rec.Code)
+	}
+	del := httptest.NewRecorder()
+	s.ServeHTTP(del, httptest.NewRequest("DELETE", "/ids", nil))
+	if del.Code != http.StatusMethodNotAllowed || !strings.Contains(del.Header().Get("Allow"), "POST") {
+		t.Errorf("DELETE /ids = %d, Allow %q", del.Code, del.Header().Get("Allow"))
+	}
+
+	g, _ := snowflake.NewGenerator(1, 1)
+	g.Close()
+	rec := post(New(g), "/ids?n=10", "")
+	if body := rec.Body.String(); !strings.HasPrefix(body, `{"error":`) || rec.Result().Trailer.Get(ErrorTrailer) == "" {
+		t.Errorf("closed generator: body %q, trailer %q", body, rec.Result().Trailer.Get(ErrorTrailer))
+	}
+}
@@ -0,0 +1,308 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+// ThriftServer service compatible with Twitter's original snowflake.thrift:
+//
+//	service Snowflake {
+//	  i64 get_worker_id()
+//	  i64 get_timestamp()
+//	  i64 get_id(1:string useragent)
+//	  i64 get_datacenter_id()
+//	}
+//
+// It speaks the strict binary protocol over framed or buffered transports, detected per
+// connection. Errors, including invalid user agents, are returned as TApplicationException.
+type ThriftServer struct {
+	g            snowflake.Generator
+	workerID     int64
+	datacenterID int64
+
+	tcpServer
+}
+
+// NewThriftServer return thrift service issuing ids from g; workerID and datacenterID are
+// what get_worker_id and get_datacenter_id report and should match g
+func NewThriftServer(g snowflake.Generator, workerID, datacenterID int64) *ThriftServer {
+	return &ThriftServer{g: g, workerID: workerID, datacenterID: datacenterID}
+}
+
+// Serve accept connections on l until Close, then return ErrServerClosed
+func (s *ThriftServer) Serve(l net.Listener) error {
+	return s.serve(l, s.serveConn)
+}
+
+// Close stop accepting and close open connections
+func (s *ThriftServer) Close() error {
+	return s.close()
+}
+
+// thrift 二进制协议常量
+const (
+	thriftVersion1  = 0x80010000
+	thriftCall      = 1
+	thriftReply     = 2
+	thriftException = 3
+	thriftOneway    = 4
+
+	thriftStop   = 0
+	thriftBool   = 2
+	thriftByte   = 3
+	thriftDouble = 4
+	thriftI16    = 6
+	thriftI32    = 8
+	thriftI64    = 10
+	thriftString = 11
+	thriftStruct = 12
+	thriftMap    = 13
+	thriftSet    = 14
+	thriftList   = 15
+
+	// TApplicationException 类型
+	thriftUnknownMethod = 1
+	thriftInternalError = 6
+	thriftProtocolError = 7
+
+	// thriftMaxFrame 单个请求的上限，请求只含一个 user agent 字符串
+	thriftMaxFrame = 1 << 20
+)
+
+// userAgentPattern 与原服务相同的 user agent 校验
+var userAgentPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z\-0-9]*$`)
+
+func (s *ThriftServer) serveConn(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	head, err := r.Peek(1)
+	if err != nil {
+		return
+	}
+	// 严格二进制协议的消息以 0x80 开头，否则是 4 字节长度的帧
+	framed := head[0] != 0x80
+	for {
+		var in *thriftReader
+		if framed {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil || size > thriftMaxFrame {
+				return
+			}
+			frame := make([]byte, size)
+			if _, err := io.ReadFull(r, frame); err != nil {
+				return
+			}
+			in = &thriftReader{r: bufio.NewReader(bytes.NewReader(frame))}
+		} else {
+			in = &thriftReader{r: r}
+		}
+		reply, ok := s.handle(in)
+		if !ok {
+			return
+		}
+		if reply == nil {
+			continue
+		}
+		if framed {
+			binary.Write(w, binary.BigEndian, uint32(len(reply)))
+		}
+		w.Write(reply)
+		if w.Flush() != nil {
+			return
+		}
+	}
+}
+
+// handle 处理一条消息，返回编码好的回复；oneway 调用返回 nil，协议错误时 ok 为 false
+func (s *ThriftServer) handle(in *thriftReader) (reply []byte, ok bool) {
+	version := in.i32()
+	name := in.string()
+	seqID := in.i32()
+	if in.err != nil || uint32(version)&0xffff0000 != thriftVersion1 {
+		return nil, false
+	}
+	typ := byte(version)
+	args := in.args()
+	if in.err != nil || (typ != thriftCall && typ != thriftOneway) {
+		return nil, false
+	}
+	var out thriftWriter
+	var result int64
+	var err error
+	code := int32(thriftInternalError)
+	switch name {
+	case "get_worker_id":
+		result = s.workerID
+	case "get_datacenter_id":
+		result = s.datacenterID
+	case "get_timestamp":
+		result = time.Now().UnixMilli()
+	case "get_id":
+		if ua := args[1]; !userAgentPattern.MatchString(ua) {
+			err, code = fmt.Errorf("invalid user agent %q", ua), thriftProtocolError
+		} else {
+			result, err = s.g.Next()
+		}
+	default:
+		err, code = fmt.Errorf("unknown method %q", name), thriftUnknownMethod
+	}
+	if typ == thriftOneway {
+		return nil, true
+	}
+	if err != nil {
+		out.message(name, thriftException, seqID)
+		out.field(thriftString, 1)
+		out.string(err.Error())
+		out.field(thriftI32, 2)
+		out.i32(code)
+	} else {
+		out.message(name, thriftReply, seqID)
+		out.field(thriftI64, 0)
+		out.i64(result)
+	}
+	out.b = append(out.b, thriftStop)
+	return out.b, true
+}
+
+// thriftReader 读取二进制协议，出错后后续读取均为零值
+type thriftReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (t *thriftReader) read(n int) []byte {
+	if t.err != nil {
+		return make([]byte, n)
+	}
+	b := make([]byte, n)
+	_, t.err = io.ReadFull(t.r, b)
+	return b
+}
+
+func (t *thriftReader) i32() int32 {
+	return int32(binary.BigEndian.Uint32(t.read(4)))
+}
+
+func (t *thriftReader) string() string {
+	n := t.i32()
+	if n < 0 || n > thriftMaxFrame {
+		t.fail()
+		return ""
+	}
+	return string(t.read(int(n)))
+}
+
+func (t *thriftReader) fail() {
+	if t.err == nil {
+		t.err = errors.New("thrift: malformed message")
+	}
+}
+
+// args 读取参数结构体，只保留字符串字段，其余字段跳过
+func (t *thriftReader) args() map[int16]string {
+	fields := make(map[int16]string)
+	for t.err == nil {
+		typ := t.read(1)[0]
+		if typ == thriftStop {
+			break
+		}
+		id := int16(binary.BigEndian.Uint16(t.read(2)))
+		if typ == thriftString {
+			fields[id] = t.string()
+		} else {
+			t.skip(typ, 0)
+		}
+	}
+	return fields
+}
+
+// skip 跳过一个任意类型的值
+func (t *thriftReader) skip(typ byte, depth int) {
+	if depth > 32 {
+		t.fail()
+		return
+	}
+	switch typ {
+	case thriftBool, thriftByte:
+		t.read(1)
+	case thriftI16:
+		t.read(2)
+	case thriftI32:
+		t.read(4)
+	case thriftI64, thriftDouble:
+		t.read(8)
+	case thriftString:
+		t.string()
+	case thriftStruct:
+		for t.err == nil {
+			ft := t.read(1)[0]
+			if ft == thriftStop {
+				return
+			}
+			t.read(2)
+			t.skip(ft, depth+1)
+		}
+	case thriftMap:
+		kt, vt := t.read(1)[0], t.read(1)[0]
+		n := t.count()
+		for i := 0; i < n && t.err == nil; i++ {
+			t.skip(kt, depth+1)
+			t.skip(vt, depth+1)
+		}
+	case thriftSet, thriftList:
+		et := t.read(1)[0]
+		n := t.count()
+		for i := 0; i < n && t.err == nil; i++ {
+			t.skip(et, depth+1)
+		}
+	default:
+		t.fail()
+	}
+}
+
+func (t *thriftReader) count() int {
+	n := t.i32()
+	if n < 0 || n > thriftMaxFrame {
+		t.fail()
+		return 0
+	}
+	return int(n)
+}
+
+type thriftWriter struct {
+	b []byte
+}
+
+func (t *thriftWriter) message(name string, typ byte, seqID int32) {
+	t.i32(int32(thriftVersion1 | uint32(typ)))
+	t.string(name)
+	t.i32(seqID)
+}
+
+func (t *thriftWriter) field(typ byte, id int16) {
+	t.b = append(t.b, typ)
+	t.b = binary.BigEndian.AppendUint16(t.b, uint16(id))
+}
+
+func (t *thriftWriter) i32(v int32) {
+	t.b = binary.BigEndian.AppendUint32(t.b, uint32(v))
+}
+
+func (t *thriftWriter) i64(v int64) {
+	t.b = binary.BigEndian.AppendUint64(t.b, uint64(v))
+}
+
+func (t *thriftWriter) string(s string) {
+	t.i32(int32(len(s)))
+	t.b = append(t.b, s...)
+}
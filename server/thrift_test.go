@@ -0,0 +1,132 @@
+package server
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+// thriftCallMsg 编码一次调用，useragent 为空时不带参数
+func thriftCallMsg(name string, seqID int32, useragent string) []byte {
+	var t thriftWriter
+	t.message(name, thriftCall, seqID)
+	if useragent != "" {
+		t.field(thriftString, 1)
+		t.string(useragent)
+	}
+	t.b = append(t.b, thriftStop)
+	return t.b
+}
+
+// readThriftReply 返回消息类型、seqid、成功时的 i64 结果或异常信息
+func readThriftReply(t *testing.T, r *bufio.Reader, framed bool) (typ byte, seqID int32, result int64, msg string) {
+	t.Helper()
+	if framed {
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			t.Fatal(err)
+		}
+	}
+	in := &thriftReader{r: r}
+	version := in.i32()
+	in.string()
+	seqID = in.i32()
+	typ = byte(version)
+	for in.err == nil {
+		ft := in.read(1)[0]
+		if ft == thriftStop {
+			break
+		}
+		in.read(2)
+		switch ft {
+		case thriftI64:
+			result = int64(binary.BigEndian.Uint64(in.read(8)))
+		case thriftString:
+			msg = in.string()
+		default:
+			in.skip(ft, 0)
+		}
+	}
+	if in.err != nil {
+		t.Fatal(in.err)
+	}
+	return typ, seqID, result, msg
+}
+
+func Test_ThriftServer(t *testing.T) {
+	g, err := snowflake.NewGenerator(3, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewThriftServer(g, 3, 4)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ln) }()
+
+	for _, framed := range []bool{false, true} {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		r := bufio.NewReader(conn)
+		call := func(name string, seqID int32, ua string) (byte, int32, int64, string) {
+			msg := thriftCallMsg(name, seqID, ua)
+			if framed {
+				conn.Write(binary.BigEndian.AppendUint32(nil, uint32(len(msg))))
+			}
+			conn.Write(msg)
+			return readThriftReply(t, r, framed)
+		}
+
+		if typ, seq, id, _ := call("get_id", 7, "legacy-client1"); typ != thriftReply || seq != 7 || !snowflake.IsValid(id) {
+			t.Errorf("framed=%v get_id = %d %d %d", framed, typ, seq, id)
+		} else if p := snowflake.Decompose(snowflake.ID(id)); p.WorkerID != 3 || p.DatacenterID != 4 {
+			t.Errorf("framed=%v get_id parts = %+v", framed, p)
+		}
+		if _, _, v, _ := call("get_worker_id", 8, ""); v != 3 {
+			t.Errorf("get_worker_id = %d", v)
+		}
+		if _, _, v, _ := call("get_datacenter_id", 9, ""); v != 4 {
+			t.Errorf("get_datacenter_id = %d", v)
+		}
+		if _, _, v, _ := call("get_timestamp", 10, ""); time.Since(time.UnixMilli(v)).Abs() > time.Minute {
+			t.Errorf("get_timestamp = %d", v)
+		}
+		if typ, _, _, msg := call("get_id", 11, "1bad agent"); typ != thriftException || msg == "" {
+			t.Errorf("invalid useragent = %d %q", typ, msg)
+		}
+		if typ, _, _, _ := call("get_nothing", 12, ""); typ != thriftException {
+			t.Errorf("unknown method = %d", typ)
+		}
+		conn.Close()
+	}
+
+	s.Close()
+	if err := <-done; err != ErrServerClosed {
+		t.Errorf("Serve = %v", err)
+	}
+}
+
+func Test_ThriftServerMalformed(t *testing.T) {
+	g, _ := snowflake.NewGenerator(1, 1)
+	s := NewThriftServer(g, 1, 1)
+	client, srv := net.Pipe()
+	go func() {
+		s.serveConn(srv)
+		srv.Close()
+	}()
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	client.Write([]byte{0x80, 0x01, 0x00, 0x01, 0x7f, 0xff, 0xff, 0xff})
+	if _, err := client.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("read after malformed message = %v, want EOF", err)
+	}
+}
@@ -0,0 +1,200 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// procShard 一个 P 独占的序列分片，填充到独立的缓存行，避免多核间的伪共享
+type procShard struct {
+	mutex         sync.Mutex
+	lastTimestamp int64
+	sequence      int64 // 本毫秒分片内已使用的序列数
+	issued        uint64
+	_             [128]byte // 相邻分片的字段至少相隔 128 字节，覆盖相邻缓存行预取
+}
+
+// sharded 按 P 划分每毫秒的序列空间，见 NewShardedWorker
+type sharded struct {
+	w          *worker // 配置、时钟和主机锁
+	shards     []procShard
+	shardShift uint8 // 分片号在序列字段中的起始位
+	localMask  int64 // 分片内序列的掩码
+	closed     atomic.Bool
+}
+
+// NewShardedWorker return an experimental generator splitting each millisecond's sequence
+// space into one private slice per P (GOMAXPROCS, rounded up to a power of two), picked
+// through the runtime's processor pinning, so goroutines on different cores never contend on
+// one lock or cache line at extreme generation rates.
+// Ids stay unique: slices occupy disjoint high bits of the sequence field, and within its own
+// slice a shard is an ordinary worker, refusing to go back in time and waiting for the next
+// millisecond once its slice is used up. Ids are ordered per shard only, and a single core
+// gets 1/P of the sequence space per millisecond.
+// It supports the layout, clock, machine id, wait strategy and host lock options; options
+// relying on a single sequence (leases, state stores, skew guards, smearing, borrowing,
+// reservations, random machine bits or sequences, Redis sequences) and the per id hooks of the
+// issuing path (recorders, event handlers, loggers, profile labels) are rejected.
+// Stats only reports Issued, LastIssued and Uptime, and there is no Saturation.
+func NewShardedWorker(workerID, datacenterID uint8, opts ...Option) (Generator, error) {
+	w, err := newWorker(workerID, datacenterID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if w.lease != nil || w.state != nil || w.skew != nil || w.smear > 0 || w.borrow > 0 || w.reserve > 0 || w.randomMachine || w.randomSequence || w.redisSeq != nil ||
+		w.recorder != nil || w.onEvent != nil || w.logger != nil || w.profileLabels {
+		w.Close()
+		return nil, fmt.Errorf("option not supported by sharded workers")
+	}
+	return newSharded(w, uint8(bits.Len(uint(runtime.GOMAXPROCS(0)-1)))), nil
+}
+
+// newSharded 以 2^shardBits 个分片包装 w，分片数不超过序列空间
+func newSharded(w *worker, shardBits uint8) *sharded {
+	sequenceBits := w.layout.sequenceBits()
+	shardBits = min(shardBits, sequenceBits)
+	return &sharded{
+		w:          w,
+		shards:     make([]procShard, 1<<shardBits),
+		shardShift: sequenceBits - shardBits,
+		localMask:  -1 ^ (-1 << (sequenceBits - shardBits)),
+	}
+}
+
+// shard 当前 P 的分片；取得编号后立即解除绑定，P 切换只影响局部性，正确性由分片锁保证
+func (s *sharded) shard() (int, *procShard) {
+	p := runtime_procPin()
+	runtime_procUnpin()
+	i := p & (len(s.shards) - 1)
+	return i, &s.shards[i]
+}
+
+// next 在分片 i 中生成 id，调用方需持有分片锁
+func (s *sharded) next(i int, sh *procShard) (int64, error) {
+	if s.closed.Load() {
+		return 0, ErrClosed
+	}
+	w := s.w
+	timestamp := w.timestampSince(sh.lastTimestamp)
+	if timestamp < sh.lastTimestamp {
+		return 0, fmt.Errorf("%w.  Refusing to generate id for %d milliseconds", ErrClockBackwards, sh.lastTimestamp-timestamp)
+	}
+	if timestamp == sh.lastTimestamp {
+		sh.sequence = (sh.sequence + 1) & s.localMask
+		if sh.sequence == 0 {
+			timestamp = s.wait(sh.lastTimestamp)
+		}
+	} else {
+		sh.sequence = 0
+	}
+	sh.lastTimestamp = timestamp
+	id, err := signed(w.compose(timestamp, int64(i)<<s.shardShift|sh.sequence))
+	if err == nil {
+		sh.issued++
+	}
+	return id, err
+}
+
+// wait 等待下一毫秒，不经过 worker 的统计，避免多个分片争用同一计数器
+func (s *sharded) wait(lastTimestamp int64) int64 {
+	if s.w.customClock {
+		return pollNextMillis(lastTimestamp, s.w.now)
+	}
+	return waitNextMillis(lastTimestamp, s.w.wait)
+}
+
+func (s *sharded) Next() (int64, error) {
+	if s.w.unsignedOnly() {
+		return 0, fmt.Errorf("layout is unsigned, use NextUint64")
+	}
+	i, sh := s.shard()
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+	return s.next(i, sh)
+}
+
+func (s *sharded) NextContext(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return s.Next()
+}
+
+func (s *sharded) NextN(n int) ([]int64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid n %d", n)
+	}
+	ids := make([]int64, n)
+	if err := s.Fill(ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (s *sharded) NextNContext(ctx context.Context, n int) ([]int64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid n %d", n)
+	}
+	ids := make([]int64, 0, min(n, 1<<16))
+	for len(ids) < n {
+		if err := ctx.Err(); err != nil {
+			return ids, err
+		}
+		chunk := min(n-len(ids), nextNChunk)
+		ids = slices.Grow(ids, chunk)
+		if err := s.Fill(ids[len(ids) : len(ids)+chunk]); err != nil {
+			return ids, err
+		}
+		ids = ids[:len(ids)+chunk]
+	}
+	return ids, nil
+}
+
+// Fill fill dst under one shard lock acquisition
+func (s *sharded) Fill(dst []int64) error {
+	if s.w.unsignedOnly() {
+		return fmt.Errorf("layout is unsigned, use NextUint64")
+	}
+	i, sh := s.shard()
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+	for j := range dst {
+		id, err := s.next(i, sh)
+		if err != nil {
+			return err
+		}
+		dst[j] = id
+	}
+	return nil
+}
+
+func (s *sharded) Close() error {
+	s.closed.Store(true)
+	return s.w.Close()
+}
+
+// Stats return the ids issued by all shards, their latest timestamp and the uptime; the
+// other counters are not tracked per shard and stay zero
+func (s *sharded) Stats() Stats {
+	st := Stats{Uptime: time.Duration(s.w.now()-s.w.created) * time.Millisecond}
+	var last int64
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mutex.Lock()
+		st.Issued += sh.issued
+		last = max(last, sh.lastTimestamp)
+		sh.mutex.Unlock()
+	}
+	if st.Issued > 0 {
+		st.LastIssued = time.UnixMilli(last)
+	}
+	return st
+}
@@ -0,0 +1,159 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Test_shardedSequenceSpace 唯一性的证明：同一毫秒内 (分片号, 分片内序列) 到序列字段是双射，
+// 不同分片的 id 必然不同；而每个分片内部与普通 worker 相同，同一毫秒不会重复使用分片内序列，
+// 时间也不会回退。这里对所有分片数穷举验证前一半，后一半由 Test_ShardedWorker 在并发下验证
+func Test_shardedSequenceSpace(t *testing.T) {
+	l := DefaultLayout
+	l.SequenceBits = 6
+	w, err := newWorker(0, 0, WithLayout(l))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for shardBits := uint8(0); shardBits <= l.SequenceBits+1; shardBits++ {
+		s := newSharded(w, shardBits)
+		seen := map[int64]bool{}
+		for i := range s.shards {
+			for local := int64(0); local <= s.localMask; local++ {
+				seq := int64(i)<<s.shardShift | local
+				if seen[seq] || seq > l.sequenceMask() {
+					t.Fatalf("%d shards: shard %d local %d maps to %d twice or out of range", len(s.shards), i, local, seq)
+				}
+				seen[seq] = true
+			}
+		}
+		if len(seen) != int(l.sequenceMask()+1) {
+			t.Errorf("%d shards cover %d of %d sequences", len(s.shards), len(seen), l.sequenceMask()+1)
+		}
+	}
+}
+
+func Test_ShardedWorker(t *testing.T) {
+	base := time.Now()
+	var reads atomic.Int64
+	// 时钟走得很慢，各分片反复耗尽并等待下一毫秒
+	clock := func() time.Time { return base.Add(time.Duration(reads.Add(1)/64) * time.Millisecond) }
+	l := DefaultLayout
+	l.SequenceBits = 5
+	w, err := newWorker(3, 1, WithLayout(l), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := newSharded(w, 2)
+	const goroutines, perGoroutine = 32, 500
+	var wg sync.WaitGroup
+	results := make([][]int64, goroutines)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perGoroutine; j++ {
+				if j%50 == 0 {
+					ids, err := g.NextN(10)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					results[i] = append(results[i], ids...)
+					continue
+				}
+				id, err := g.Next()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				results[i] = append(results[i], id)
+			}
+		}()
+	}
+	wg.Wait()
+	seen := map[int64]bool{}
+	for _, ids := range results {
+		for _, id := range ids {
+			if seen[id] {
+				t.Fatalf("duplicate id %d", id)
+			}
+			seen[id] = true
+			p := l.Decompose(ID(id))
+			if p.WorkerID != 3 || p.DatacenterID != 1 {
+				t.Fatalf("id %d decodes to %+v", id, p)
+			}
+		}
+	}
+	if s := g.Stats(); s.Issued != uint64(len(seen)) {
+		t.Errorf("Stats().Issued = %d, want %d", s.Issued, len(seen))
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Next(); err != ErrClosed {
+		t.Errorf("after Close: %v", err)
+	}
+}
+
+func Test_NewShardedWorker(t *testing.T) {
+	g, err := NewShardedWorker(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	ids, err := g.NextN(10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[int64]bool{}
+	for _, id := range ids {
+		if seen[id] || !IsValid(id) {
+			t.Fatalf("duplicate or invalid id %d", id)
+		}
+		seen[id] = true
+	}
+	for name, opt := range map[string]Option{
+		"smearing":       WithClockSmear(time.Second),
+		"recorder":       WithRecorder(&Recorder{}),
+		"event handler":  WithEventHandler(func(Event) {}),
+		"logger":         WithLogger(SlogLogger(slog.Default())),
+		"profile labels": WithProfileLabels(),
+	} {
+		if _, err := NewShardedWorker(1, 1, opt); err == nil {
+			t.Errorf("%s accepted", name)
+		}
+	}
+	if _, ok := g.(SaturationReporter); ok {
+		t.Error("sharded worker reports a saturation it does not track")
+	}
+}
+
+func Test_ShardedWorkerCachedClock(t *testing.T) {
+	// 分片在真实时钟上等到下一毫秒后，缓存时钟可能仍落后于分片的上次时间戳
+	g, err := NewShardedWorker(1, 1, WithCachedClock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	for i := 0; i < 200000; i++ {
+		if _, err := g.Next(); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+}
+
+func Benchmark_ShardedWorker(b *testing.B) {
+	g, _ := NewShardedWorker(1, 1)
+	defer g.Close()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			g.Next()
+		}
+	})
+}
@@ -0,0 +1,27 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import "testing"
+
+func Test_Sign(t *testing.T) {
+	key := []byte("secret")
+	id, err := Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Sign(ID(id), key)
+	got, err := Verify(s, key)
+	if err != nil || got != ID(id) {
+		t.Fatalf("Verify(%q) = %d, %v", s, got, err)
+	}
+	if _, err := Verify(s, []byte("other")); err != ErrInvalidSignature {
+		t.Errorf("wrong key: got %v", err)
+	}
+	if _, err := Verify(Sign(ID(id)+1, []byte("other")), key); err != ErrInvalidSignature {
+		t.Errorf("forged token: got %v", err)
+	}
+	if _, err := Verify(EncodeSignedCursor(key, id), key); err != ErrInvalidSignature {
+		t.Errorf("signed cursor accepted as signed id: got %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import "fmt"
+
+// SignMode how a worker treats the top bit of unsigned layouts, for consumers such as Java
+// that read ids as signed 64-bit integers
+type SignMode int
+
+const (
+	// SignAllow unsigned layouts issue ids with the top bit set, through NextUint64 only (default)
+	SignAllow SignMode = iota
+	// SignNonNegative never issue an id with the top bit set: Next accepts unsigned layouts,
+	// and once an id would be negative as int64 every method returns an *InvariantError
+	SignNonNegative
+	// SignRejectLayout refuse layouts whose ids could have the top bit set, see Layout.MaySetSignBit
+	SignRejectLayout
+)
+
+// WithSignMode set how the worker treats the top bit of unsigned layouts, default SignAllow
+func WithSignMode(m SignMode) Option {
+	return func(w *worker) {
+		w.signMode = m
+	}
+}
+
+// checkSign 校验布局是否满足 SignRejectLayout
+func (w *worker) checkSign() error {
+	if w.signMode == SignRejectLayout && w.layout.MaySetSignBit() {
+		return fmt.Errorf("layout uses all 64 bits, ids may be negative as signed integers")
+	}
+	return nil
+}
+
+// unsignedOnly 布局的 id 只能通过 NextUint64 获取
+func (w *worker) unsignedOnly() bool {
+	return w.layout.Unsigned && w.signMode == SignAllow
+}
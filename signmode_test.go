@@ -0,0 +1,74 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_SignMode(t *testing.T) {
+	epoch := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	wide := Layout{Epoch: epoch, TimestampBits: 42, WorkerBits: 10, SequenceBits: 12, Unsigned: true}
+	// 时间戳最高位置 1 前后各一小时
+	flip := epoch.Add(time.Duration(1<<41) * time.Millisecond)
+	before := func() time.Time { return flip.Add(-time.Hour) }
+	after := func() time.Time { return flip.Add(time.Hour) }
+
+	if DefaultLayout.MaySetSignBit() || !wide.MaySetSignBit() {
+		t.Fatal("MaySetSignBit")
+	}
+	narrow := wide
+	narrow.TimestampBits = 41
+	if narrow.MaySetSignBit() {
+		t.Fatal("63-bit unsigned layout may not set the sign bit")
+	}
+
+	w, err := newWorker(0, 0, WithLayout(wide), WithClock(after))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Next(); err == nil {
+		t.Error("SignAllow: Next accepted an unsigned layout")
+	}
+	if id, err := w.NextUint64(); err != nil || int64(id) >= 0 {
+		t.Errorf("SignAllow: NextUint64 = %d, %v, want top bit set", id, err)
+	}
+
+	w, err = newWorker(0, 0, WithLayout(wide), WithClock(before), WithSignMode(SignNonNegative))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := w.Next(); err != nil || id <= 0 {
+		t.Errorf("SignNonNegative before the top bit: Next = %d, %v", id, err)
+	}
+	if ids, err := w.NextN(3); err != nil || len(ids) != 3 {
+		t.Errorf("SignNonNegative before the top bit: NextN = %v, %v", ids, err)
+	}
+	w, err = newWorker(0, 0, WithLayout(wide), WithClock(after), WithSignMode(SignNonNegative))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ie *InvariantError
+	if _, err := w.Next(); !errors.As(err, &ie) {
+		t.Errorf("SignNonNegative after the top bit: Next got %v, want InvariantError", err)
+	}
+	if _, err := w.NextUint64(); !errors.As(err, &ie) {
+		t.Errorf("SignNonNegative after the top bit: NextUint64 got %v, want InvariantError", err)
+	}
+	if s := w.Stats(); s.Issued != 0 {
+		t.Errorf("rejected ids counted as issued: %+v", s)
+	}
+
+	if _, err := newWorker(0, 0, WithLayout(wide), WithSignMode(SignRejectLayout)); err == nil {
+		t.Error("SignRejectLayout accepted a 64-bit layout")
+	}
+	w, err = newWorker(0, 0, WithLayout(narrow), WithSignMode(SignRejectLayout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := w.Next(); err != nil || id <= 0 {
+		t.Errorf("SignRejectLayout: Next = %d, %v", id, err)
+	}
+}
@@ -0,0 +1,114 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// ErrClockSkew returned while this node's clock is too far from the fleet median
+var ErrClockSkew = errors.New("snowflake: clock skew exceeds threshold")
+
+// SkewSource report how far each peer's clock is ahead (positive) or behind (negative)
+// this node's clock, e.g. from timestamps gossiped through the allocator backend
+type SkewSource interface {
+	PeerOffsets(ctx context.Context) ([]time.Duration, error)
+}
+
+// WithSkewGuard refuse to serve (ErrClockSkew) while the median peer offset from src
+// exceeds threshold, mirroring the ZooKeeper clock sanity check of the original snowflake.
+// src is checked on creation and every interval; when src fails the previous verdict is kept.
+// threshold and interval must be positive.
+func WithSkewGuard(src SkewSource, threshold, interval time.Duration) Option {
+	return func(w *worker) {
+		w.skew = &skewGuard{src: src, threshold: threshold, interval: interval, stop: make(chan struct{})}
+	}
+}
+
+type skewGuard struct {
+	src       SkewSource
+	threshold time.Duration
+	interval  time.Duration
+	offset    atomic.Int64 // 最近一次测得的中位数偏差(纳秒)
+	exceeded  atomic.Bool
+	stop      chan struct{}
+}
+
+// validate 检查阈值与间隔，非正的间隔会让后台 ticker panic
+func (g *skewGuard) validate() error {
+	if g.threshold <= 0 || g.interval <= 0 {
+		return fmt.Errorf("skew guard threshold %v and interval %v must be positive", g.threshold, g.interval)
+	}
+	return nil
+}
+
+// check 测量一次并更新判定，返回是否超限
+func (g *skewGuard) check() (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.interval)
+	defer cancel()
+	offsets, err := g.src.PeerOffsets(ctx)
+	if err != nil {
+		return g.exceeded.Load(), err
+	}
+	if len(offsets) == 0 {
+		return g.exceeded.Load(), nil
+	}
+	median := medianDuration(offsets)
+	g.offset.Store(int64(median))
+	exceeded := median > g.threshold || median < -g.threshold
+	g.exceeded.Store(exceeded)
+	return exceeded, nil
+}
+
+func medianDuration(ds []time.Duration) time.Duration {
+	s := append([]time.Duration(nil), ds...)
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	if len(s)%2 == 1 {
+		return s[len(s)/2]
+	}
+	return (s[len(s)/2-1] + s[len(s)/2]) / 2
+}
+
+// startSkewGuard 创建时同步检查一次，超限则拒绝创建
+func (w *worker) startSkewGuard() error {
+	if exceeded, err := w.skew.check(); err != nil {
+		return err
+	} else if exceeded {
+		return ErrClockSkew
+	}
+	go func() {
+		ticker := time.NewTicker(w.skew.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.skew.stop:
+				return
+			case <-ticker.C:
+				was := w.skew.exceeded.Load()
+				exceeded, err := w.skew.check()
+				if err != nil {
+					w.notify(func() { w.log(LevelWarn, "snowflake: clock skew check failed", "error", err) })
+				}
+				if exceeded && !was {
+					w.notify(func() { w.emit(EventClockSkew, time.Duration(w.skew.offset.Load()).Milliseconds()) })
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (w *worker) stopSkewGuard() {
+	if w.skew != nil {
+		select {
+		case <-w.skew.stop:
+		default:
+			close(w.skew.stop)
+		}
+	}
+}
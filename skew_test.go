@@ -0,0 +1,88 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeSkew struct {
+	mu      sync.Mutex
+	offsets []time.Duration
+	err     error
+}
+
+func (f *fakeSkew) PeerOffsets(ctx context.Context) ([]time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.offsets, f.err
+}
+
+func (f *fakeSkew) set(offsets []time.Duration, err error) {
+	f.mu.Lock()
+	f.offsets, f.err = offsets, err
+	f.mu.Unlock()
+}
+
+func Test_SkewGuard(t *testing.T) {
+	src := &fakeSkew{offsets: []time.Duration{time.Millisecond, -2 * time.Millisecond, 3 * time.Second}}
+	events := make(chan Event, 10)
+	g, err := NewGenerator(1, 1, WithSkewGuard(src, time.Second, 5*time.Millisecond),
+		WithEventHandler(func(e Event) { events <- e }))
+	if err != nil {
+		t.Fatalf("one outlier should not trip the median: %v", err)
+	}
+	defer g.Close()
+	if _, err := g.Next(); err != nil {
+		t.Fatal(err)
+	}
+
+	src.set([]time.Duration{2 * time.Second, 3 * time.Second, 0}, nil)
+	select {
+	case e := <-events:
+		if e.Type != EventClockSkew || e.Millis != 2000 {
+			t.Errorf("event = %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no clock skew event")
+	}
+	if _, err := g.Next(); err != ErrClockSkew {
+		t.Errorf("Next while skewed: got %v", err)
+	}
+
+	src.set(nil, errors.New("gossip unavailable"))
+	time.Sleep(20 * time.Millisecond)
+	if _, err := g.Next(); err != ErrClockSkew {
+		t.Errorf("source errors should keep the previous verdict: got %v", err)
+	}
+
+	src.set([]time.Duration{0}, nil)
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := g.Next(); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Next did not recover after skew cleared")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	src.set([]time.Duration{-5 * time.Second}, nil)
+	if _, err := NewGenerator(2, 1, WithSkewGuard(src, time.Second, time.Second)); err != ErrClockSkew {
+		t.Errorf("creation while skewed: got %v", err)
+	}
+}
+
+func Test_SkewGuardInvalid(t *testing.T) {
+	for _, c := range []struct{ threshold, interval time.Duration }{{time.Second, 0}, {time.Second, -time.Second}, {0, time.Second}, {-time.Second, time.Second}} {
+		if g, err := NewGenerator(1, 1, WithSkewGuard(&fakeSkew{}, c.threshold, c.interval)); err == nil {
+			g.Close()
+			t.Errorf("threshold %v, interval %v accepted", c.threshold, c.interval)
+		}
+	}
+}
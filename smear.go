@@ -0,0 +1,53 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WithClockSmear tolerate backwards clock steps of at most max (leap second smearing,
+// VM migration): instead of refusing, the worker keeps the last timestamp and consumes
+// its remaining sequence space, waiting for the clock to catch up once it is used up.
+// Larger steps are still refused. max is truncated to milliseconds.
+func WithClockSmear(max time.Duration) Option {
+	return func(w *worker) {
+		w.smear = max.Milliseconds()
+	}
+}
+
+// SmearStats statistics of tolerated backwards clock steps, see WithClockSmear
+type SmearStats struct {
+	Steps  uint64 // number of tolerated backwards steps
+	Frozen uint64 // ids issued from a frozen timestamp
+}
+
+type smearCounter struct {
+	steps  atomic.Uint64
+	frozen atomic.Uint64
+	active bool // 当前处于冻结状态，调用方需持有 w.mutex
+}
+
+// SmearStats return smear statistics of the worker
+func (w *worker) SmearStats() SmearStats {
+	return SmearStats{Steps: w.smearCounter.steps.Load(), Frozen: w.smearCounter.frozen.Load()}
+}
+
+// smeared 回拨在容忍范围内时返回冻结的上次时间戳，调用方需持有 w.mutex
+func (w *worker) smeared(timestamp int64) int64 {
+	if timestamp > w.lastTimestamp {
+		w.smearCounter.active = false
+		return timestamp
+	}
+	if timestamp == w.lastTimestamp || w.lastTimestamp-timestamp > w.smear {
+		return timestamp
+	}
+	if !w.smearCounter.active {
+		w.smearCounter.active = true
+		w.smearCounter.steps.Add(1)
+		w.emit(EventClockSmeared, w.lastTimestamp-timestamp)
+	}
+	w.smearCounter.frozen.Add(1)
+	return w.lastTimestamp
+}
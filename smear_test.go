@@ -0,0 +1,62 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_ClockSmear(t *testing.T) {
+	var events []Event
+	w, err := newWorker(1, 1, WithClockSmear(5*time.Millisecond), WithEventHandler(func(e Event) { events = append(events, e) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 使用未来时间，避免回退到真实时钟
+	base := currentMillis() + 1000
+	now := base
+	w.now = func() int64 { return now }
+
+	first, err := w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = base - 3
+	var last int64 = first
+	for i := 0; i < 3; i++ {
+		id, err := w.Next()
+		if err != nil {
+			t.Fatalf("step within tolerance refused: %v", err)
+		}
+		if id <= last {
+			t.Fatalf("id %d not after %d", id, last)
+		}
+		if got := DefaultLayout.Decompose(ID(id)).Time; got.UnixMilli() != base {
+			t.Errorf("frozen id time = %d, want %d", got.UnixMilli(), base)
+		}
+		last = id
+	}
+
+	now = base - 8
+	if _, err := w.Next(); err == nil {
+		t.Error("step beyond tolerance should be refused")
+	}
+
+	now = base + 1
+	if _, err := w.Next(); err != nil {
+		t.Fatal(err)
+	}
+	now = base - 1
+	if _, err := w.Next(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := w.Stats().SmearStats; s.Steps != 2 || s.Frozen != 4 {
+		t.Errorf("stats = %+v", s)
+	}
+	if len(events) != 3 || events[0].Type != EventClockSmeared || events[0].Millis != 3 ||
+		events[1].Type != EventClockBackwards || events[2].Type != EventClockSmeared {
+		t.Errorf("events = %+v", events)
+	}
+}
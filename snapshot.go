@@ -0,0 +1,91 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// importWait Import 等待时钟追上快照的最长时间，更大的差距由 Next 作为时钟回拨拒绝
+const importWait = time.Second
+
+// Snapshot state of a worker exported for moving it to another host, JSON encodable
+type Snapshot struct {
+	Layout        Layout    `json:"layout"`         // layout after WithMachineID, if used
+	DatacenterID  int64     `json:"datacenter_id"`  // 0 for machine ids
+	WorkerID      int64     `json:"worker_id"`      // worker or machine id
+	LastTimestamp int64     `json:"last_timestamp"` // unix millis of the last issued id
+	Sequence      int64     `json:"sequence"`       // sequence of the last issued id
+	Buffered      []int64   `json:"buffered,omitempty"`
+	Exported      time.Time `json:"exported"`
+}
+
+// Exporter implemented by workers created by NewWorker and NewGenerator
+type Exporter interface {
+	Export() (Snapshot, error)
+}
+
+// Export stop the worker and return its state for Import on another host: ids, layout, the
+// last issued timestamp and sequence, and ids imported but not handed out yet. Later calls
+// return ErrClosed, so the state never issues ids on two hosts. Ids buffered outside the
+// worker, e.g. by Feed, can be appended to Buffered after draining the channel.
+func (w *worker) Export() (Snapshot, error) {
+	w.mutex.Lock()
+	if w.closed {
+		w.mutex.Unlock()
+		return Snapshot{}, ErrClosed
+	}
+	s := Snapshot{
+		Layout:        w.layout,
+		DatacenterID:  w.datacenterID,
+		WorkerID:      w.workerID,
+		LastTimestamp: w.lastTimestamp,
+		Sequence:      w.sequence,
+		Buffered:      append([]int64(nil), w.buffered...),
+		Exported:      time.Now(),
+	}
+	w.mutex.Unlock()
+	return s, w.Close()
+}
+
+// Import return a generator continuing from s: it hands out the buffered ids first and then
+// issues ids after the snapshot's last one. opts configure everything but the ids and layout
+// (clock, leases, state store, ...); the snapshot overrides WithLayout, WithMachineID and
+// WithRandomMachine. A clock up to a second behind the snapshot is waited for, larger gaps
+// make Next fail with ErrClockBackwards until the clock catches up.
+func Import(s Snapshot, opts ...Option) (Generator, error) {
+	if s.DatacenterID < 0 || s.WorkerID < 0 {
+		return nil, fmt.Errorf("invalid snapshot ids %d/%d", s.DatacenterID, s.WorkerID)
+	}
+	w, err := newWorker(0, 0, append(opts, restoreSnapshot(s))...)
+	if err != nil {
+		return nil, err
+	}
+	if now := w.now(); now < w.lastTimestamp && w.lastTimestamp-now <= importWait.Milliseconds() {
+		// 等到快照的最后一毫秒，同一毫秒内序列继续递增
+		w.tilNextMillis(context.Background(), w.lastTimestamp-1, "import")
+	}
+	return w, nil
+}
+
+// restoreSnapshot 最后应用，覆盖其他选项设置的布局和 id，newWorker 据此校验范围
+func restoreSnapshot(s Snapshot) Option {
+	return func(w *worker) {
+		w.machine, w.randomMachine = false, false
+		w.layout = s.Layout
+		w.datacenterID, w.workerID = s.DatacenterID, s.WorkerID
+		w.lastTimestamp, w.sequence = s.LastTimestamp, s.Sequence
+		w.buffered = append([]int64(nil), s.Buffered...)
+	}
+}
+
+// takeBuffered 取出下一个缓冲的 id，调用方需持有 w.mutex
+func (w *worker) takeBuffered() uint64 {
+	id := w.buffered[0]
+	w.buffered = w.buffered[1:]
+	elapsed, _, workerID, sequence := w.layout.decompose(uint64(id))
+	w.issue(w.epoch+elapsed, sequence, workerID)
+	return uint64(id)
+}
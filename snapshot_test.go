@@ -0,0 +1,109 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_ExportImport(t *testing.T) {
+	now := time.UnixMilli(twepoch + 1000)
+	clock := WithClock(func() time.Time { return now })
+	g, err := NewGenerator(7, 3, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := Feed(ctx, g, 4, FeedStall)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := <-ch
+	// 停止供给后取出缓冲区中未读的 id，迁移到新主机
+	cancel()
+	var buffered []int64
+	for id := range ch {
+		buffered = append(buffered, id)
+	}
+	s, err := g.(Exporter).Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Buffered = append(s.Buffered, buffered...)
+	if _, err := g.Next(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Next after Export = %v", err)
+	}
+	if _, err := g.(Exporter).Export(); !errors.Is(err, ErrClosed) {
+		t.Errorf("second Export = %v", err)
+	}
+	if s.WorkerID != 7 || s.DatacenterID != 3 || s.LastTimestamp != now.UnixMilli() || s.Sequence < int64(len(buffered)) {
+		t.Errorf("snapshot = %+v", s)
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored Snapshot
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+	// 导入端的布局和机器 id 选项被快照覆盖
+	imported, err := Import(restored, clock, WithLayout(Layout{}), WithMachineID(9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer imported.Close()
+	seen := map[int64]bool{first: true}
+	last := first
+	for i := range len(buffered) + 3 {
+		id, err := imported.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[id] || id <= last {
+			t.Fatalf("id %d after %d: duplicate or out of order", id, last)
+		}
+		if i < len(buffered) && id != buffered[i] {
+			t.Errorf("id %d = %d, want buffered %d", i, id, buffered[i])
+		}
+		if p := DefaultLayout.Decompose(ID(id)); p.WorkerID != 7 || p.DatacenterID != 3 {
+			t.Errorf("imported id %d decoded as %+v", id, p)
+		}
+		seen[id], last = true, id
+	}
+	if st := imported.Stats(); st.Issued != uint64(len(buffered)+3) {
+		t.Errorf("Issued = %d", st.Issued)
+	}
+
+	// 时钟略微落后时等待追上快照
+	ticks := now.Add(-5 * time.Millisecond)
+	catchUp := WithClock(func() time.Time { ticks = ticks.Add(time.Millisecond); return ticks })
+	caught, err := Import(Snapshot{Layout: s.Layout, DatacenterID: 3, WorkerID: 7, LastTimestamp: s.LastTimestamp, Sequence: s.Sequence}, catchUp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer caught.Close()
+	if id, err := caught.Next(); err != nil || id <= last {
+		t.Errorf("Next after catching up = %d, %v", id, err)
+	}
+
+	// 时钟落后快照超过一秒时拒绝签发
+	behind := WithClock(func() time.Time { return now.Add(-time.Minute) })
+	late, err := Import(s, behind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer late.Close()
+	late.(*worker).buffered = nil
+	if _, err := late.Next(); !errors.Is(err, ErrClockBackwards) {
+		t.Errorf("Next with clock behind snapshot = %v", err)
+	}
+	if _, err := Import(Snapshot{Layout: DefaultLayout, WorkerID: 32}); err == nil {
+		t.Error("Import accepted a worker id beyond the layout")
+	}
+}
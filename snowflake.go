@@ -1,43 +1,85 @@
+//go:build !snowflake_decode
+
 package snowflake
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"math/rand/v2"
+	"os"
 	"sync"
-	"time"
+	"sync/atomic"
 )
 
-// Twitter_Snowflake
-// SnowFlake的结构如下(每部分用-分开):
-// 0 - 0000000000 0000000000 0000000000 0000000000 0 - 00000 - 00000 - 000000000000
-// 1位标识，由于long基本类型在Java中是带符号的，最高位是符号位，正数是0，负数是1，所以id一般是正数，最高位是0
-// 41位时间截(毫秒级)，注意，41位时间截不是存储当前时间的时间截，而是存储时间截的差值（当前时间截 - 开始时间截)
-// 得到的值 这里的的开始时间截，一般是我们的id生成器开始使用的时间，由我们程序来指定的（如下下面程序IdWorker类的startTime属性）。41位的时间截，可以使用69年，年T = (1L << 41) / (1000L * 60 * 60 * 24 * 365) = 69
-// 10位的数据机器位，可以部署在1024个节点，包括5位datacenterId和5位workerId
-// 12位序列，毫秒内的计数，12位的计数顺序号支持每个节点每毫秒(同一机器，同一时间截)产生4096个ID序号
-// 加起来刚好64位，为一个Long型
-// SnowFlake的优点是，整体上按照时间自增排序，并且整个分布式系统内不会产生ID碰撞(由数据中心ID和机器ID作区分)，并且效率较高
-const (
-	twepoch = 1546272000000 // 默认起始的时间戳 1546272000000 (2019-01-01)
-
-	workerIDBits     = 5                             //机器id所占的位数
-	datacenterIDBits = 5                             //数据标识id所占的位数
-	maxWorkerID      = -1 ^ (-1 << workerIDBits)     //支持的最大机器id，结果是31 (这个移位算法可以很快的计算出几位二进制数所能表示的最大十进制数)
-	maxDatacenterID  = -1 ^ (-1 << datacenterIDBits) // 支持的最大数据标识id，结果是31
-	sequenceBits     = 12                            //序列在id中占的位数
-
-	workerIDShift      = sequenceBits                                   //机器ID向左移12位
-	datacenterIDShift  = sequenceBits + workerIDBits                    //数据标识id向左移17位(12+5)
-	timestampLeftShift = sequenceBits + workerIDBits + datacenterIDBits //时间截向左移22位(5+5+12)
-	sequenceMask       = -1 ^ (-1 << sequenceBits)                      //生成序列的掩码，这里为4095 (0b111111111111=0xfff=4095)
-)
+// ErrClockBackwards wrapped by the error Next returns while the clock is behind the last issued timestamp
+var ErrClockBackwards = errors.New("Clock moved backwards")
+
+// cacheLinePad 填充一个缓存行(含相邻行预取)，隔开不同线程写入的字段
+type cacheLinePad [128]byte
 
+// worker 字段按访问方式分组：创建后只读的配置、持锁修改的发号状态、后台和统计读取的计数，
+// 组间填充缓存行，发号时的写入不会使其他核缓存的配置失效，相邻分配的 worker 也互不影响
 type worker struct {
-	workerID      int64
-	datacenterID  int64
+	_ cacheLinePad
+
+	// 配置，newWorker 返回后只读
+	datacenterID   int64
+	layout         Layout
+	machineID      int64 // WithMachineID 指定的机器 id
+	machine        bool
+	randomMachine  bool // 机器位随机生成，见 WithRandomMachine
+	randomSequence bool // 每毫秒的序列从随机值开始，见 WithRandomSequence
+	recorder       *Recorder
+	epoch          int64 // layout.Epoch 的毫秒时间戳
+	created        int64 // 创建时的毫秒时间戳，NextAt 只接受更早的时间
+	now            func() int64
+	customClock    bool // now 来自 WithClock，不回退到系统时钟
+	cachedClock    bool // now 来自 WithCachedClock 的共享时钟
+	wait           WaitStrategy
+	profileLabels  bool  // 等待时设置 pprof 标签，见 WithProfileLabels
+	smear          int64 // 容忍的回拨毫秒数，见 WithClockSmear
+	borrow         int64 // 可借用的未来毫秒数，见 WithBorrowAhead
+	reserve        int64 // 每毫秒为高优先级保留的序列数，见 WithPriorityReserve
+	signMode       SignMode
+	onEvent        func(Event)
+	logger         Logger
+	lockDir        string
+	lease          *lease
+	skew           *skewGuard
+	state          *stateMark
+	redisSeq       *redisSequence
+
+	_ cacheLinePad
+
+	// 发号状态，持有 mutex 时读写
+	mutex         sync.Mutex
 	sequence      int64
 	lastTimestamp int64
-	mutex         sync.Mutex
+	workerID      int64 // 随机机器位模式下会更换
+	rerollMs      int64
+	rerolled      []int64         // rerollMs 这一毫秒已用过的机器位
+	rand          *rand.Rand      // WithRandomSeed 的随机序列
+	backfill      map[int64]int64 // NextAt 每毫秒的下一个序列
+	backfillBelow int64           // 不晚于此的毫秒已被 NextAt 遗忘
+	backfillAbove int64           // 不早于此的毫秒已被 NextAt 遗忘
+	buffered      []int64         // Import 导入或 Warmup 预生成、尚未发出的 id
+	warming       bool            // Warmup 预生成期间，id 发出时才计入统计
+	lockFile      *os.File
+	clockRetained bool // 持有共享缓存时钟的引用，Close 时释放
+	closed        bool
+
+	_ cacheLinePad
+
+	// 统计，发号时写入，Stats 等无锁读取
+	issued        atomic.Uint64
+	counters      statsCounter
+	waitCounter   waitCounter
+	smearCounter  smearCounter
+	borrowCounter borrowCounter
+	saturation    saturation
+
+	_ cacheLinePad
 }
 
 // Worker snowflake worker
@@ -45,54 +87,247 @@ type Worker interface {
 	Next() (int64, error)
 }
 
-// NewWorker return new snowflake worker
-func NewWorker(workerID uint8, datacenterID uint8) Worker {
-	if workerID > maxWorkerID {
-		log.Fatalf("worker Id can't be greater than %d or less than 0", maxWorkerID)
+// WithLayout use a custom bit layout instead of DefaultLayout
+func WithLayout(l Layout) Option {
+	return func(w *worker) {
+		w.layout = l
 	}
-	if datacenterID > maxDatacenterID {
-		log.Fatalf("datacenter Id can't be greater than %d or less than 0", maxDatacenterID)
+}
+
+// Uint64Worker implemented by workers created by NewWorker
+type Uint64Worker interface {
+	NextUint64() (uint64, error)
+}
+
+// NewWorker return new snowflake worker, panics on invalid configuration,
+// use NewGenerator to handle the error
+func NewWorker(workerID uint8, datacenterID uint8, opts ...Option) Worker {
+	w, err := newWorker(workerID, datacenterID, opts...)
+	if err != nil {
+		panic(err)
 	}
-	return &worker{workerID: int64(workerID), datacenterID: int64(datacenterID)}
+	return w
 }
 
-func tilNextMillis(lastTimestamp int64) int64 {
-	timestamp := time.Now().UnixNano() / 1e6
-	for timestamp <= lastTimestamp {
-		timestamp = time.Now().UnixNano() / 1e6
+func newWorker(workerID uint8, datacenterID uint8, opts ...Option) (*worker, error) {
+	w := &worker{workerID: int64(workerID), datacenterID: int64(datacenterID), layout: DefaultLayout, now: currentMillis}
+	for _, opt := range opts {
+		opt(w)
 	}
-	return timestamp
+	if w.machine {
+		if err := w.applyMachineID(); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.layout.Validate(); err != nil {
+		return nil, err
+	}
+	if err := w.checkSign(); err != nil {
+		return nil, err
+	}
+	if w.logger != nil {
+		for _, warning := range w.layout.Report().Warnings {
+			w.log(LevelWarn, "snowflake: layout: "+warning)
+		}
+	}
+	if w.workerID > w.layout.maxWorkerID() {
+		return nil, fmt.Errorf("worker Id can't be greater than %d or less than 0", w.layout.maxWorkerID())
+	}
+	if w.datacenterID > w.layout.maxDatacenterID() {
+		return nil, fmt.Errorf("datacenter Id can't be greater than %d or less than 0", w.layout.maxDatacenterID())
+	}
+	if w.redisSeq != nil && (w.randomMachine || w.randomSequence) {
+		return nil, fmt.Errorf("random machine bits or sequences cannot be combined with a Redis sequence")
+	}
+	if w.reserve != 0 && (w.reserve < 0 || w.reserve >= w.layout.sequenceMask()) {
+		return nil, fmt.Errorf("priority reserve %d must be within 0..%d", w.reserve, w.layout.sequenceMask()-1)
+	}
+	if w.smear < 0 || w.borrow < 0 {
+		return nil, fmt.Errorf("clock smear %dms and borrow ahead %dms must not be negative", w.smear, w.borrow)
+	}
+	if w.lease != nil {
+		if err := w.lease.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if w.skew != nil {
+		if err := w.skew.validate(); err != nil {
+			return nil, err
+		}
+	}
+	w.epoch = w.layout.Epoch.UnixMilli()
+	w.retainClock()
+	w.created = w.now()
+	if w.recorder != nil {
+		w.recorder.bind(w.layout, w.datacenterID)
+	}
+	if w.lockDir != "" {
+		if err := w.acquireHostLock(); err != nil {
+			w.releaseClock()
+			return nil, err
+		}
+	}
+	if w.state != nil {
+		if err := w.loadState(); err != nil {
+			w.releaseHostLock()
+			w.releaseClock()
+			return nil, err
+		}
+	}
+	if w.lease != nil {
+		if err := w.startLease(); err != nil {
+			w.releaseHostLock()
+			w.releaseClock()
+			return nil, err
+		}
+	}
+	if w.skew != nil {
+		if err := w.startSkewGuard(); err != nil {
+			w.stopLease()
+			w.releaseHostLock()
+			w.releaseClock()
+			return nil, err
+		}
+	}
+	return w, nil
 }
 
 // Next return new id
 func (w *worker) Next() (int64, error) {
-	timestamp := time.Now().UnixNano() / 1e6
+	if w.unsignedOnly() {
+		return 0, fmt.Errorf("layout is unsigned, use NextUint64")
+	}
+	return signed(w.NextUint64())
+}
+
+// signed 合法布局不会占用符号位，这里兜底防止返回负数
+func signed(id uint64, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	if int64(id) <= 0 {
+		return 0, &InvariantError{ID: int64(id), Reason: "sign bit set"}
+	}
+	return int64(id), nil
+}
+
+// NextUint64 return new id as uint64, the only way to get ids from unsigned layouts
+func (w *worker) NextUint64() (uint64, error) {
+	w.lockLane(context.Background(), PriorityNormal)
+	defer w.mutex.Unlock()
+	return w.next(context.Background())
+}
+
+// timestamp 读取当前毫秒时间戳，调用方需持有 w.mutex
+func (w *worker) timestamp() int64 {
+	return w.timestampSince(w.lastTimestamp)
+}
+
+// timestampSince 读取当前毫秒时间戳，last 为调用方上次签发的时间戳
+func (w *worker) timestampSince(last int64) int64 {
+	timestamp := w.now()
+	if timestamp < last && !w.customClock {
+		// 缓存时钟可能落后于上次序列耗尽时读取的真实时间，以较新的为准
+		if real := currentMillis(); real > timestamp {
+			timestamp = real
+		}
+	}
+	return timestamp
+}
+
+// next 生成下一个 id，ctx 用于等待时的 pprof 标签和 Redis 序列请求，调用方需持有 w.mutex
+func (w *worker) next(ctx context.Context) (uint64, error) {
+	if w.closed {
+		return 0, ErrClosed
+	}
+	timestamp := w.timestamp()
+	if w.lease != nil && !w.lease.valid() {
+		return 0, ErrLeaseLost
+	}
+	if w.skew != nil && w.skew.exceeded.Load() {
+		return 0, ErrClockSkew
+	}
+	if len(w.buffered) > 0 {
+		return w.takeBuffered(), nil
+	}
+	if w.borrowCounter.borrowing {
+		timestamp = w.repaid(timestamp)
+	}
+	if w.smear > 0 {
+		timestamp = w.smeared(timestamp)
+	}
 	if timestamp < w.lastTimestamp {
-		return 0, fmt.Errorf("Clock moved backwards.  Refusing to generate id for %d milliseconds", w.lastTimestamp-timestamp)
+		w.counters.regressions.Add(1)
+		w.emit(EventClockBackwards, w.lastTimestamp-timestamp)
+		return 0, fmt.Errorf("%w.  Refusing to generate id for %d milliseconds", ErrClockBackwards, w.lastTimestamp-timestamp)
 	}
-	w.mutex.Lock()
-	if timestamp == w.lastTimestamp {
-		w.sequence = (w.sequence + 1) & sequenceMask
+	exhausted := false
+	if w.redisSeq != nil {
+		var err error
+		if timestamp, w.sequence, err = w.redisNext(ctx, timestamp); err != nil {
+			return 0, err
+		}
+	} else if timestamp == w.lastTimestamp {
+		w.sequence = (w.sequence + 1) & w.layout.sequenceMask()
 		if w.sequence == 0 {
-			// wait new timestamp
-			timestamp = tilNextMillis(w.lastTimestamp)
+			w.counters.rollovers.Add(1)
+			w.saturation.record(timestamp)
+			exhausted = true
+			// 随机机器位模式下换一组机器位继续使用本毫秒
+			if !w.randomMachine || !w.rerollMachine(timestamp) {
+				if w.borrow > 0 {
+					timestamp = w.borrowNext(ctx)
+				} else {
+					// wait new timestamp
+					timestamp = w.tilNextMillis(ctx, w.lastTimestamp, "rollover")
+				}
+			}
 		}
 	} else {
-		w.sequence = 0
+		w.sequence = w.startSequence()
+	}
+	if w.state != nil {
+		if err := w.raiseState(timestamp); err != nil {
+			return 0, err
+		}
 	}
 	w.lastTimestamp = timestamp
-	id := ((timestamp - twepoch) << timestampLeftShift) |
-		(w.datacenterID << datacenterIDShift) |
-		(w.workerID << workerIDShift) |
-		w.sequence
-	w.mutex.Unlock()
-	return id, nil
+	if exhausted {
+		// 状态更新后再通知，处理函数 panic 时本毫秒已用过的序列不会被重新签发
+		w.emit(EventSequenceExhausted, 0)
+	}
+	id, err := w.compose(timestamp, w.sequence)
+	if err == nil && !w.warming {
+		w.issue(timestamp, w.sequence, w.workerID)
+	}
+	return id, err
 }
 
-// DefaultWorker return @see NewWorker(0,0)
-var DefaultWorker = &worker{}
+// issue 把发出的 id 计入统计和 Recorder，调用方需持有 w.mutex
+func (w *worker) issue(timestamp, sequence, workerID int64) {
+	w.issued.Add(1)
+	w.counters.issue(timestamp, sequence)
+	if w.recorder != nil {
+		w.recorder.record(timestamp, sequence, workerID)
+	}
+}
 
-// Next return DefaultWorker new id
-func Next() (int64, error) {
-	return DefaultWorker.Next()
+// compose 校验时间戳后按布局拼接 id
+func (w *worker) compose(timestamp, sequence int64) (uint64, error) {
+	elapsed := timestamp - w.epoch
+	if elapsed < 0 {
+		return 0, &InvariantError{ID: elapsed, Reason: "clock is before layout epoch"}
+	}
+	if elapsed > w.layout.maxElapsed() {
+		return 0, fmt.Errorf("timestamp exceeds %d bits of layout", w.layout.TimestampBits)
+	}
+	id := w.layout.compose(elapsed, w.datacenterID, w.workerID, sequence)
+	if id == 0 {
+		// 仅在 epoch 当毫秒、各字段均为 0 时出现
+		return 0, &InvariantError{ID: 0, Reason: "all fields are zero at layout epoch"}
+	}
+	if w.signMode == SignNonNegative && int64(id) < 0 {
+		return 0, &InvariantError{ID: int64(id), Reason: "sign bit set"}
+	}
+	return id, nil
 }
@@ -1,6 +1,10 @@
+//go:build !snowflake_decode
+
 package snowflake
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 )
 
@@ -12,3 +16,76 @@ func Test_Next(t *testing.T) {
 	}
 
 }
+
+func Benchmark_Next(b *testing.B) {
+	worker := NewWorker(0, 0)
+	for i := 0; i < b.N; i++ {
+		worker.Next()
+	}
+}
+
+// Test_NextConcurrent 并发压测，配合 go test -race 检查数据竞争
+func Test_NextConcurrent(t *testing.T) {
+	const goroutines, perGoroutine = 16, 5000
+	worker := NewWorker(0, 0)
+	ids := make(chan int64, goroutines*perGoroutine)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				id, err := worker.Next()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ids <- id
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+	seen := make(map[int64]struct{}, goroutines*perGoroutine)
+	for id := range ids {
+		if _, ok := seen[id]; ok {
+			t.Fatalf("duplicate id %d", id)
+		}
+		seen[id] = struct{}{}
+	}
+}
+
+// Benchmark_NextGoroutines 22 位序列的布局不受每毫秒 4096 个的上限约束，测量锁和缓存行的开销；
+// pool 的多个 worker 分配在相邻内存上，同时覆盖 worker 之间的伪共享
+func Benchmark_NextGoroutines(b *testing.B) {
+	wide := DefaultLayout
+	wide.DatacenterBits, wide.WorkerBits, wide.SequenceBits = 0, 0, 22
+	for _, c := range []struct {
+		name string
+		new  func() (Generator, error)
+	}{
+		{"worker", func() (Generator, error) { return NewGenerator(0, 0, WithLayout(wide)) }},
+		{"pool", func() (Generator, error) { return NewPool(8, 0, 0) }},
+	} {
+		for _, n := range []int{8, 32, 128} {
+			b.Run(fmt.Sprintf("%s/goroutines=%d", c.name, n), func(b *testing.B) {
+				g, err := c.new()
+				if err != nil {
+					b.Fatal(err)
+				}
+				defer g.Close()
+				var wg sync.WaitGroup
+				for range n {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						for i := 0; i < b.N/n; i++ {
+							g.Next()
+						}
+					}()
+				}
+				wg.Wait()
+			})
+		}
+	}
+}
@@ -0,0 +1,6 @@
+// Package snowflakeecho echo adapter of snowflake.Middleware. It needs
+// github.com/labstack/echo/v4 in the build environment and is only built with the
+// snowflake_echo tag, so the core keeps no dependencies:
+//
+//	go build -tags snowflake_echo ./...
+package snowflakeecho
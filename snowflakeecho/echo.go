@@ -0,0 +1,28 @@
+//go:build snowflake_echo && !snowflake_decode
+
+package snowflakeecho
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/perlyna/snowflake"
+)
+
+// Middleware return echo middleware running snowflake.Middleware(w): each request gets a
+// request id in snowflake.RequestIDHeader and on c.Request()'s context.
+//
+//	e.Use(snowflakeecho.Middleware(w))
+func Middleware(w snowflake.Worker) echo.MiddlewareFunc {
+	mw := snowflake.Middleware(w)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var err error
+			mw(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				c.SetRequest(r)
+				err = next(c)
+			})).ServeHTTP(c.Response(), c.Request())
+			return err
+		}
+	}
+}
@@ -0,0 +1,42 @@
+//go:build snowflake_echo && !snowflake_decode
+
+package snowflakeecho
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/perlyna/snowflake"
+)
+
+func Test_Middleware(t *testing.T) {
+	e := echo.New()
+	e.Use(Middleware(snowflake.NewWorker(0, 0)))
+	var seen string
+	var fromCtx snowflake.ID
+	e.GET("/", func(c echo.Context) error {
+		seen = c.Request().Header.Get(snowflake.RequestIDHeader)
+		fromCtx, _ = snowflake.IDFromContext(c.Request().Context())
+		return nil
+	})
+	e.GET("/fail", func(echo.Context) error {
+		return errors.New("handler failed")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	id, err := snowflake.Parse(seen)
+	if err != nil || fromCtx != id || rec.Header().Get(snowflake.RequestIDHeader) != seen {
+		t.Errorf("request id %q, context %d, response %q", seen, fromCtx, rec.Header().Get(snowflake.RequestIDHeader))
+	}
+
+	// 处理函数的错误交给 echo 的错误处理
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	if rec.Code != http.StatusInternalServerError || rec.Header().Get(snowflake.RequestIDHeader) == "" {
+		t.Errorf("failing handler = %d, request id %q", rec.Code, rec.Header().Get(snowflake.RequestIDHeader))
+	}
+}
@@ -0,0 +1,6 @@
+// Package snowflakefiber fiber adapter of snowflake.Middleware. It needs
+// github.com/gofiber/fiber/v2 in the build environment and is only built with the
+// snowflake_fiber tag, so the core keeps no dependencies:
+//
+//	go build -tags snowflake_fiber ./...
+package snowflakefiber
@@ -0,0 +1,30 @@
+//go:build snowflake_fiber && !snowflake_decode
+
+package snowflakefiber
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/perlyna/snowflake"
+)
+
+// Middleware return fiber middleware applying the rules of snowflake.Middleware(w): each
+// request gets a request id in snowflake.RequestIDHeader and, when it parses as an id, on
+// c.UserContext(). fiber is not built on net/http, so the id comes from snowflake.RequestID.
+//
+//	app.Use(snowflakefiber.Middleware(w))
+func Middleware(w snowflake.Worker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		incoming := c.Get(snowflake.RequestIDHeader)
+		rid := snowflake.RequestID(w, incoming)
+		if rid != "" && incoming == "" {
+			c.Request().Header.Set(snowflake.RequestIDHeader, rid)
+		}
+		if rid != "" {
+			c.Set(snowflake.RequestIDHeader, rid)
+		}
+		if id, err := snowflake.Parse(rid); err == nil {
+			c.SetUserContext(snowflake.ContextWithID(c.UserContext(), id))
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,43 @@
+//go:build snowflake_fiber && !snowflake_decode
+
+package snowflakefiber
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/perlyna/snowflake"
+)
+
+func Test_Middleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(Middleware(snowflake.NewWorker(0, 0)))
+	var seen string
+	var fromCtx snowflake.ID
+	var inCtx bool
+	app.Get("/", func(c *fiber.Ctx) error {
+		seen = c.Get(snowflake.RequestIDHeader)
+		fromCtx, inCtx = snowflake.IDFromContext(c.UserContext())
+		return nil
+	})
+
+	res, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := snowflake.Parse(seen)
+	if err != nil || fromCtx != id || res.Header.Get(snowflake.RequestIDHeader) != seen {
+		t.Errorf("request id %q, context %d, response %q", seen, fromCtx, res.Header.Get(snowflake.RequestIDHeader))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(snowflake.RequestIDHeader, "upstream")
+	if res, err = app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+	if seen != "upstream" || res.Header.Get(snowflake.RequestIDHeader) != "upstream" || inCtx {
+		t.Errorf("incoming request id %q, response %q, in context %v", seen, res.Header.Get(snowflake.RequestIDHeader), inCtx)
+	}
+}
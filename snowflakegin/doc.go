@@ -0,0 +1,6 @@
+// Package snowflakegin gin adapter of snowflake.Middleware. It needs github.com/gin-gonic/gin
+// in the build environment and is only built with the snowflake_gin tag, so the core keeps no
+// dependencies:
+//
+//	go build -tags snowflake_gin ./...
+package snowflakegin
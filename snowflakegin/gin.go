@@ -0,0 +1,24 @@
+//go:build snowflake_gin && !snowflake_decode
+
+package snowflakegin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/perlyna/snowflake"
+)
+
+// Middleware return gin middleware running snowflake.Middleware(w): each request gets a
+// request id in snowflake.RequestIDHeader and on c.Request's context.
+//
+//	r.Use(snowflakegin.Middleware(w))
+func Middleware(w snowflake.Worker) gin.HandlerFunc {
+	mw := snowflake.Middleware(w)
+	return func(c *gin.Context) {
+		mw(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			c.Request = r
+			c.Next()
+		})).ServeHTTP(c.Writer, c.Request)
+	}
+}
@@ -0,0 +1,31 @@
+//go:build snowflake_gin && !snowflake_decode
+
+package snowflakegin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/perlyna/snowflake"
+)
+
+func Test_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware(snowflake.NewWorker(0, 0)))
+	var seen string
+	var fromCtx snowflake.ID
+	r.GET("/", func(c *gin.Context) {
+		seen = c.GetHeader(snowflake.RequestIDHeader)
+		fromCtx, _ = snowflake.IDFromContext(c.Request.Context())
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	id, err := snowflake.Parse(seen)
+	if err != nil || fromCtx != id || rec.Header().Get(snowflake.RequestIDHeader) != seen {
+		t.Errorf("request id %q, context %d, response %q", seen, fromCtx, rec.Header().Get(snowflake.RequestIDHeader))
+	}
+}
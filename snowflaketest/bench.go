@@ -0,0 +1,68 @@
+package snowflaketest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/perlyna/snowflake"
+)
+
+// BenchmarkWorker run the standard benchmark suite against workers created by newWorker,
+// so alternative implementations can be compared apples-to-apples with benchstat:
+// Next from one goroutine, Next from 64 goroutines, NextN batches of 100 and Next plus
+// decimal formatting. Each sub-benchmark gets a fresh worker.
+func BenchmarkWorker(b *testing.B, newWorker func() snowflake.Worker) {
+	b.Run("Next", func(b *testing.B) {
+		w := newWorker()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := w.Next(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Next64Goroutines", func(b *testing.B) {
+		w := newWorker()
+		b.ReportAllocs()
+		const goroutines = 64
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			n := b.N / goroutines
+			if g < b.N%goroutines {
+				n++
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < n; i++ {
+					if _, err := w.Next(); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+	b.Run("NextN100", func(b *testing.B) {
+		g := snowflake.AsGenerator(newWorker())
+		b.ReportAllocs()
+		// 每次操作为一个 id，便于与 Next 对比
+		for i := 0; i < b.N; i += 100 {
+			if _, err := g.NextN(100); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("NextString", func(b *testing.B) {
+		w := newWorker()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			id, err := w.Next()
+			if err != nil {
+				b.Fatal(err)
+			}
+			_ = snowflake.ID(id).String()
+		}
+	})
+}
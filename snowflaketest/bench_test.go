@@ -0,0 +1,28 @@
+package snowflaketest_test
+
+import (
+	"testing"
+
+	"github.com/perlyna/snowflake"
+	"github.com/perlyna/snowflake/snowflaketest"
+)
+
+func BenchmarkDefault(b *testing.B) {
+	snowflaketest.BenchmarkWorker(b, func() snowflake.Worker { return snowflake.NewWorker(1, 1) })
+}
+
+func BenchmarkCachedClock(b *testing.B) {
+	snowflaketest.BenchmarkWorker(b, func() snowflake.Worker { return snowflake.NewWorker(1, 1, snowflake.WithCachedClock()) })
+}
+
+func BenchmarkWaitSpin(b *testing.B) {
+	snowflaketest.BenchmarkWorker(b, func() snowflake.Worker {
+		return snowflake.NewWorker(1, 1, snowflake.WithWaitStrategy(snowflake.WaitSpin))
+	})
+}
+
+func BenchmarkWaitSleep(b *testing.B) {
+	snowflaketest.BenchmarkWorker(b, func() snowflake.Worker {
+		return snowflake.NewWorker(1, 1, snowflake.WithWaitStrategy(snowflake.WaitSleep))
+	})
+}
@@ -0,0 +1,96 @@
+package snowflaketest
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+// Chaos faults injected by ChaosWorker, each rate is the probability per Next call
+type Chaos struct {
+	Delay         time.Duration // sleep injected before a call
+	DelayRate     float64
+	BackwardsRate float64 // return an error wrapping snowflake.ErrClockBackwards
+	DuplicateRate float64 // return the previous id again, never use outside tests
+	ReorderRate   float64 // return the next id before the current one
+	Seed          uint64  // seed of the fault schedule, the same seed injects the same faults
+}
+
+// ChaosStats faults injected so far
+type ChaosStats struct {
+	Delays     uint64
+	Backwards  uint64
+	Duplicates uint64
+	Reorders   uint64
+}
+
+// ChaosWorker snowflake.Worker wrapper misbehaving as configured, so consumers can verify
+// they survive slow generators, clock errors, duplicate and out-of-order ids
+type ChaosWorker struct {
+	w     snowflake.Worker
+	chaos Chaos
+
+	mu      sync.Mutex
+	rand    *rand.Rand
+	last    int64
+	held    int64 // 乱序时暂存、下次返回的 id
+	hasHeld bool
+	stats   ChaosStats
+}
+
+// NewChaosWorker return w wrapped with the faults of c
+func NewChaosWorker(w snowflake.Worker, c Chaos) *ChaosWorker {
+	return &ChaosWorker{w: w, chaos: c, rand: rand.New(rand.NewPCG(c.Seed, c.Seed^0x9e3779b97f4a7c15))}
+}
+
+// Next return the next id of the wrapped worker, or a fault
+func (c *ChaosWorker) Next() (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.hit(c.chaos.DelayRate) {
+		c.stats.Delays++
+		// 延迟期间持有锁，模拟整个生成器变慢
+		time.Sleep(c.chaos.Delay)
+	}
+	if c.hit(c.chaos.BackwardsRate) {
+		c.stats.Backwards++
+		return 0, fmt.Errorf("%w.  Refusing to generate id for %d milliseconds (injected)", snowflake.ErrClockBackwards, 1+c.rand.IntN(10))
+	}
+	if c.last != 0 && c.hit(c.chaos.DuplicateRate) {
+		c.stats.Duplicates++
+		return c.last, nil
+	}
+	if c.hasHeld {
+		c.hasHeld = false
+		c.last = c.held
+		return c.held, nil
+	}
+	id, err := c.w.Next()
+	if err != nil {
+		return 0, err
+	}
+	if c.hit(c.chaos.ReorderRate) {
+		next, err := c.w.Next()
+		if err == nil {
+			c.stats.Reorders++
+			c.held, c.hasHeld = id, true
+			id = next
+		}
+	}
+	c.last = id
+	return id, nil
+}
+
+// Stats return the faults injected so far
+func (c *ChaosWorker) Stats() ChaosStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+func (c *ChaosWorker) hit(rate float64) bool {
+	return rate > 0 && c.rand.Float64() < rate
+}
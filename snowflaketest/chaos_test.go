@@ -0,0 +1,47 @@
+package snowflaketest_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/perlyna/snowflake"
+	"github.com/perlyna/snowflake/snowflaketest"
+)
+
+func Test_ChaosWorker(t *testing.T) {
+	c := snowflaketest.NewChaosWorker(snowflake.NewWorker(1, 1), snowflaketest.Chaos{
+		Delay: time.Microsecond, DelayRate: 0.01,
+		BackwardsRate: 0.05, DuplicateRate: 0.05, ReorderRate: 0.05,
+		Seed: 1,
+	})
+	var last int64
+	var backwards, duplicates, reorders int
+	for i := 0; i < 10000; i++ {
+		id, err := c.Next()
+		if err != nil {
+			if !errors.Is(err, snowflake.ErrClockBackwards) {
+				t.Fatal(err)
+			}
+			backwards++
+			continue
+		}
+		switch {
+		case id == last:
+			duplicates++
+		case id < last:
+			reorders++
+		}
+		last = id
+	}
+	st := c.Stats()
+	if backwards == 0 || duplicates == 0 || reorders == 0 || st.Delays == 0 {
+		t.Fatalf("backwards %d, duplicates %d, reorders %d, stats %+v", backwards, duplicates, reorders, st)
+	}
+	if uint64(backwards) != st.Backwards || uint64(duplicates) != st.Duplicates {
+		t.Errorf("observed %d/%d, stats %+v", backwards, duplicates, st)
+	}
+
+	clean := snowflaketest.NewChaosWorker(snowflake.NewWorker(1, 1), snowflaketest.Chaos{})
+	snowflaketest.CheckMonotonic(t, clean, 1000)
+}
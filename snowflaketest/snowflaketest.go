@@ -0,0 +1,123 @@
+// Package snowflaketest property checks and fuzz targets for snowflake generators,
+// layouts and encodings, so custom layouts and implementations get the same guarantees
+package snowflaketest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/perlyna/snowflake"
+)
+
+// CheckMonotonic call w.Next n times, failing unless ids strictly increase
+func CheckMonotonic(t testing.TB, w snowflake.Worker, n int) {
+	t.Helper()
+	var last int64
+	for i := 0; i < n; i++ {
+		id, err := w.Next()
+		if err != nil {
+			t.Fatalf("Next #%d: %v", i, err)
+		}
+		if i > 0 && id <= last {
+			t.Fatalf("Next #%d = %d, not greater than previous %d", i, id, last)
+		}
+		last = id
+	}
+}
+
+// CheckConcurrentUnique call w.Next n times from each of goroutines goroutines,
+// failing on errors or repeated ids
+func CheckConcurrentUnique(t testing.TB, w snowflake.Worker, goroutines, n int) {
+	t.Helper()
+	results := make([][]int64, goroutines)
+	errs := make([]error, goroutines)
+	var wg sync.WaitGroup
+	for g := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids := make([]int64, 0, n)
+			for i := 0; i < n; i++ {
+				id, err := w.Next()
+				if err != nil {
+					errs[g] = err
+					return
+				}
+				ids = append(ids, id)
+			}
+			results[g] = ids
+		}()
+	}
+	wg.Wait()
+	seen := make(map[int64]struct{}, goroutines*n)
+	for g, ids := range results {
+		if errs[g] != nil {
+			t.Fatalf("goroutine %d: %v", g, errs[g])
+		}
+		for _, id := range ids {
+			if _, ok := seen[id]; ok {
+				t.Fatalf("duplicate id %d", id)
+			}
+			seen[id] = struct{}{}
+		}
+	}
+}
+
+// CheckRoundTrip fail unless parse(format(id)) == id for every id
+func CheckRoundTrip(t testing.TB, format func(snowflake.ID) string, parse func(string) (snowflake.ID, error), ids ...snowflake.ID) {
+	t.Helper()
+	for _, id := range ids {
+		s := format(id)
+		got, err := parse(s)
+		if err != nil || got != id {
+			t.Errorf("parse(format(%d)) = parse(%q) = %d, %v", id, s, got, err)
+		}
+	}
+}
+
+// CheckDecompose fail unless every field of l.Decompose(id) is within the layout
+func CheckDecompose(t testing.TB, l snowflake.Layout, id snowflake.ID) {
+	t.Helper()
+	p := l.Decompose(id)
+	if p.Sequence < 0 || p.Sequence >= 1<<l.SequenceBits {
+		t.Errorf("Decompose(%d).Sequence = %d outside %d bits", id, p.Sequence, l.SequenceBits)
+	}
+	if p.WorkerID < 0 || p.WorkerID >= 1<<l.WorkerBits {
+		t.Errorf("Decompose(%d).WorkerID = %d outside %d bits", id, p.WorkerID, l.WorkerBits)
+	}
+	if p.DatacenterID < 0 || p.DatacenterID >= 1<<l.DatacenterBits {
+		t.Errorf("Decompose(%d).DatacenterID = %d outside %d bits", id, p.DatacenterID, l.DatacenterBits)
+	}
+	if p.Time.Before(l.Epoch.Truncate(time.Millisecond)) {
+		t.Errorf("Decompose(%d).Time = %v before epoch %v", id, p.Time, l.Epoch)
+	}
+}
+
+// FuzzParse fuzz target: whatever parse accepts must format back to a string parse maps to
+// the same id, and parse must never panic; seeds are added to the corpus
+func FuzzParse(f *testing.F, format func(snowflake.ID) string, parse func(string) (snowflake.ID, error), seeds ...string) {
+	for _, s := range seeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		id, err := parse(s)
+		if err != nil {
+			return
+		}
+		CheckRoundTrip(t, format, parse, id)
+	})
+}
+
+// FuzzDecompose fuzz target: Decompose of any non-negative id under l stays within the layout
+func FuzzDecompose(f *testing.F, l snowflake.Layout, seeds ...int64) {
+	for _, s := range seeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, v int64) {
+		if v < 0 && !l.Unsigned {
+			return
+		}
+		CheckDecompose(t, l, snowflake.ID(v))
+	})
+}
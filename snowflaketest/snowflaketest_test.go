@@ -0,0 +1,40 @@
+package snowflaketest_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/perlyna/snowflake"
+	"github.com/perlyna/snowflake/snowflaketest"
+)
+
+func Test_Worker(t *testing.T) {
+	snowflaketest.CheckMonotonic(t, snowflake.NewWorker(1, 1), 10000)
+	snowflaketest.CheckConcurrentUnique(t, snowflake.NewWorker(1, 1), 8, 2000)
+}
+
+func Test_Encodings(t *testing.T) {
+	ids := []snowflake.ID{1, 4603909, math.MaxInt64}
+	snowflaketest.CheckRoundTrip(t, snowflake.ID.String, snowflake.Parse, ids...)
+	snowflaketest.CheckRoundTrip(t, snowflake.FormatChecked, snowflake.ParseChecked, ids...)
+	snowflaketest.CheckRoundTrip(t, snowflake.FormatProquint, snowflake.ParseProquint, ids...)
+	for _, id := range ids {
+		snowflaketest.CheckDecompose(t, snowflake.DefaultLayout, id)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	snowflaketest.FuzzParse(f, snowflake.ID.String, snowflake.Parse, "0", "4603909", "-1", "9223372036854775807")
+}
+
+func FuzzParseChecked(f *testing.F) {
+	snowflaketest.FuzzParse(f, snowflake.FormatChecked, snowflake.ParseChecked, "5724", "46039093")
+}
+
+func FuzzParseProquint(f *testing.F) {
+	snowflaketest.FuzzParse(f, snowflake.FormatProquint, snowflake.ParseProquint, "babab-babab-lusab-babad")
+}
+
+func FuzzDecompose(f *testing.F) {
+	snowflaketest.FuzzDecompose(f, snowflake.DefaultLayout, 0, 4603909, math.MaxInt64)
+}
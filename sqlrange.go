@@ -0,0 +1,94 @@
+package snowflake
+
+import (
+	"database/sql"
+	"iter"
+	"math"
+	"time"
+)
+
+// IDRange half-open id interval [From, To) covering every id issued in a time range
+type IDRange struct {
+	From ID // 包含
+	To   ID // 不包含
+}
+
+// FirstID return the smallest id the layout can issue at or after t's millisecond,
+// clamped to the layout's time range
+func (l Layout) FirstID(t time.Time) ID {
+	elapsed := t.UnixMilli() - l.Epoch.UnixMilli()
+	if t.Sub(l.Epoch)%time.Millisecond > 0 {
+		// 不足一毫秒的部分向上取整，保证 id 时间不早于 t
+		elapsed++
+	}
+	if elapsed < 0 {
+		return 0
+	}
+	if elapsed > l.maxElapsed() {
+		elapsed = l.maxElapsed() + 1
+		// 越界进位到版本位，版本已是最大值时进位到符号位
+		if l.bits() >= 63 && int64(l.Version) == l.maxVersion() {
+			return math.MaxInt64
+		}
+	}
+	return ID(l.compose(elapsed, 0, 0, 0))
+}
+
+// Range return the ids issued in [from, to), for filtering by id instead of a separately
+// indexed timestamp column; unsigned layouts compare the bounds as uint64
+func (l Layout) Range(from, to time.Time) IDRange {
+	return IDRange{From: l.FirstID(from), To: l.FirstID(to)}
+}
+
+// Range return the ids of DefaultWorker's layout issued in [from, to)
+func Range(from, to time.Time) IDRange {
+	return defaultLayout().Range(from, to)
+}
+
+// Contains report whether id is in the range
+func (r IDRange) Contains(id ID) bool {
+	return id >= r.From && id < r.To
+}
+
+// SQL return "column >= ? AND column < ?" and its arguments;
+// column is inserted verbatim and must not come from user input
+func (r IDRange) SQL(column string) (string, []any) {
+	return column + " >= ? AND " + column + " < ?", []any{int64(r.From), int64(r.To)}
+}
+
+// NamedSQL return "column >= @name_from AND column < @name_to" with sql.Named arguments,
+// for drivers supporting named parameters
+func (r IDRange) NamedSQL(column, name string) (string, []any) {
+	from, to := name+"_from", name+"_to"
+	return column + " >= @" + from + " AND " + column + " < @" + to,
+		[]any{sql.Named(from, int64(r.From)), sql.Named(to, int64(r.To))}
+}
+
+// IterateRange yield the inclusive (minID, maxID) bounds of consecutive bucket-long slices
+// of [from, to), for chunked backfills over id keyed tables with BETWEEN; buckets start at
+// from, the last one is cut at to, and empty slices of the id space are skipped
+func (l Layout) IterateRange(from, to time.Time, bucket time.Duration) iter.Seq2[ID, ID] {
+	return func(yield func(ID, ID) bool) {
+		if bucket <= 0 {
+			bucket = to.Sub(from)
+		}
+		for start := from; start.Before(to); start = start.Add(bucket) {
+			end := start.Add(bucket)
+			if end.After(to) || end.Before(start) {
+				end = to
+			}
+			r := l.Range(start, end)
+			if r.To <= r.From {
+				continue
+			}
+			if !yield(r.From, r.To-1) {
+				return
+			}
+		}
+	}
+}
+
+// IterateRange yield bucket bounds of DefaultWorker's layout, see Layout.IterateRange
+func IterateRange(from, to time.Time, bucket time.Duration) iter.Seq2[ID, ID] {
+	return defaultLayout().IterateRange(from, to, bucket)
+}
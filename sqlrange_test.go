@@ -0,0 +1,101 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_LayoutRange(t *testing.T) {
+	w, err := newWorker(31, 31)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now()
+	id, err := w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := DefaultLayout.Range(before.Add(-time.Millisecond), before.Add(time.Second))
+	if !r.Contains(ID(id)) {
+		t.Errorf("%+v does not contain %d", r, id)
+	}
+	at := DefaultLayout.Decompose(ID(id)).Time
+	if r := DefaultLayout.Range(at.Add(time.Millisecond), at.Add(time.Hour)); r.Contains(ID(id)) {
+		t.Errorf("%+v contains id from %v", r, at)
+	}
+	if r := DefaultLayout.Range(at.Add(-time.Hour), at); r.Contains(ID(id)) {
+		t.Errorf("end of range should be exclusive: %+v", r)
+	}
+	// 不足一毫秒的起点向上取整
+	if got := DefaultLayout.FirstID(at.Add(time.Microsecond)); got != DefaultLayout.FirstID(at.Add(time.Millisecond)) {
+		t.Errorf("FirstID rounding: %d", got)
+	}
+
+	if got := DefaultLayout.FirstID(DefaultLayout.Epoch.Add(-time.Hour)); got != 0 {
+		t.Errorf("FirstID before epoch = %d", got)
+	}
+	if got := DefaultLayout.FirstID(DefaultLayout.Epoch.AddDate(100, 0, 0)); got <= 0 {
+		t.Errorf("FirstID after layout end = %d", got)
+	}
+}
+
+func Test_IDRangeSQL(t *testing.T) {
+	r := IDRange{From: 10, To: 20}
+	q, args := r.SQL("id")
+	if q != "id >= ? AND id < ?" || !reflect.DeepEqual(args, []any{int64(10), int64(20)}) {
+		t.Errorf("SQL = %q, %v", q, args)
+	}
+	q, args = r.NamedSQL("o.id", "created")
+	want := []any{sql.Named("created_from", int64(10)), sql.Named("created_to", int64(20))}
+	if q != "o.id >= @created_from AND o.id < @created_to" || !reflect.DeepEqual(args, want) {
+		t.Errorf("NamedSQL = %q, %v", q, args)
+	}
+}
+
+func Test_IterateRange(t *testing.T) {
+	l := DefaultLayout
+	from := l.Epoch.Add(time.Hour)
+	to := from.Add(25 * time.Minute)
+	var got [][2]ID
+	for lo, hi := range l.IterateRange(from, to, 10*time.Minute) {
+		got = append(got, [2]ID{lo, hi})
+	}
+	if len(got) != 3 {
+		t.Fatalf("buckets = %d, want 3", len(got))
+	}
+	if got[0][0] != l.FirstID(from) || got[2][1] != l.FirstID(to)-1 {
+		t.Errorf("bounds %v do not cover [from, to)", got)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i][0] != got[i-1][1]+1 {
+			t.Errorf("bucket %d starts at %d, previous ends at %d", i, got[i][0], got[i-1][1])
+		}
+	}
+	if p := l.Decompose(got[1][0]); !p.Time.Equal(from.Add(10 * time.Minute)) {
+		t.Errorf("second bucket starts at %v", p.Time)
+	}
+
+	n := 0
+	for range l.IterateRange(from, to, time.Minute) {
+		if n++; n == 5 {
+			break
+		}
+	}
+	if n != 5 {
+		t.Errorf("break after %d buckets", n)
+	}
+	for range l.IterateRange(to, from, time.Minute) {
+		t.Fatal("reversed range must be empty")
+	}
+	n = 0
+	for range l.IterateRange(from, to, 0) {
+		n++
+	}
+	if n != 1 {
+		t.Errorf("zero bucket yields %d buckets, want 1", n)
+	}
+}
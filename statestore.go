@@ -0,0 +1,151 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StateStore persist the worker's timestamp high-water mark across restarts,
+// implemented by FileStateStore, RedisStateStore and EtcdStateStore
+type StateStore interface {
+	// Load return the stored mark in unix milliseconds, 0 if none was stored
+	Load(ctx context.Context) (int64, error)
+	// Store durably raise the mark to millis, keeping a higher stored mark, so the workers
+	// of a pool or multiplexer can share one store
+	Store(ctx context.Context, millis int64) error
+}
+
+// WithStateStore keep a timestamp high-water mark in s so that a restarted worker never
+// reissues ids of milliseconds it may already have used, even if the clock moved back
+// across the restart. The mark is raised window ahead of the clock (default 1s), so Next
+// writes to s about once per window while holding the worker lock; a failed write
+// fails that Next call. A worker restarted within the window waits for the clock to pass
+// the mark on creation; if the clock is further behind, Next refuses ids until it passes.
+func WithStateStore(s StateStore, window time.Duration) Option {
+	return func(w *worker) {
+		if window <= 0 {
+			window = time.Second
+		}
+		w.state = &stateMark{store: s, window: window.Milliseconds()}
+	}
+}
+
+type stateMark struct {
+	store  StateStore
+	window int64
+	mark   int64 // 已持久化的时间戳上限，不会发出晚于它的 id
+}
+
+// stateTimeout 单次读写状态存储的超时
+const stateTimeout = 5 * time.Second
+
+// loadState 读取上次的上限，视作最后一次发号的时间戳
+func (w *worker) loadState() error {
+	ctx, cancel := context.WithTimeout(context.Background(), stateTimeout)
+	defer cancel()
+	mark, err := w.state.store.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("snowflake: load state: %w", err)
+	}
+	if mark > w.lastTimestamp {
+		// 上限这一毫秒可能已用完全部序列
+		w.lastTimestamp, w.sequence = mark, w.layout.sequenceMask()
+	}
+	w.state.mark = mark
+	// 窗口内的快速重启等待时钟越过上限，更大的差距视为时钟回拨，由 Next 拒绝
+	if now := w.now(); now <= mark && mark-now <= w.state.window {
+		w.tilNextMillis(context.Background(), mark, "restart")
+	}
+	return nil
+}
+
+// raiseState 时间戳超过上限时先持久化新的上限，调用方需持有 w.mutex
+func (w *worker) raiseState(timestamp int64) error {
+	if timestamp <= w.state.mark {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), stateTimeout)
+	defer cancel()
+	mark := timestamp + w.state.window
+	if err := w.state.store.Store(ctx, mark); err != nil {
+		return fmt.Errorf("snowflake: store state: %w", err)
+	}
+	w.state.mark = mark
+	return nil
+}
+
+// FileStateStore StateStore keeping the mark in a local file, replaced atomically and fsynced.
+// Store holds a lock on the file path plus ".lock" (an advisory lock on unix, within the
+// process elsewhere) while it compares and replaces the mark
+type FileStateStore string
+
+// fileStateMu 进程内串行化 FileStateStore.Store，flock 不区分同一进程的调用方
+var fileStateMu sync.Mutex
+
+// Load read the mark, 0 when the file does not exist
+func (f FileStateStore) Load(ctx context.Context) (int64, error) {
+	b, err := os.ReadFile(string(f))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+}
+
+// Store write the mark to a temporary file, fsync it and rename it over the file, unless the
+// file already holds a higher mark
+func (f FileStateStore) Store(ctx context.Context, millis int64) error {
+	path := string(f)
+	fileStateMu.Lock()
+	defer fileStateMu.Unlock()
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if err := waitLockFile(lock); err != nil {
+		return err
+	}
+	defer unlockFile(lock)
+	if cur, err := f.Load(ctx); err != nil {
+		return err
+	} else if cur >= millis {
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(strconv.FormatInt(millis, 10) + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	// 同步目录，保证 rename 本身落盘
+	if d, err := os.Open(filepath.Dir(path)); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
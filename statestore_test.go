@@ -0,0 +1,344 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memStateStore struct {
+	mu     sync.Mutex
+	mark   int64
+	stores int
+	err    error
+}
+
+func (m *memStateStore) Load(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mark, nil
+}
+
+func (m *memStateStore) Store(ctx context.Context, millis int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.mark = millis
+	m.stores++
+	return nil
+}
+
+func Test_StateStoreRestart(t *testing.T) {
+	s := &memStateStore{}
+	w, err := newWorker(1, 1, WithStateStore(s, time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		if _, err := w.Next(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.stores == 0 || s.stores > 2 {
+		t.Errorf("stores = %d, want about one per window", s.stores)
+	}
+	if s.mark <= w.lastTimestamp {
+		t.Errorf("mark %d not ahead of last timestamp %d", s.mark, w.lastTimestamp)
+	}
+
+	// 时钟回拨到上限之后很远：拒绝发号
+	s.mark = currentMillis() + time.Hour.Milliseconds()
+	w2, err := newWorker(1, 1, WithStateStore(s, time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w2.Next(); err == nil {
+		t.Error("expected error while clock is behind the stored mark")
+	}
+
+	// 窗口内重启：创建时等待，id 晚于上限
+	mark := currentMillis() + 20
+	s.mark = mark
+	w3, err := newWorker(1, 1, WithStateStore(s, time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := w3.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts := id>>timestampLeftShift + twepoch; ts <= mark {
+		t.Errorf("id timestamp %d not after mark %d", ts, mark)
+	}
+}
+
+func Test_StateStoreError(t *testing.T) {
+	s := &memStateStore{err: errors.New("disk full")}
+	w, err := newWorker(1, 1, WithStateStore(s, time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Next(); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Next error = %v", err)
+	}
+	s.err = nil
+	if _, err := w.Next(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_FileStateStore(t *testing.T) {
+	f := FileStateStore(filepath.Join(t.TempDir(), "snowflake.state"))
+	ctx := context.Background()
+	if mark, err := f.Load(ctx); err != nil || mark != 0 {
+		t.Fatalf("Load missing = %d, %v", mark, err)
+	}
+	if err := f.Store(ctx, 1700000000123); err != nil {
+		t.Fatal(err)
+	}
+	if mark, err := f.Load(ctx); err != nil || mark != 1700000000123 {
+		t.Errorf("Load = %d, %v", mark, err)
+	}
+	if _, err := NewGenerator(1, 1, WithStateStore(f, 0)); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_StateStoreSharedByWorkers(t *testing.T) {
+	// 两个 worker 共用一个存储，时钟较慢的不能把上限降低
+	f := FileStateStore(filepath.Join(t.TempDir(), "snowflake.state"))
+	now := time.Now()
+	ahead, err := NewGenerator(1, 1, WithStateStore(f, time.Second), WithClock(func() time.Time { return now.Add(time.Minute) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ahead.Close()
+	behind, err := NewGenerator(2, 1, WithStateStore(f, time.Second), WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer behind.Close()
+	id, err := ahead.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := behind.Next(); err != nil {
+		t.Fatal(err)
+	}
+	mark, err := f.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issued := DefaultLayout.Decompose(ID(id)).Time.UnixMilli(); mark < issued {
+		t.Errorf("mark %d below the issued timestamp %d of the other worker", mark, issued)
+	}
+}
+
+// fakeRedis 只支持 AUTH/SELECT/GET/SET/INCR/PEXPIRE、状态上限和 RedisWorkerIDStore 脚本的内存服务器，键不会过期
+func fakeRedis(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	var mu sync.Mutex
+	data := map[string]string{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					reply, err := readRESP(r)
+					if err != nil {
+						return
+					}
+					var args []string
+					for _, a := range reply.([]any) {
+						args = append(args, string(a.([]byte)))
+					}
+					mu.Lock()
+					switch strings.ToUpper(args[0]) {
+					case "AUTH":
+						if args[1] == "secret" {
+							conn.Write([]byte("+OK\r\n"))
+						} else {
+							conn.Write([]byte("-WRONGPASS invalid password\r\n"))
+						}
+					case "SELECT":
+						conn.Write([]byte("+OK\r\n"))
+					case "GET":
+						if v, ok := data[args[1]]; ok {
+							conn.Write([]byte("$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"))
+						} else {
+							conn.Write([]byte("$-1\r\n"))
+						}
+					case "SET":
+						if _, ok := data[args[1]]; ok && slices.Contains(args[3:], "NX") {
+							conn.Write([]byte("$-1\r\n"))
+							break
+						}
+						data[args[1]] = args[2]
+						conn.Write([]byte("+OK\r\n"))
+					case "EVAL":
+						if args[1] == redisRaiseScript {
+							cur, _ := strconv.ParseInt(data[args[3]], 10, 64)
+							if n, _ := strconv.ParseInt(args[4], 10, 64); n > cur {
+								data[args[3]] = args[4]
+							}
+							conn.Write([]byte(":1\r\n"))
+							break
+						}
+						// 其余为 RedisWorkerIDStore 的脚本：持有者未变时续期或换成冷却期
+						if data[args[3]] != args[4] {
+							conn.Write([]byte(":0\r\n"))
+							break
+						}
+						if args[1] == redisReleaseScript {
+							data[args[3]] = ""
+						}
+						conn.Write([]byte(":1\r\n"))
+					case "INCR":
+						n, _ := strconv.ParseInt(data[args[1]], 10, 64)
+						data[args[1]] = strconv.FormatInt(n+1, 10)
+						conn.Write([]byte(":" + data[args[1]] + "\r\n"))
+					case "PEXPIRE":
+						conn.Write([]byte(":1\r\n"))
+					default:
+						conn.Write([]byte("-ERR unknown command\r\n"))
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func Test_RedisStateStore(t *testing.T) {
+	addr := fakeRedis(t)
+	ctx := context.Background()
+	s := NewRedisStateStore(addr, "secret", 2, "snowflake:1:1", time.Second)
+	defer s.Close()
+	if mark, err := s.Load(ctx); err != nil || mark != 0 {
+		t.Fatalf("Load missing = %d, %v", mark, err)
+	}
+	if err := s.Store(ctx, 42); err != nil {
+		t.Fatal(err)
+	}
+	if mark, err := s.Load(ctx); err != nil || mark != 42 {
+		t.Errorf("Load = %d, %v", mark, err)
+	}
+	if err := s.Store(ctx, 41); err != nil {
+		t.Fatal(err)
+	}
+	if mark, err := s.Load(ctx); err != nil || mark != 42 {
+		t.Errorf("Load after storing a lower mark = %d, %v", mark, err)
+	}
+
+	bad := NewRedisStateStore(addr, "wrong", 0, "snowflake:1:1", time.Second)
+	defer bad.Close()
+	var re redisError
+	if _, err := bad.Load(ctx); !errors.As(err, &re) {
+		t.Errorf("Load with wrong password = %v", err)
+	}
+}
+
+func Test_EtcdStateStore(t *testing.T) {
+	type kv struct {
+		value    string
+		revision int64
+	}
+	var mu sync.Mutex
+	data := map[string]kv{}
+	var revision int64
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Key     string
+			Compare []struct {
+				Key            string
+				Target         string
+				ModRevision    string `json:"mod_revision"`
+				CreateRevision string `json:"create_revision"`
+			}
+			Success []struct {
+				RequestPut struct{ Key, Value string } `json:"request_put"`
+			}
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/v3/kv/txn":
+			c := req.Compare[0]
+			cur, ok := data[c.Key]
+			if (c.Target == "CREATE" && ok) || (c.Target == "MOD" && strconv.FormatInt(cur.revision, 10) != c.ModRevision) {
+				rw.Write([]byte(`{"succeeded":false}`))
+				return
+			}
+			revision++
+			put := req.Success[0].RequestPut
+			data[put.Key] = kv{value: put.Value, revision: revision}
+			rw.Write([]byte(`{"succeeded":true}`))
+		case "/v3/kv/range":
+			if v, ok := data[req.Key]; ok {
+				json.NewEncoder(rw).Encode(map[string]any{"kvs": []map[string]string{{"key": req.Key, "value": v.value, "mod_revision": strconv.FormatInt(v.revision, 10)}}, "count": "1"})
+			} else {
+				rw.Write([]byte(`{"header":{}}`))
+			}
+		default:
+			http.NotFound(rw, r)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	s := &EtcdStateStore{Endpoint: srv.URL + "/", Key: "/snowflake/1/1"}
+	if mark, err := s.Load(ctx); err != nil || mark != 0 {
+		t.Fatalf("Load missing = %d, %v", mark, err)
+	}
+	if err := s.Store(ctx, 42); err != nil {
+		t.Fatal(err)
+	}
+	if mark, err := s.Load(ctx); err != nil || mark != 42 {
+		t.Errorf("Load = %d, %v", mark, err)
+	}
+	if got := data[base64.StdEncoding.EncodeToString([]byte("/snowflake/1/1"))]; got.value != base64.StdEncoding.EncodeToString([]byte("42")) {
+		t.Errorf("stored value = %q", got.value)
+	}
+	if err := s.Store(ctx, 41); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Store(ctx, 43); err != nil {
+		t.Fatal(err)
+	}
+	if mark, err := s.Load(ctx); err != nil || mark != 43 {
+		t.Errorf("Load after a lower and a higher mark = %d, %v", mark, err)
+	}
+	if err := (&EtcdStateStore{Endpoint: srv.URL + "/missing"}).Store(ctx, 1); err == nil {
+		t.Error("expected error for 404")
+	}
+}
@@ -0,0 +1,88 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"io"
+)
+
+// streamBatch 每次填充的 id 数
+const streamBatch = 1024
+
+// IDStream newline delimited decimal ids read from a worker, implementing io.Reader and io.WriterTo
+type IDStream struct {
+	g         Generator
+	remaining int64 // 剩余 id 数，负数表示不限
+	ids       []int64
+	buf       []byte
+	pending   []byte // buf 中尚未读取的部分
+	err       error
+}
+
+// NewIDStream return a stream of n ids from w, unlimited when n < 0
+func NewIDStream(w Worker, n int64) *IDStream {
+	return &IDStream{g: AsGenerator(w), remaining: n, ids: make([]int64, streamBatch)}
+}
+
+// fill 生成下一批 id 并格式化到 buf
+func (s *IDStream) fill() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.remaining == 0 {
+		return io.EOF
+	}
+	ids := s.ids
+	if s.remaining > 0 && s.remaining < int64(len(ids)) {
+		ids = ids[:s.remaining]
+	}
+	if err := Fill(s.g, ids); err != nil {
+		s.err = err
+		return err
+	}
+	if s.remaining > 0 {
+		s.remaining -= int64(len(ids))
+	}
+	s.buf = s.buf[:0]
+	for _, id := range ids {
+		s.buf = append(AppendDecimal(s.buf, ID(id)), '\n')
+	}
+	s.pending = s.buf
+	return nil
+}
+
+// Read read newline delimited ids, returns io.EOF after the last one
+func (s *IDStream) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(s.pending) == 0 {
+			if err := s.fill(); err != nil {
+				return n, err
+			}
+		}
+		c := copy(p[n:], s.pending)
+		s.pending = s.pending[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// WriteTo write the remaining ids to dst, never returns for unlimited streams unless dst fails
+func (s *IDStream) WriteTo(dst io.Writer) (int64, error) {
+	var total int64
+	for {
+		if len(s.pending) == 0 {
+			if err := s.fill(); err == io.EOF {
+				return total, nil
+			} else if err != nil {
+				return total, err
+			}
+		}
+		n, err := dst.Write(s.pending)
+		total += int64(n)
+		s.pending = s.pending[n:]
+		if err != nil {
+			return total, err
+		}
+	}
+}
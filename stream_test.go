@@ -0,0 +1,65 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"testing"
+)
+
+func checkStream(t *testing.T, r io.Reader, n int) {
+	t.Helper()
+	sc := bufio.NewScanner(r)
+	var last int64
+	count := 0
+	for sc.Scan() {
+		id, err := strconv.ParseInt(sc.Text(), 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= last {
+			t.Fatalf("id %d not greater than %d", id, last)
+		}
+		last = id
+		count++
+	}
+	if count != n {
+		t.Errorf("got %d ids, want %d", count, n)
+	}
+}
+
+func Test_IDStreamRead(t *testing.T) {
+	s := NewIDStream(NewWorker(1, 1), 3000)
+	// 小缓冲区，覆盖 id 跨越两次 Read 的情况
+	var out bytes.Buffer
+	buf := make([]byte, 7)
+	for {
+		n, err := s.Read(buf)
+		out.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkStream(t, &out, 3000)
+}
+
+func Test_IDStreamWriteTo(t *testing.T) {
+	var out bytes.Buffer
+	n, err := NewIDStream(NewWorker(1, 1), 2500).WriteTo(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(out.Len()) {
+		t.Errorf("WriteTo returned %d, wrote %d", n, out.Len())
+	}
+	checkStream(t, &out, 2500)
+
+	// 不限数量时由调用方截断
+	checkStream(t, io.LimitReader(NewIDStream(NewWorker(1, 1), -1), 20*100), 100)
+}
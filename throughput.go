@@ -0,0 +1,89 @@
+package snowflake
+
+import (
+	"sort"
+	"time"
+)
+
+// Throughput reconstruct issuance rates over time per worker from a sample of ids:
+// the sequence restarts every millisecond, so the largest sequence seen for a worker in
+// a millisecond means at least that many plus one ids were issued in it
+type Throughput struct {
+	layout Layout
+	bucket int64 // 毫秒
+	millis map[throughputKey]int64
+}
+
+type throughputKey struct {
+	datacenter, worker int64
+	elapsed            int64 // 距 epoch 的毫秒数
+}
+
+// Rate estimated issuance of one worker in one bucket
+type Rate struct {
+	Start        time.Time
+	DatacenterID int64
+	WorkerID     int64
+	Issued       int64   // lower bound of ids issued
+	ActiveMillis int64   // milliseconds with at least one id
+	PeakPerMilli int64   // largest per millisecond lower bound
+	PerSecond    float64 // Issued over the bucket width
+}
+
+// NewThroughput return an empty Throughput for ids of the layout bucketed by bucket
+// (at least 1ms)
+func NewThroughput(l Layout, bucket time.Duration) *Throughput {
+	return &Throughput{layout: l, bucket: max(bucket.Milliseconds(), 1), millis: map[throughputKey]int64{}}
+}
+
+// Add record a sampled id, in any order
+func (t *Throughput) Add(id ID) {
+	elapsed, datacenter, worker, sequence := t.layout.decompose(uint64(id))
+	k := throughputKey{datacenter, worker, elapsed}
+	if n, ok := t.millis[k]; !ok || sequence+1 > n {
+		t.millis[k] = sequence + 1
+	}
+}
+
+// Rates return the estimated rates ordered by bucket start, datacenter and worker
+func (t *Throughput) Rates() []Rate {
+	type bucketKey struct{ datacenter, worker, bucket int64 }
+	rates := map[bucketKey]*Rate{}
+	epoch := t.layout.Epoch.UnixMilli()
+	for k, n := range t.millis {
+		// 按绝对时间分桶，桶边界与 epoch 无关
+		b := floorDiv(epoch+k.elapsed, t.bucket)
+		r := rates[bucketKey{k.datacenter, k.worker, b}]
+		if r == nil {
+			r = &Rate{Start: time.UnixMilli(b * t.bucket), DatacenterID: k.datacenter, WorkerID: k.worker}
+			rates[bucketKey{k.datacenter, k.worker, b}] = r
+		}
+		r.Issued += n
+		r.ActiveMillis++
+		r.PeakPerMilli = max(r.PeakPerMilli, n)
+	}
+	out := make([]Rate, 0, len(rates))
+	for _, r := range rates {
+		r.PerSecond = float64(r.Issued) * 1000 / float64(t.bucket)
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		if a.DatacenterID != b.DatacenterID {
+			return a.DatacenterID < b.DatacenterID
+		}
+		return a.WorkerID < b.WorkerID
+	})
+	return out
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
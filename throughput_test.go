@@ -0,0 +1,55 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Throughput(t *testing.T) {
+	l := DefaultLayout
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	elapsed := base.UnixMilli() - l.Epoch.UnixMilli()
+	id := func(ms, dc, wk, seq int64) ID { return ID(l.compose(elapsed+ms, dc, wk, seq)) }
+
+	tp := NewThroughput(l, time.Second)
+	// worker 1: 第 0 毫秒只采样到序列 9，第 5 毫秒采样到 0 和 99
+	tp.Add(id(5, 0, 1, 99))
+	tp.Add(id(0, 0, 1, 9))
+	tp.Add(id(5, 0, 1, 0))
+	// worker 2 在下一秒
+	tp.Add(id(1500, 0, 2, 3))
+
+	rates := tp.Rates()
+	if len(rates) != 2 {
+		t.Fatalf("rates = %+v", rates)
+	}
+	r := rates[0]
+	if !r.Start.Equal(base) || r.WorkerID != 1 || r.Issued != 110 || r.ActiveMillis != 2 || r.PeakPerMilli != 100 || r.PerSecond != 110 {
+		t.Errorf("worker 1 rate = %+v", r)
+	}
+	r = rates[1]
+	if !r.Start.Equal(base.Add(time.Second)) || r.WorkerID != 2 || r.Issued != 4 {
+		t.Errorf("worker 2 rate = %+v", r)
+	}
+}
+
+func Test_ThroughputFromWorker(t *testing.T) {
+	w := NewWorker(3, 1)
+	tp := NewThroughput(DefaultLayout, time.Hour)
+	for i := 0; i < 10000; i++ {
+		id, _ := w.Next()
+		// 只采样十分之一，估计值仍接近真实数量
+		if i%10 == 9 {
+			tp.Add(ID(id))
+		}
+	}
+	var issued int64
+	for _, r := range tp.Rates() {
+		issued += r.Issued
+	}
+	if issued < 5000 || issued > 10000 {
+		t.Errorf("estimated %d ids, want close to 10000", issued)
+	}
+}
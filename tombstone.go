@@ -0,0 +1,29 @@
+package snowflake
+
+import "fmt"
+
+// Tombstone return the tombstone marker of id: id with the tombstone bit set, sorting right
+// after id and before any later id, e.g. the delete marker of an event sourced record.
+// The layout must reserve the bit, see Layout.TombstoneBit
+func (l Layout) Tombstone(id ID) (ID, error) {
+	if !l.TombstoneBit {
+		return 0, fmt.Errorf("layout reserves no tombstone bit")
+	}
+	if l.IsTombstone(id) {
+		return 0, fmt.Errorf("id %d is already a tombstone", int64(id))
+	}
+	return id | 1, nil
+}
+
+// IsTombstone report whether id is a tombstone marker returned by Tombstone
+func (l Layout) IsTombstone(id ID) bool {
+	return l.TombstoneBit && id&1 != 0
+}
+
+// Original return the id marked by a tombstone, id itself when it is not a tombstone
+func (l Layout) Original(id ID) ID {
+	if l.TombstoneBit {
+		return id &^ 1
+	}
+	return id
+}
@@ -0,0 +1,85 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+func Test_Tombstone(t *testing.T) {
+	l := DefaultLayout
+	l.TombstoneBit = true
+	w, err := NewGenerator(3, 1, WithLayout(l))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	ids, err := w.NextN(5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sorted []int64
+	for _, id := range ids {
+		if l.IsTombstone(ID(id)) {
+			t.Fatalf("issued id %d is a tombstone", id)
+		}
+		tomb, err := l.Tombstone(ID(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, tp := l.Decompose(ID(id)), l.Decompose(tomb)
+		if !tp.Tombstone || p.Tombstone || tp.Sequence != p.Sequence || tp.WorkerID != 3 || tp.DatacenterID != 1 || !tp.Time.Equal(p.Time) {
+			t.Fatalf("Decompose(%d) = %+v, tombstone %+v", id, p, tp)
+		}
+		if l.Original(tomb) != ID(id) || l.Original(ID(id)) != ID(id) {
+			t.Errorf("Original(%d)", tomb)
+		}
+		sorted = append(sorted, id, int64(tomb))
+	}
+	// 墓碑紧随原 id，在下一个 id 之前
+	if !slices.IsSorted(sorted) {
+		t.Error("tombstones do not sort between their id and the next")
+	}
+	if st := w.Stats(); st.MaxSequence > 2047 {
+		t.Errorf("MaxSequence = %d, want at most 2047 with the tombstone bit", st.MaxSequence)
+	}
+	if r := l.Report(); r.IDsPerMilli != 2048 {
+		t.Errorf("IDsPerMilli = %d", r.IDsPerMilli)
+	}
+	if _, err := l.Tombstone(ID(sorted[1])); err == nil {
+		t.Error("Tombstone of a tombstone accepted")
+	}
+	if _, err := DefaultLayout.Tombstone(ID(ids[0])); err == nil || DefaultLayout.IsTombstone(ID(ids[0]|1)) {
+		t.Error("layout without tombstone bit")
+	}
+	if err := (Layout{Epoch: time.UnixMilli(twepoch), TimestampBits: 41, TombstoneBit: true}).Validate(); err == nil {
+		t.Error("tombstone bit without sequence bits accepted")
+	}
+
+	got, err := LayoutFromMetadata(l.Metadata())
+	if err != nil || got != l {
+		t.Errorf("LayoutFromMetadata = %+v, %v", got, err)
+	}
+	if l.Fingerprint() == DefaultLayout.Fingerprint() {
+		t.Error("tombstone bit does not change the fingerprint")
+	}
+
+	// 分片 worker 同样保留墓碑位
+	s, err := NewShardedWorker(3, 1, WithLayout(l))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for range runtime.GOMAXPROCS(0) * 4 {
+		id, err := s.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p := l.Decompose(ID(id)); p.Tombstone || p.WorkerID != 3 || p.DatacenterID != 1 {
+			t.Fatalf("sharded id decoded as %+v", p)
+		}
+	}
+}
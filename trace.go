@@ -0,0 +1,61 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+)
+
+// TraceID 128-bit W3C / OpenTelemetry trace id
+type TraceID [16]byte
+
+// SpanID 64-bit W3C / OpenTelemetry span id
+type SpanID [8]byte
+
+// NewTraceID return trace id whose high 8 bytes are a snowflake id from w, so trace ids
+// sort by start time (friendly to tail-sampling buffers), and low 8 bytes are random as
+// the W3C spec expects from the right-most bytes
+func NewTraceID(w Worker) (TraceID, error) {
+	var t TraceID
+	id, err := w.Next()
+	if err != nil {
+		return t, err
+	}
+	binary.BigEndian.PutUint64(t[:8], uint64(id))
+	if _, err := rand.Read(t[8:]); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+// NewSpanID return span id holding a snowflake id from w, never all zero
+func NewSpanID(w Worker) (SpanID, error) {
+	var s SpanID
+	id, err := w.Next()
+	if err != nil {
+		return s, err
+	}
+	binary.BigEndian.PutUint64(s[:], uint64(id))
+	return s, nil
+}
+
+// String return lower case hex form
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// String return lower case hex form
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// Traceparent return W3C traceparent header value, e.g. "00-<trace id>-<span id>-01"
+func Traceparent(trace TraceID, span SpanID, sampled bool) string {
+	flags := "00"
+	if sampled {
+		flags = "01"
+	}
+	return "00-" + trace.String() + "-" + span.String() + "-" + flags
+}
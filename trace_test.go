@@ -0,0 +1,39 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"encoding/binary"
+	"regexp"
+	"testing"
+)
+
+func Test_TraceID(t *testing.T) {
+	w := NewWorker(0, 0)
+	a, err := NewTraceID(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewTraceID(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b || a.String() >= b.String() {
+		t.Errorf("trace ids should be unique and time ordered: %s, %s", a, b)
+	}
+	if !IsValid(int64(binary.BigEndian.Uint64(a[:8]))) {
+		t.Errorf("trace id %s should start with a snowflake id", a)
+	}
+
+	span, err := NewSpanID(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if span == (SpanID{}) {
+		t.Error("span id must not be zero")
+	}
+	header := Traceparent(a, span, true)
+	if !regexp.MustCompile(`^00-[0-9a-f]{32}-[0-9a-f]{16}-01$`).MatchString(header) {
+		t.Errorf("Traceparent = %q", header)
+	}
+}
@@ -0,0 +1,39 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import "context"
+
+// TryWorker implemented by workers created by NewWorker
+type TryWorker interface {
+	TryNext() (int64, bool)
+}
+
+// TryNext return new id without ever waiting for the next millisecond: it returns false
+// when the current millisecond's sequence is used up (or reserved, see WithPriorityReserve),
+// leaving the retry to event loop style callers. It also returns false when Next would
+// fail, call Next to get the error.
+func (w *worker) TryNext() (int64, bool) {
+	if w.unsignedOnly() {
+		return 0, false
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if len(w.buffered) == 0 && w.reserved(PriorityNormal) {
+		return 0, false
+	}
+	if len(w.buffered) == 0 && w.exhausted() {
+		w.saturation.record(w.lastTimestamp)
+		return 0, false
+	}
+	id, err := signed(w.next(context.Background()))
+	return id, err == nil
+}
+
+// exhausted 下一个 id 是否需要等待下一毫秒，调用方需持有 w.mutex
+func (w *worker) exhausted() bool {
+	if w.timestamp() > w.lastTimestamp || w.sequence != w.layout.sequenceMask() || w.canBorrow() {
+		return false
+	}
+	return !w.randomMachine || !w.canReroll(w.lastTimestamp)
+}
@@ -0,0 +1,37 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import "testing"
+
+func Test_TryNext(t *testing.T) {
+	w, err := newWorker(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frozen := currentMillis() + 1000
+	w.now = func() int64 { return frozen }
+	w.customClock = true
+	var last int64
+	for i := int64(0); i <= w.layout.sequenceMask(); i++ {
+		id, ok := w.TryNext()
+		if !ok || id <= last {
+			t.Fatalf("TryNext #%d = %d, %v", i, id, ok)
+		}
+		last = id
+	}
+	if id, ok := w.TryNext(); ok {
+		t.Fatalf("TryNext after exhausting the sequence = %d, want false", id)
+	}
+	frozen++
+	if id, ok := w.TryNext(); !ok || id <= last {
+		t.Errorf("TryNext in next millisecond = %d, %v", id, ok)
+	}
+	w.Close()
+	if _, ok := w.TryNext(); ok {
+		t.Error("TryNext after Close should fail")
+	}
+	if _, ok := DefaultWorker.(TryWorker); !ok {
+		t.Error("DefaultWorker should implement TryWorker")
+	}
+}
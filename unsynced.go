@@ -0,0 +1,109 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// unsynced 不加锁的 worker，只能由一个 goroutine 使用
+type unsynced struct {
+	w *worker
+}
+
+// NewUnsafeWorker return a generator that skips all locking, for pipelines where a single
+// goroutine issues every id (e.g. one generator goroutine feeding a channel).
+// It is NOT safe for concurrent use: calls from two goroutines race and may return duplicate
+// ids. Options running background checks (WithLeaseCheck, WithSkewGuard) are rejected, and
+// WithPriorityReserve has no effect. Use NewWorker unless profiles show the lock matters.
+func NewUnsafeWorker(workerID, datacenterID uint8, opts ...Option) (Generator, error) {
+	w, err := newWorker(workerID, datacenterID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if w.lease != nil || w.skew != nil {
+		w.Close()
+		return nil, fmt.Errorf("background checks are not supported by unsafe workers")
+	}
+	return &unsynced{w: w}, nil
+}
+
+func (u *unsynced) Next() (int64, error) {
+	if u.w.unsignedOnly() {
+		return 0, fmt.Errorf("layout is unsigned, use NextUint64")
+	}
+	return signed(u.w.next(context.Background()))
+}
+
+// NextUint64 return new id as uint64, see Uint64Worker
+func (u *unsynced) NextUint64() (uint64, error) {
+	return u.w.next(context.Background())
+}
+
+func (u *unsynced) NextContext(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if u.w.unsignedOnly() {
+		return 0, fmt.Errorf("layout is unsigned, use NextUint64")
+	}
+	return signed(u.w.next(ctx))
+}
+
+func (u *unsynced) NextN(n int) ([]int64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid n %d", n)
+	}
+	ids := make([]int64, n)
+	if err := u.Fill(ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (u *unsynced) NextNContext(ctx context.Context, n int) ([]int64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("invalid n %d", n)
+	}
+	ids := make([]int64, 0, min(n, 1<<16))
+	for len(ids) < n {
+		if err := ctx.Err(); err != nil {
+			return ids, err
+		}
+		chunk := min(n-len(ids), nextNChunk)
+		ids = slices.Grow(ids, chunk)
+		if err := u.Fill(ids[len(ids) : len(ids)+chunk]); err != nil {
+			return ids, err
+		}
+		ids = ids[:len(ids)+chunk]
+	}
+	return ids, nil
+}
+
+func (u *unsynced) Fill(dst []int64) error {
+	if u.w.unsignedOnly() {
+		return fmt.Errorf("layout is unsigned, use NextUint64")
+	}
+	for i := range dst {
+		id, err := signed(u.w.next(context.Background()))
+		if err != nil {
+			return err
+		}
+		dst[i] = id
+	}
+	return nil
+}
+
+func (u *unsynced) Close() error {
+	return u.w.Close()
+}
+
+func (u *unsynced) Stats() Stats {
+	return u.w.Stats()
+}
+
+func (u *unsynced) Saturation() float64 {
+	return u.w.Saturation()
+}
@@ -0,0 +1,55 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_NewUnsafeWorker(t *testing.T) {
+	g, err := NewUnsafeWorker(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := g.NextN(3 * (sequenceMask + 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids = append(ids, 0)
+	if ids[len(ids)-1], err = g.Next(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("ids not increasing at %d: %d after %d", i, ids[i], ids[i-1])
+		}
+	}
+	if p := Decompose(ID(ids[0])); p.WorkerID != 2 || p.DatacenterID != 1 {
+		t.Errorf("Decompose = %+v", p)
+	}
+	if s := g.Stats(); s.Issued != uint64(len(ids)) {
+		t.Errorf("Stats().Issued = %d", s.Issued)
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Next(); err != ErrClosed {
+		t.Errorf("after Close: %v", err)
+	}
+
+	checker := LeaseCheckerFunc(func(ctx context.Context) error { return nil })
+	if _, err := NewUnsafeWorker(0, 0, WithLeaseCheck(checker, time.Second, time.Second)); err == nil {
+		t.Error("lease check accepted")
+	}
+}
+
+func Benchmark_NextUnsafe(b *testing.B) {
+	wide := DefaultLayout
+	wide.DatacenterBits, wide.WorkerBits, wide.SequenceBits = 0, 0, 22
+	g, _ := NewUnsafeWorker(0, 0, WithLayout(wide), WithCachedClock())
+	for i := 0; i < b.N; i++ {
+		g.Next()
+	}
+}
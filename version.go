@@ -0,0 +1,53 @@
+package snowflake
+
+import "fmt"
+
+// Schemes layouts of the same bit width told apart by their version bits, so ids of an
+// evolved layout (e.g. a new epoch) and of its predecessors can be decoded side by side
+type Schemes struct {
+	layouts map[uint8]Layout
+	probe   Layout // 读取版本位用，所有布局的版本位位置相同
+}
+
+// NewSchemes return schemes of layouts, which must share VersionBits > 0, total bit width
+// and signedness, and have distinct versions
+func NewSchemes(layouts ...Layout) (*Schemes, error) {
+	if len(layouts) == 0 {
+		return nil, fmt.Errorf("schemes need at least one layout")
+	}
+	s := &Schemes{layouts: make(map[uint8]Layout), probe: layouts[0]}
+	for _, l := range layouts {
+		if err := l.Validate(); err != nil {
+			return nil, err
+		}
+		if l.VersionBits == 0 {
+			return nil, fmt.Errorf("layout version %d has no version bits", l.Version)
+		}
+		if l.VersionBits != s.probe.VersionBits || l.bits() != s.probe.bits() || l.Unsigned != s.probe.Unsigned {
+			return nil, fmt.Errorf("layout version %d puts its version bits elsewhere than version %d", l.Version, s.probe.Version)
+		}
+		if _, ok := s.layouts[l.Version]; ok {
+			return nil, fmt.Errorf("layout version %d registered twice", l.Version)
+		}
+		s.layouts[l.Version] = l
+	}
+	return s, nil
+}
+
+// Layout return the layout id was issued with, selected by its version bits
+func (s *Schemes) Layout(id ID) (Layout, bool) {
+	if !s.probe.Unsigned && id <= 0 || uint64(id)>>s.probe.bits() != 0 {
+		return Layout{}, false
+	}
+	l, ok := s.layouts[s.probe.version(uint64(id))]
+	return l, ok
+}
+
+// Decompose split id with the layout selected by its version bits
+func (s *Schemes) Decompose(id ID) (Parts, error) {
+	l, ok := s.Layout(id)
+	if !ok {
+		return Parts{}, fmt.Errorf("id %d has no known scheme version", id)
+	}
+	return l.Decompose(id), nil
+}
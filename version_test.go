@@ -0,0 +1,83 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Schemes(t *testing.T) {
+	v0 := Layout{Epoch: time.UnixMilli(twepoch), VersionBits: 1, TimestampBits: 40, DatacenterBits: 5, WorkerBits: 5, SequenceBits: 12}
+	v1 := v0
+	v1.Version = 1
+	v1.Epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := v1.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	bad := v0
+	bad.Version = 2
+	if err := bad.Validate(); err == nil {
+		t.Error("version 2 must not fit into 1 version bit")
+	}
+
+	w0, err := newWorker(1, 2, WithLayout(v0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w1, err := newWorker(3, 4, WithLayout(v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id0, _ := w0.Next()
+	id1, _ := w1.Next()
+	if id1 <= id0 {
+		t.Errorf("version 1 id %d not above version 0 id %d", id1, id0)
+	}
+	if !v0.IsValid(id0) || v0.IsValid(id1) || !v1.IsValid(id1) || v1.IsValid(id0) {
+		t.Error("IsValid must check the version")
+	}
+
+	s, err := NewSchemes(v0, v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		id                 int64
+		version            uint8
+		datacenter, worker int64
+	}{{id0, 0, 2, 1}, {id1, 1, 4, 3}} {
+		p, err := s.Decompose(ID(tt.id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Version != tt.version || p.DatacenterID != tt.datacenter || p.WorkerID != tt.worker || time.Since(p.Time).Abs() > time.Minute {
+			t.Errorf("Decompose(%d) = %+v", tt.id, p)
+		}
+	}
+	if _, err := s.Decompose(-1); err == nil {
+		t.Error("expected error for negative id")
+	}
+
+	r := v0.Range(time.UnixMilli(twepoch), v0.Epoch.Add(time.Duration(v0.maxElapsed()+10)*time.Millisecond))
+	if !r.Contains(ID(id0)) || r.Contains(ID(id1)) || r.To != ID(v1.compose(0, 0, 0, 0)) {
+		t.Errorf("range %+v must end where version 1 starts", r)
+	}
+
+	if _, err := NewSchemes(v0, v0); err == nil {
+		t.Error("expected error for duplicate version")
+	}
+	if _, err := NewSchemes(DefaultLayout); err == nil {
+		t.Error("expected error for layout without version bits")
+	}
+	wide := v1
+	wide.TimestampBits = 41
+	if _, err := NewSchemes(v0, wide); err == nil {
+		t.Error("expected error for layouts of different width")
+	}
+
+	got, err := LayoutFromMetadata(v1.Metadata())
+	if err != nil || got.Version != 1 || got.VersionBits != 1 {
+		t.Errorf("LayoutFromMetadata = %+v, %v", got, err)
+	}
+}
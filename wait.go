@@ -0,0 +1,117 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"sync/atomic"
+	"time"
+)
+
+// WaitStrategy how a worker waits for the next millisecond once the sequence is exhausted
+type WaitStrategy int
+
+const (
+	// WaitHybrid sleep until shortly before the next millisecond, then spin (default)
+	WaitHybrid WaitStrategy = iota
+	// WaitSpin busy-wait, lowest latency but burns a full core while waiting
+	WaitSpin
+	// WaitSleep sleep only, cheapest on CPU but may overshoot by the timer granularity
+	WaitSleep
+)
+
+// hybridSpin 混合策略中最后自旋的时长，覆盖 time.Sleep 常见的唤醒误差
+const hybridSpin = 200 * time.Microsecond
+
+// WithWaitStrategy set how the worker waits when the sequence is exhausted, default WaitHybrid
+func WithWaitStrategy(s WaitStrategy) Option {
+	return func(w *worker) {
+		w.wait = s
+	}
+}
+
+// WaitStats statistics of waits for the next millisecond
+type WaitStats struct {
+	Waits  uint64        // number of times the sequence was exhausted
+	Waited time.Duration // total time spent waiting
+}
+
+// WaitReporter implemented by workers created by NewWorker
+type WaitReporter interface {
+	WaitStats() WaitStats
+}
+
+type waitCounter struct {
+	waits  atomic.Uint64
+	waited atomic.Int64
+}
+
+// WaitStats return wait statistics of the worker
+func (w *worker) WaitStats() WaitStats {
+	return WaitStats{Waits: w.waitCounter.waits.Load(), Waited: time.Duration(w.waitCounter.waited.Load())}
+}
+
+// WithProfileLabels run waits for the next millisecond under the pprof labels snowflake_wait
+// (rollover, reserve or restart) and snowflake_worker, so CPU and goroutine profiles of the
+// application attribute that time to the generator. After a wait the goroutine's labels are
+// those of the ctx given to NextContext, none for the other methods: callers that label their
+// own goroutines should pass the labeled ctx to NextContext.
+func WithProfileLabels() Option {
+	return func(w *worker) {
+		w.profileLabels = true
+	}
+}
+
+// tilNextMillis 等待直到时间戳大于 lastTimestamp，reason 为 pprof 标签中的等待原因
+func (w *worker) tilNextMillis(ctx context.Context, lastTimestamp int64, reason string) int64 {
+	start := time.Now()
+	var timestamp int64
+	wait := func(context.Context) {
+		if w.customClock {
+			timestamp = pollNextMillis(lastTimestamp, w.now)
+		} else {
+			timestamp = waitNextMillis(lastTimestamp, w.wait)
+		}
+	}
+	if w.profileLabels {
+		worker := fmt.Sprintf("%d/%d", w.datacenterID, w.workerID)
+		pprof.Do(ctx, pprof.Labels("snowflake_wait", reason, "snowflake_worker", worker), wait)
+	} else {
+		wait(ctx)
+	}
+	w.waitCounter.waits.Add(1)
+	w.waitCounter.waited.Add(int64(time.Since(start)))
+	return timestamp
+}
+
+func waitNextMillis(lastTimestamp int64, s WaitStrategy) int64 {
+	for {
+		now := currentNanos()
+		timestamp := now / 1e6
+		if timestamp > lastTimestamp {
+			return timestamp
+		}
+		remaining := time.Duration((lastTimestamp+1)*1e6 - now)
+		switch {
+		case s == WaitSleep:
+			time.Sleep(remaining)
+		case s == WaitHybrid && remaining > hybridSpin:
+			time.Sleep(remaining - hybridSpin)
+		case s != WaitSpin:
+			runtime.Gosched()
+		}
+	}
+}
+
+// pollNextMillis 轮询自定义时钟直到时间戳大于 lastTimestamp
+func pollNextMillis(lastTimestamp int64, now func() int64) int64 {
+	for {
+		if timestamp := now(); timestamp > lastTimestamp {
+			return timestamp
+		}
+		runtime.Gosched()
+	}
+}
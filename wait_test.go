@@ -0,0 +1,87 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_WaitStrategy(t *testing.T) {
+	for _, s := range []WaitStrategy{WaitHybrid, WaitSpin, WaitSleep} {
+		w := NewWorker(0, 0, WithWaitStrategy(s)).(*worker)
+		// 固定时钟，确保同一毫秒内序列号耗尽至少等待一次
+		frozen := currentMillis()
+		w.now = func() int64 { return frozen }
+		var last int64
+		for i := 0; i < 2*(sequenceMask+1); i++ {
+			id, err := w.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id <= last {
+				t.Fatalf("strategy %d: id %d not greater than previous %d", s, id, last)
+			}
+			last = id
+		}
+		stats := w.WaitStats()
+		if stats.Waits == 0 || stats.Waited <= 0 {
+			t.Errorf("strategy %d: expected waits to be recorded, got %+v", s, stats)
+		}
+	}
+}
+
+func Benchmark_NextWaitSleep(b *testing.B) {
+	worker := NewWorker(0, 0, WithWaitStrategy(WaitSleep))
+	for i := 0; i < b.N; i++ {
+		worker.Next()
+	}
+}
+
+func Test_WithProfileLabels(t *testing.T) {
+	var mu sync.Mutex
+	var profile string
+	var waiting atomic.Bool
+	var reads atomic.Int64
+	base := time.Now()
+	// 第三个 id 读取时钟时序列已耗尽，此后的读取来自等待，抓取带有等待 goroutine 标签的 profile
+	clock := func() time.Time {
+		if waiting.Load() && reads.Add(1) > 1 {
+			mu.Lock()
+			defer mu.Unlock()
+			if profile == "" {
+				var b strings.Builder
+				pprof.Lookup("goroutine").WriteTo(&b, 1)
+				profile = b.String()
+			}
+			return base.Add(time.Millisecond)
+		}
+		return base
+	}
+	l := DefaultLayout
+	l.SequenceBits = 1
+	g, err := NewGenerator(2, 1, WithLayout(l), WithClock(clock), WithProfileLabels())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := pprof.WithLabels(context.Background(), pprof.Labels("request", "checkout"))
+	for range 2 {
+		if _, err := g.NextContext(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waiting.Store(true)
+	if _, err := g.NextContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, label := range []string{`"snowflake_wait":"rollover"`, `"snowflake_worker":"1/2"`, `"request":"checkout"`} {
+		if !strings.Contains(profile, label) {
+			t.Errorf("goroutine profile lacks %s:\n%s", label, profile)
+		}
+	}
+}
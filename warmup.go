@@ -0,0 +1,52 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"fmt"
+)
+
+// Warmer implemented by workers created by NewWorker and NewGenerator
+type Warmer interface {
+	Warmup(n int) error
+}
+
+// Warmup prepare the worker for latency critical first requests after a deploy: it reads the
+// clock, runs the issuing path and issues the next n ids into a buffer that Next and the
+// batch methods hand out before issuing new ones; Warmup(0) only reads the clock. Buffered
+// ids carry the warmup time and stay ordered before later ids; Stats and WithRecorder count
+// them once handed out.
+func (w *worker) Warmup(n int) error {
+	if n < 0 {
+		return fmt.Errorf("invalid n %d", n)
+	}
+	w.now()
+	if n == 0 {
+		return nil
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	// 先移开已有的缓冲，next 才会签发新的 id，之后接在其后
+	pending := w.buffered
+	w.buffered = nil
+	ids := make([]int64, 0, n)
+	var err error
+	// 预生成的 id 在发出时才计入统计和 Recorder，见 takeBuffered
+	w.warming = true
+	defer func() { w.warming = false }()
+	for range n {
+		var id uint64
+		if id, err = w.next(context.Background()); err != nil {
+			break
+		}
+		ids = append(ids, int64(id))
+	}
+	w.buffered = append(pending, ids...)
+	return err
+}
+
+// Warmup create DefaultWorker and warm it up, see Warmer
+func Warmup(n int) error {
+	return defaultWorker().Warmup(n)
+}
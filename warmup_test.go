@@ -0,0 +1,74 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func Test_Warmup(t *testing.T) {
+	now := time.UnixMilli(twepoch + 1000)
+	rec := &Recorder{}
+	w, err := NewGenerator(1, 1, WithClock(func() time.Time { return now }), WithRecorder(rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.(Warmer).Warmup(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.(Warmer).Warmup(0); err != nil {
+		t.Fatal(err)
+	}
+	if st := w.Stats(); st.Issued != 0 || st.MaxSequence != 0 {
+		t.Errorf("Stats after Warmup = %+v", st)
+	}
+	if steps := rec.Schedule().Steps; len(steps) != 0 {
+		t.Errorf("recorded %d steps before handing out", len(steps))
+	}
+	now = now.Add(time.Millisecond)
+	ids, err := w.NextN(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 前 3 个为预生成的 id，时间为预热时刻；Warmup(0) 不预生成
+	for i, id := range ids {
+		p := DefaultLayout.Decompose(ID(id))
+		if want := i < 3; p.Time.Equal(now.Add(-time.Millisecond)) != want {
+			t.Errorf("id %d issued at %v", i, p.Time)
+		}
+	}
+	if !slices.IsSorted(ids) || len(slices.Compact(slices.Clone(ids))) != len(ids) {
+		t.Errorf("ids = %v", ids)
+	}
+	if st := w.Stats(); st.Issued != 6 {
+		t.Errorf("Issued = %d", st.Issued)
+	}
+	steps := rec.Schedule().Steps
+	if len(steps) != len(ids) {
+		t.Fatalf("recorded %d steps, want %d", len(steps), len(ids))
+	}
+	for i, s := range steps {
+		p := DefaultLayout.Decompose(ID(ids[i]))
+		if s.Millis != p.Time.UnixMilli() || s.Sequence != p.Sequence || s.WorkerID != p.WorkerID {
+			t.Errorf("step %d = %+v, id parts %+v", i, s, p)
+		}
+	}
+	if err := w.(Warmer).Warmup(-1); err == nil {
+		t.Error("Warmup(-1) accepted")
+	}
+
+	cached, err := NewGenerator(1, 1, WithCachedClock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cached.Close()
+	if err := cached.(Warmer).Warmup(0); err != nil {
+		t.Fatal(err)
+	}
+	if coarse.ms.Load() == 0 {
+		t.Error("cached clock not started")
+	}
+}
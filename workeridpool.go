@@ -0,0 +1,295 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mrand "math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrPoolExhausted returned by WorkerIDPool.Borrow when every worker id of the pool is leased
+var ErrPoolExhausted = errors.New("snowflake: no worker id left in the pool")
+
+// WorkerIDStore shared lease table behind a WorkerIDPool, one entry per leased worker id;
+// every method must be atomic across the processes sharing the store
+type WorkerIDStore interface {
+	// Claim lease id to holder for ttl, false when a lease or cooldown on id is still live
+	Claim(ctx context.Context, id int64, holder string, ttl time.Duration) (bool, error)
+	// Renew extend holder's lease on id to ttl from now, false when holder no longer holds it
+	Renew(ctx context.Context, id int64, holder string, ttl time.Duration) (bool, error)
+	// Release end holder's lease on id, keeping id unassignable for cooldown;
+	// a no-op when holder no longer holds it
+	Release(ctx context.Context, id int64, holder string, cooldown time.Duration) error
+}
+
+// WorkerIDPool shared pool of worker ids [First, Last] that autoscaling processes borrow on
+// top of their own during bursts and return afterwards. Each borrowed id is leased in Store
+// for TTL and renewed by the generators created on it, so an id is never assigned twice:
+// another process can only claim it after the lease expired (the holder's generators stop
+// within TTL/2 of their last renewal) or after it was returned and Cooldown passed.
+// Keep the clocks of the processes within Cooldown of each other, e.g. with WithSkewGuard.
+type WorkerIDPool struct {
+	Store       WorkerIDStore
+	First, Last uint8         // worker ids of the pool, inclusive
+	TTL         time.Duration // lease expiry in the store, default 10s
+	Cooldown    time.Duration // time a returned id stays unassignable, default 1s
+	Holder      string        // prefix of the lease holders stored for this process, default the hostname
+
+	mu       sync.Mutex
+	borrowed map[uint8]*BorrowedWorkerID
+}
+
+// BorrowedWorkerID a worker id leased from a WorkerIDPool. As a LeaseChecker it renews the lease
+// and fails with ErrLeaseLost once another holder took the id over
+type BorrowedWorkerID struct {
+	WorkerID uint8
+
+	pool   *WorkerIDPool
+	holder string
+
+	mu        sync.Mutex
+	generator Generator // 同一 worker id 只能有一个生成器，否则会签发相同的 id
+	returned  bool
+}
+
+func (p *WorkerIDPool) ttl() time.Duration {
+	if p.TTL > 0 {
+		return p.TTL
+	}
+	return 10 * time.Second
+}
+
+func (p *WorkerIDPool) cooldown() time.Duration {
+	if p.Cooldown > 0 {
+		return p.Cooldown
+	}
+	return time.Second
+}
+
+// holder 本次租用的持有者标识，随机后缀区分同一进程的多次租用
+func (p *WorkerIDPool) holder() string {
+	prefix := p.Holder
+	if prefix == "" {
+		prefix, _ = os.Hostname()
+	}
+	var b [8]byte
+	rand.Read(b[:])
+	return prefix + "/" + strconv.Itoa(os.Getpid()) + "/" + hex.EncodeToString(b[:])
+}
+
+// Borrow lease a free worker id of the pool, ErrPoolExhausted when none is left
+func (p *WorkerIDPool) Borrow(ctx context.Context) (*BorrowedWorkerID, error) {
+	if p.First > p.Last {
+		return nil, fmt.Errorf("invalid pool worker ids %d-%d", p.First, p.Last)
+	}
+	holder := p.holder()
+	n := int(p.Last) - int(p.First) + 1
+	// 从随机位置开始尝试，减少同时扩容的进程争抢同一个 id
+	start := mrand.IntN(n)
+	for i := range n {
+		id := p.First + uint8((start+i)%n)
+		ok, err := p.Store.Claim(ctx, int64(id), holder, p.ttl())
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		b := &BorrowedWorkerID{WorkerID: id, pool: p, holder: holder}
+		p.mu.Lock()
+		if p.borrowed == nil {
+			p.borrowed = make(map[uint8]*BorrowedWorkerID)
+		}
+		p.borrowed[id] = b
+		p.mu.Unlock()
+		return b, nil
+	}
+	return nil, ErrPoolExhausted
+}
+
+// Borrowed return the worker ids this process currently borrows from the pool
+func (p *WorkerIDPool) Borrowed() []uint8 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ids := make([]uint8, 0, len(p.borrowed))
+	for id := range p.borrowed {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// CheckLease renew the lease, ErrLeaseLost once another holder took the id over
+func (b *BorrowedWorkerID) CheckLease(ctx context.Context) error {
+	ok, err := b.pool.Store.Renew(ctx, int64(b.WorkerID), b.holder, b.pool.ttl())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// NewGenerator create the generator of the borrowed worker id, fenced by the lease: it renews
+// the lease every TTL/3 and stops issuing TTL/2 after the last renewal. A borrowed id backs
+// one generator only, later calls fail; borrow another id for more throughput
+func (b *BorrowedWorkerID) NewGenerator(datacenterID uint8, opts ...Option) (Generator, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.returned {
+		return nil, ErrLeaseLost
+	}
+	if b.generator != nil {
+		return nil, fmt.Errorf("borrowed worker id %d already has a generator", b.WorkerID)
+	}
+	ttl := b.pool.ttl()
+	g, err := NewGenerator(b.WorkerID, datacenterID, append(opts, WithLeaseCheck(b, ttl/3, ttl/2))...)
+	if err != nil {
+		return nil, err
+	}
+	b.generator = g
+	return g, nil
+}
+
+// Return close the generator created on the id and give it back to the pool;
+// other processes can borrow it once the pool's Cooldown passed
+func (b *BorrowedWorkerID) Return(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.returned {
+		return nil
+	}
+	if b.generator != nil {
+		b.generator.Close()
+	}
+	if err := b.pool.Store.Release(ctx, int64(b.WorkerID), b.holder, b.pool.cooldown()); err != nil {
+		return err
+	}
+	b.returned = true
+	b.pool.mu.Lock()
+	delete(b.pool.borrowed, b.WorkerID)
+	b.pool.mu.Unlock()
+	return nil
+}
+
+// MemoryWorkerIDStore WorkerIDStore for worker id pools shared within one process
+type MemoryWorkerIDStore struct {
+	mu     sync.Mutex
+	leases map[int64]memoryLease
+	now    func() time.Time
+}
+
+type memoryLease struct {
+	holder  string // 空表示归还后的冷却期
+	expires time.Time
+}
+
+// NewMemoryWorkerIDStore return an empty in-memory store
+func NewMemoryWorkerIDStore() *MemoryWorkerIDStore {
+	return &MemoryWorkerIDStore{leases: make(map[int64]memoryLease), now: time.Now}
+}
+
+// live 返回 id 上未过期的租约，调用方需持有 s.mu
+func (s *MemoryWorkerIDStore) live(id int64) (memoryLease, bool) {
+	l, ok := s.leases[id]
+	if ok && !s.now().Before(l.expires) {
+		delete(s.leases, id)
+		return memoryLease{}, false
+	}
+	return l, ok
+}
+
+// Claim lease id to holder unless a live lease or cooldown exists
+func (s *MemoryWorkerIDStore) Claim(ctx context.Context, id int64, holder string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.live(id); ok {
+		return false, nil
+	}
+	s.leases[id] = memoryLease{holder: holder, expires: s.now().Add(ttl)}
+	return true, nil
+}
+
+// Renew extend holder's live lease on id
+func (s *MemoryWorkerIDStore) Renew(ctx context.Context, id int64, holder string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.live(id); !ok || l.holder != holder {
+		return false, nil
+	}
+	s.leases[id] = memoryLease{holder: holder, expires: s.now().Add(ttl)}
+	return true, nil
+}
+
+// Release replace holder's lease on id with a cooldown
+func (s *MemoryWorkerIDStore) Release(ctx context.Context, id int64, holder string, cooldown time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.live(id); !ok || l.holder != holder {
+		return nil
+	}
+	s.leases[id] = memoryLease{expires: s.now().Add(cooldown)}
+	return nil
+}
+
+// redisRenewScript 仅在持有者未变时续期
+const redisRenewScript = `if redis.call('GET', KEYS[1]) == ARGV[1] then return redis.call('PEXPIRE', KEYS[1], ARGV[2]) end return 0`
+
+// redisReleaseScript 仅在持有者未变时把租约换成冷却期
+const redisReleaseScript = `if redis.call('GET', KEYS[1]) == ARGV[1] then redis.call('SET', KEYS[1], '', 'PX', ARGV[2]) return 1 end return 0`
+
+// RedisWorkerIDStore WorkerIDStore keeping one key prefix:<worker id> per lease on a Redis server
+type RedisWorkerIDStore struct {
+	c      redisConn
+	prefix string
+}
+
+// NewRedisWorkerIDStore return a store on the Redis server at addr (host:port); password and db
+// are optional (empty and 0), timeout bounds each command. Include the datacenter id in prefix
+// when pools of several datacenters share the server
+func NewRedisWorkerIDStore(addr, password string, db int, prefix string, timeout time.Duration) *RedisWorkerIDStore {
+	return &RedisWorkerIDStore{c: redisConn{addr: addr, password: password, db: db, timeout: timeout}, prefix: prefix}
+}
+
+func (s *RedisWorkerIDStore) key(id int64) string {
+	return s.prefix + ":" + strconv.FormatInt(id, 10)
+}
+
+// Claim SET the key with NX and PX ttl
+func (s *RedisWorkerIDStore) Claim(ctx context.Context, id int64, holder string, ttl time.Duration) (bool, error) {
+	reply, err := s.c.do(ctx, "SET", s.key(id), holder, "NX", "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	if err != nil {
+		return false, err
+	}
+	return reply != nil, nil
+}
+
+// Renew PEXPIRE the key while it still holds holder
+func (s *RedisWorkerIDStore) Renew(ctx context.Context, id int64, holder string, ttl time.Duration) (bool, error) {
+	reply, err := s.c.do(ctx, "EVAL", redisRenewScript, "1", s.key(id), holder, strconv.FormatInt(ttl.Milliseconds(), 10))
+	if err != nil {
+		return false, err
+	}
+	return reply == int64(1), nil
+}
+
+// Release overwrite the key with an empty cooldown entry while it still holds holder
+func (s *RedisWorkerIDStore) Release(ctx context.Context, id int64, holder string, cooldown time.Duration) error {
+	_, err := s.c.do(ctx, "EVAL", redisReleaseScript, "1", s.key(id), holder, strconv.FormatInt(max(cooldown.Milliseconds(), 1), 10))
+	return err
+}
+
+// Close close the connection
+func (s *RedisWorkerIDStore) Close() error {
+	return s.c.close()
+}
@@ -0,0 +1,147 @@
+//go:build !snowflake_decode
+
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_WorkerIDPool(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	now := time.Now()
+	store := NewMemoryWorkerIDStore()
+	store.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		now = now.Add(d)
+		mu.Unlock()
+	}
+	p := &WorkerIDPool{Store: store, First: 20, Last: 21, TTL: time.Minute, Cooldown: time.Second, Holder: "a"}
+	other := &WorkerIDPool{Store: store, First: 20, Last: 21, TTL: time.Minute, Holder: "b"}
+
+	b1, err := p.Borrow(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2, err := p.Borrow(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b1.WorkerID == b2.WorkerID {
+		t.Fatalf("worker id %d borrowed twice", b1.WorkerID)
+	}
+	if got := p.Borrowed(); !reflect.DeepEqual(got, []uint8{20, 21}) {
+		t.Errorf("Borrowed = %v", got)
+	}
+	if _, err := other.Borrow(ctx); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("Borrow from full pool = %v", err)
+	}
+
+	g, err := b1.NewGenerator(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := g.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b1.NewGenerator(3); err == nil {
+		t.Error("second generator on a borrowed id should fail")
+	}
+	if p := DefaultLayout.Decompose(ID(id)); p.WorkerID != int64(b1.WorkerID) || p.DatacenterID != 3 {
+		t.Errorf("id %d from worker %d datacenter %d", id, p.WorkerID, p.DatacenterID)
+	}
+
+	// 归还后冷却期内不能再分配，生成器随之关闭
+	if err := b1.Return(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Next(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Next after Return = %v", err)
+	}
+	if _, err := b1.NewGenerator(3); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("NewGenerator after Return = %v", err)
+	}
+	if got := p.Borrowed(); !reflect.DeepEqual(got, []uint8{b2.WorkerID}) {
+		t.Errorf("Borrowed after Return = %v", got)
+	}
+	if _, err := other.Borrow(ctx); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("Borrow during cooldown = %v", err)
+	}
+	advance(time.Second)
+	b3, err := other.Borrow(ctx)
+	if err != nil || b3.WorkerID != b1.WorkerID {
+		t.Fatalf("Borrow after cooldown = %v, %v", b3, err)
+	}
+
+	// 租约过期后被他人取得，原持有者续期失败
+	if err := b2.CheckLease(ctx); err != nil {
+		t.Fatal(err)
+	}
+	advance(time.Minute)
+	if err := b3.CheckLease(ctx); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("CheckLease after expiry = %v", err)
+	}
+	b4, err := other.Borrow(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b2.CheckLease(ctx); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("CheckLease after takeover = %v", err)
+	}
+	// 过期的持有者归还不影响新的租约
+	if err := b2.Return(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := b4.CheckLease(ctx); err != nil {
+		t.Errorf("CheckLease after stale Return = %v", err)
+	}
+
+	if _, err := (&WorkerIDPool{Store: store, First: 2, Last: 1}).Borrow(ctx); err == nil {
+		t.Error("empty pool should fail")
+	}
+}
+
+func Test_RedisWorkerIDStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewRedisWorkerIDStore(fakeRedis(t), "", 0, "snowflake:ids:1", time.Second)
+	defer s.Close()
+	if ok, err := s.Claim(ctx, 7, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("Claim = %v, %v", ok, err)
+	}
+	if ok, err := s.Claim(ctx, 7, "b", time.Minute); err != nil || ok {
+		t.Errorf("Claim of leased id = %v, %v", ok, err)
+	}
+	if ok, err := s.Renew(ctx, 7, "a", time.Minute); err != nil || !ok {
+		t.Errorf("Renew = %v, %v", ok, err)
+	}
+	if ok, err := s.Renew(ctx, 7, "b", time.Minute); err != nil || ok {
+		t.Errorf("Renew by other holder = %v, %v", ok, err)
+	}
+	if err := s.Release(ctx, 7, "b", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := s.Renew(ctx, 7, "a", time.Minute); !ok {
+		t.Error("Release by other holder ended the lease")
+	}
+	if err := s.Release(ctx, 7, "a", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := s.Renew(ctx, 7, "a", time.Minute); ok {
+		t.Error("Renew after Release")
+	}
+	// 冷却期内键仍存在
+	if ok, _ := s.Claim(ctx, 7, "b", time.Minute); ok {
+		t.Error("Claim during cooldown")
+	}
+}
@@ -0,0 +1,56 @@
+package snowflake
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// xteaRounds XTEA 标准 32 轮(64 次 Feistel)
+const (
+	xteaRounds = 32
+	xteaDelta  = 0x9E3779B9
+)
+
+// EncryptID encrypt id with XTEA (64-bit block, 128-bit key) so that externally visible
+// ids are unlinkable and unguessable while still fitting an int64 column; the result may be negative
+func EncryptID(id ID, key []byte) (int64, error) {
+	k, err := xteaKey(key)
+	if err != nil {
+		return 0, err
+	}
+	v0, v1 := uint32(uint64(id)>>32), uint32(id)
+	var sum uint32
+	for i := 0; i < xteaRounds; i++ {
+		v0 += (v1<<4 ^ v1>>5 + v1) ^ (sum + k[sum&3])
+		sum += xteaDelta
+		v1 += (v0<<4 ^ v0>>5 + v0) ^ (sum + k[sum>>11&3])
+	}
+	return int64(uint64(v0)<<32 | uint64(v1)), nil
+}
+
+// DecryptID reverse EncryptID
+func DecryptID(v int64, key []byte) (ID, error) {
+	k, err := xteaKey(key)
+	if err != nil {
+		return 0, err
+	}
+	v0, v1 := uint32(uint64(v)>>32), uint32(v)
+	sum := uint32(0xC6EF3720) // xteaDelta * xteaRounds, 截断到 32 位
+	for i := 0; i < xteaRounds; i++ {
+		v1 -= (v0<<4 ^ v0>>5 + v0) ^ (sum + k[sum>>11&3])
+		sum -= xteaDelta
+		v0 -= (v1<<4 ^ v1>>5 + v1) ^ (sum + k[sum&3])
+	}
+	return ID(uint64(v0)<<32 | uint64(v1)), nil
+}
+
+func xteaKey(key []byte) ([4]uint32, error) {
+	var k [4]uint32
+	if len(key) != 16 {
+		return k, fmt.Errorf("XTEA key must be 16 bytes, got %d", len(key))
+	}
+	for i := range k {
+		k[i] = binary.BigEndian.Uint32(key[4*i:])
+	}
+	return k, nil
+}
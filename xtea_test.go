@@ -0,0 +1,34 @@
+package snowflake
+
+import (
+	"testing"
+	"testing/quick"
+)
+
+func Test_EncryptID(t *testing.T) {
+	key := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f}
+	// XTEA 参考向量: "ABCDEFGH" -> 497df3d072612cb5
+	got, err := EncryptID(0x4142434445464748, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uint64(got) != 0x497df3d072612cb5 {
+		t.Errorf("EncryptID = %x, want 497df3d072612cb5", uint64(got))
+	}
+
+	roundTrip := func(v int64) bool {
+		enc, err := EncryptID(ID(v), key)
+		if err != nil {
+			return false
+		}
+		dec, err := DecryptID(enc, key)
+		return err == nil && dec == ID(v)
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := EncryptID(1, key[:8]); err == nil {
+		t.Error("expected error for short key")
+	}
+}